@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"rss-en-to-jp-notification/service"
+)
+
+//go:embed web/admin.html.tmpl
+var adminPageSource string
+
+// adminPage は管理画面のテンプレート
+var adminPage = template.Must(template.New("admin").Funcs(adminFuncs()).Parse(adminPageSource))
+
+// adminPageData は管理画面のテンプレートに渡すデータ
+type adminPageData struct {
+	Authorized  bool
+	Message     string
+	Error       string
+	Feeds       []*feedStatus
+	Results     []*service.ArchivedArticle
+	Outbox      []*outboxItem
+	DeadLetters bool
+	PreviewLink string
+	Preview     *articlePreview
+}
+
+// adminFuncs は管理画面のテンプレートで使える関数（通知のテンプレートの関数に表示用の関数を加える）
+func adminFuncs() template.FuncMap {
+	funcs := template.FuncMap(service.TemplateFuncs())
+	funcs["time"] = formatTime
+	// json はメッセージの本文を読みやすいように整形する
+	funcs["json"] = func(body json.RawMessage) string {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, body, "", "  "); err != nil {
+			return string(body)
+		}
+		return buf.String()
+	}
+	return funcs
+}
+
+// registerAdmin は管理画面のハンドラーを登録する
+func (app *App) registerAdmin(mux *http.ServeMux) {
+	mux.HandleFunc("/admin", app.adminIndex)
+	mux.HandleFunc("/admin/login", app.adminLogin)
+	mux.Handle("/admin/logout", app.requireAdmin(app.adminLogout))
+	mux.Handle("/admin/run", app.requireAdmin(app.adminRun))
+	mux.Handle("/admin/replay", app.requireAdmin(app.adminReplay))
+	mux.Handle("/admin/preview", app.requireAdmin(app.adminPreview))
+}
+
+// requireAdmin は管理画面のフォームの送信先を、ログイン済みのPOSTリクエストに限定する
+// （ログインしていない場合はログイン画面に戻す）
+func (app *App) requireAdmin(h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !app.authorized(r) {
+			http.Redirect(w, r, "/admin", http.StatusSeeOther)
+			return
+		}
+		h(w, r)
+	})
+}
+
+// adminIndex は管理画面を表示する（ログインしていない場合はログイン画面）
+func (app *App) adminIndex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !app.authorized(r) {
+		app.renderAdmin(w, http.StatusUnauthorized, &adminPageData{})
+		return
+	}
+	app.renderAdmin(w, http.StatusOK, &adminPageData{Message: r.URL.Query().Get("msg")})
+}
+
+// adminLogin は認証トークンを確認し、以降のリクエストで使うCookieを設定する
+func (app *App) adminLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := r.PostFormValue("token")
+	if !app.validToken(token) {
+		log.Printf("WARNING: 管理画面へのログインに失敗しました（%s）", r.RemoteAddr)
+		app.renderAdmin(w, http.StatusUnauthorized, &adminPageData{Error: "認証トークンが正しくありません"})
+		return
+	}
+
+	// 他のサイトからのフォーム送信にはCookieを付けない（SameSite=Strict）
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminTokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// adminLogout はCookieを削除してログイン画面に戻す
+func (app *App) adminLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: adminTokenCookie, Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// adminRun はRSSチェックの即時実行を要求する
+func (app *App) adminRun(w http.ResponseWriter, r *http.Request) {
+	message := "RSS チェックの実行を要求しました"
+	if !app.requestRun() {
+		message = "RSS チェックの実行はすでに要求されています"
+	}
+	redirectAdmin(w, r, message)
+}
+
+// adminReplay はデッドレターを送信待ちに戻す
+func (app *App) adminReplay(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PostFormValue("id"))
+	replayed, err := app.replayDeadLetters(id, r.PostFormValue("all") != "")
+	if err != nil {
+		app.renderAdmin(w, http.StatusNotFound, &adminPageData{Error: "デッドレターの再送に失敗しました: " + err.Error()})
+		return
+	}
+	redirectAdmin(w, r, strconv.Itoa(replayed)+"件のデッドレターを送信待ちに戻しました（次回の実行で送信します）")
+}
+
+// adminPreview は記事を翻訳・要約した結果と通知するメッセージを表示する
+func (app *App) adminPreview(w http.ResponseWriter, r *http.Request) {
+	link := r.PostFormValue("link")
+	preview, err := app.previewArticle(previewRequest{Link: link})
+	if err != nil {
+		app.renderAdmin(w, http.StatusOK, &adminPageData{PreviewLink: link, Error: "記事のプレビューに失敗しました: " + err.Error()})
+		return
+	}
+	app.renderAdmin(w, http.StatusOK, &adminPageData{PreviewLink: link, Preview: preview})
+}
+
+// renderAdmin は管理画面を表示する（ログイン済みの場合はフィード・記事・送信箱の一覧を読み込む）
+func (app *App) renderAdmin(w http.ResponseWriter, status int, data *adminPageData) {
+	data.Authorized = status != http.StatusUnauthorized
+	if data.Authorized {
+		data.Feeds = app.feedStatuses()
+		data.Outbox = app.outboxItems()
+		for _, item := range data.Outbox {
+			if item.Status == "dead" {
+				data.DeadLetters = true
+			}
+		}
+		results, err := app.recentResults(defaultRecentResults)
+		if err != nil {
+			log.Printf("ERROR: 最近の記事の取得に失敗しました: %v", err)
+		}
+		data.Results = results
+	}
+
+	var buf bytes.Buffer
+	if err := adminPage.Execute(&buf, data); err != nil {
+		log.Printf("ERROR: 管理画面の表示に失敗しました: %v", err)
+		http.Error(w, "failed to render admin page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// redirectAdmin は操作の結果を表示するため管理画面にリダイレクトする（POST後の再送信を防ぐ）
+func redirectAdmin(w http.ResponseWriter, r *http.Request, message string) {
+	http.Redirect(w, r, "/admin?msg="+url.QueryEscape(message), http.StatusSeeOther)
+}
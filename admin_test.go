@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+// newAdminClient はCookieを保持し、リダイレクト先まで辿るクライアントを返す
+func newAdminClient(t *testing.T) *http.Client {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar}
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestAdminLogin(t *testing.T) {
+	_, server := newAPITestServer(t, newTestEnv(t))
+	client := newAdminClient(t)
+
+	resp, err := client.Get(server.URL + "/admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized || !strings.Contains(body, `name="token"`) {
+		t.Errorf("GET /admin without login = %d, want the login form", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(server.URL+"/admin/login", url.Values{"token": {"wrong-token"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusUnauthorized || !strings.Contains(body, "認証トークンが正しくありません") {
+		t.Errorf("POST /admin/login with a wrong token = %d", resp.StatusCode)
+	}
+
+	// ログインしていない場合は操作を受け付けない
+	resp, err = client.PostForm(server.URL+"/admin/run", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /admin/run without login = %d, want the login form", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(server.URL+"/admin/login", url.Values{"token": {testAdminToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || !strings.Contains(body, "ログアウト") {
+		t.Errorf("POST /admin/login = %d, want the admin page", resp.StatusCode)
+	}
+
+	resp, err = client.PostForm(server.URL+"/admin/logout", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readBody(t, resp)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /admin after logout = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestAdminPage(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app, server := newAPITestServer(t, env)
+	app.RunOnce()
+
+	client := newAdminClient(t)
+	resp, err := client.PostForm(server.URL+"/admin/login", url.Values{"token": {testAdminToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := readBody(t, resp)
+	for _, want := range []string{env.feed.URL, "[JA] Understanding Caches", "送信箱"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("admin page does not contain %q", want)
+		}
+	}
+
+	resp, err = client.PostForm(server.URL+"/admin/run", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "RSS チェックの実行を要求しました") {
+		t.Error("admin page does not show the result of the run request")
+	}
+	select {
+	case <-app.trigger:
+	default:
+		t.Error("run was not requested")
+	}
+
+	resp, err = client.PostForm(server.URL+"/admin/preview", url.Values{"link": {"https://example.com/caches"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := readBody(t, resp); !strings.Contains(body, "テスト用の要約です。") {
+		t.Error("admin page does not show the preview")
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/service"
+)
+
+// adminTokenCookie は管理画面でログインした際に認証トークンを保存するCookieの名前
+const adminTokenCookie = "admin_token"
+
+// defaultRecentResults は最近の記事の一覧に表示する件数の既定値
+const defaultRecentResults = 20
+
+// errArticleNotFound はプレビューする記事が設定済みのフィードに見つからない場合のエラー
+var errArticleNotFound = errors.New("article not found in the configured feeds")
+
+// errFeedNotFound はプレビューに指定したフィードが設定されていない場合のエラー
+var errFeedNotFound = errors.New("feed is not configured")
+
+// feedStatus は管理APIで返すフィードの設定と健全性
+type feedStatus struct {
+	URL                 string    `json:"url"`
+	Name                string    `json:"name"`
+	Type                string    `json:"type"`
+	Status              string    `json:"status"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastItemAt          time.Time `json:"last_item_at"`
+	LastError           string    `json:"last_error,omitempty"`
+}
+
+// outboxItem は管理APIで返す送信箱のメッセージ（メッセージの本文は含めない）
+type outboxItem struct {
+	ID          int       `json:"id"`
+	Status      string    `json:"status"` // pending or dead
+	Destination string    `json:"destination"`
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// previewRequest はプレビューする記事（titleを省略した場合は設定済みのフィードからlinkの記事を探す）
+type previewRequest struct {
+	Link        string `json:"link"`
+	FeedURL     string `json:"feed_url,omitempty"` // 記事を探すフィード（省略した場合はすべてのフィード）
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// articlePreview は記事を翻訳・要約した結果と、通知先ごとに送信するメッセージ
+type articlePreview struct {
+	Result   *service.TranslationResult    `json:"result"`
+	Messages map[string][]*service.Message `json:"messages"` // 通知先の名前ごと（メールの通知先は含まない）
+}
+
+// registerAPI は管理APIのハンドラーを登録する
+func (app *App) registerAPI(mux *http.ServeMux) {
+	mux.Handle("/api/feeds", app.requireToken(http.MethodGet, app.apiFeeds))
+	mux.Handle("/api/run", app.requireToken(http.MethodPost, app.apiRun))
+	mux.Handle("/api/preview", app.requireToken(http.MethodPost, app.apiPreview))
+	mux.Handle("/api/results", app.requireToken(http.MethodGet, app.apiResults))
+	mux.Handle("/api/outbox", app.requireToken(http.MethodGet, app.apiOutbox))
+	mux.Handle("/api/outbox/replay", app.requireToken(http.MethodPost, app.apiReplay))
+}
+
+// requireToken は認証トークンとHTTPメソッドを確認してからハンドラーを呼び出す
+// （トークンはAuthorization: Bearerヘッダーか、管理画面でログインした際のCookieで渡す）
+func (app *App) requireToken(method string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rss-en-to-jp-notification"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		h(w, r)
+	})
+}
+
+// authorized はリクエストに正しい認証トークンが含まれているかを判定する
+func (app *App) authorized(r *http.Request) bool {
+	token := ""
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	} else if cookie, err := r.Cookie(adminTokenCookie); err == nil {
+		token = cookie.Value
+	}
+	return app.validToken(token)
+}
+
+// validToken はトークンが設定値と一致するかを判定する（比較にかかる時間から推測されないようにする）
+func (app *App) validToken(token string) bool {
+	return app.config.AdminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(app.config.AdminToken)) == 1
+}
+
+// apiFeeds は設定済みのフィードと健全性を返す
+func (app *App) apiFeeds(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"feeds": app.feedStatuses()})
+}
+
+// apiRun はRSSチェックの即時実行を要求する（デーモンのループで実行するため、完了を待たずに202を返す）
+func (app *App) apiRun(w http.ResponseWriter, r *http.Request) {
+	if !app.requestRun() {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "a run has already been requested"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "queued"})
+}
+
+// apiPreview は記事を翻訳・要約し、通知せずに送信するメッセージを返す
+func (app *App) apiPreview(w http.ResponseWriter, r *http.Request) {
+	var req previewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Link == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "link is required"})
+		return
+	}
+
+	preview, err := app.previewArticle(req)
+	if errors.Is(err, errArticleNotFound) || errors.Is(err, errFeedNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: 記事のプレビューに失敗しました: %v", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, preview)
+}
+
+// apiResults は最近翻訳・要約した記事を新しい順に返す（?limit=件数。件数は最大100件）
+func (app *App) apiResults(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRecentResults
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = min(n, maxResultsLimit)
+	}
+
+	articles, err := app.recentResults(limit)
+	if err != nil {
+		log.Printf("ERROR: 最近の記事の取得に失敗しました: %v", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to read results"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"articles": articles})
+}
+
+// apiOutbox は送信箱の送信待ちとデッドレターを返す
+func (app *App) apiOutbox(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"entries": app.outboxItems()})
+}
+
+// apiReplay はデッドレターを送信待ちに戻す（{"id": 12} または {"all": true}。次回の実行で送信する）
+func (app *App) apiReplay(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID  int  `json:"id"`
+		All bool `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.ID == 0 && !req.All) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "id or all is required"})
+		return
+	}
+
+	replayed, err := app.replayDeadLetters(req.ID, req.All)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"replayed": replayed})
+}
+
+// feedStatuses は設定済みのフィードと健全性の一覧を作成する（実行中のRSSチェックを待たない）
+func (app *App) feedStatuses() []*feedStatus {
+	now := time.Now()
+	infos := app.feedService.Feeds()
+	statuses := make([]*feedStatus, 0, len(app.config.Feeds))
+	for i, feed := range app.config.Feeds {
+		status := &feedStatus{URL: feed.URL, Name: infos[i].Name, Type: feed.Type, Status: "未取得"}
+		if status.Type == "" {
+			status.Type = config.SourceRSS
+		}
+		if h := app.healthStore.Get(feed.URL); h != nil {
+			status.Status = healthStatus(h, now)
+			status.ConsecutiveFailures = h.ConsecutiveFailures
+			status.LastSuccess = h.LastSuccess
+			status.LastItemAt = h.LastItemAt
+			status.LastError = h.LastError
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// requestRun はデーモンのループに即時実行を要求する（すでに要求済みの場合はfalse）
+func (app *App) requestRun() bool {
+	select {
+	case app.trigger <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// previewArticle は記事を翻訳・要約し、通知先ごとに送信するメッセージを作成する（通知や状態の保存はしない）
+// （状態を変更しないため、実行中のRSSチェックと並行して処理する）
+func (app *App) previewArticle(req previewRequest) (*articlePreview, error) {
+	// タイトルを指定した場合も、実際の通知と同じく配信元フィードの情報とGUIDを設定する
+	item := &service.FeedItem{Title: req.Title, Description: req.Description, Link: req.Link, GUID: req.Link}
+	if req.Title == "" {
+		found, err := app.findArticle(req.Link, req.FeedURL)
+		if err != nil {
+			return nil, err
+		}
+		item = found
+	} else {
+		feed, err := app.previewFeed(req.FeedURL)
+		if err != nil {
+			return nil, err
+		}
+		item.Feed = feed
+	}
+
+	result := app.translatorService.TranslateAndSummarize(item)
+
+	preview := &articlePreview{Result: result, Messages: make(map[string][]*service.Message)}
+	for _, dest := range app.destinations {
+		if dest.notificationService == nil {
+			continue
+		}
+		entry, err := dest.notificationService.ArticleEntry(result, dest.Mode == config.ModeThread)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render message for %s: %w", dest.Name, err)
+		}
+		preview.Messages[dest.Name] = entry.Messages
+	}
+	return preview, nil
+}
+
+// previewFeed はタイトルを指定したプレビューに表示するフィードの情報を返す
+// （feedURLを省略した場合は最初に設定したフィード。取得に失敗した場合は設定のみから作成する）
+func (app *App) previewFeed(feedURL string) (service.FeedInfo, error) {
+	if feedURL == "" {
+		feedURL = app.config.Feeds[0].URL
+	}
+	if !slices.ContainsFunc(app.config.Feeds, func(feed config.Feed) bool { return feed.URL == feedURL }) {
+		return service.FeedInfo{}, errFeedNotFound
+	}
+
+	info, err := app.feedService.FetchFeedInfo(feedURL)
+	if err != nil {
+		log.Printf("WARNING: プレビューするフィードの取得に失敗したため、設定のみからフィードの情報を作成します（%s）: %v", feedURL, err)
+	}
+	return info, nil
+}
+
+// findArticle は設定済みのフィード（feedURLを指定した場合はそのフィードのみ）からリンクが一致する記事を探す
+func (app *App) findArticle(link, feedURL string) (*service.FeedItem, error) {
+	for _, feed := range app.config.Feeds {
+		if feedURL != "" && feed.URL != feedURL {
+			continue
+		}
+		item, err := app.feedService.FindItem(feed.URL, link)
+		if err != nil {
+			log.Printf("WARNING: プレビューする記事の検索中にフィードの取得に失敗しました（%s）: %v", feed.URL, err)
+			continue
+		}
+		if item != nil {
+			return item, nil
+		}
+	}
+	return nil, errArticleNotFound
+}
+
+// recentResults は最近翻訳・要約した記事を返す（アーカイブが無効な場合は翻訳フィードの記事）
+func (app *App) recentResults(limit int) ([]*service.ArchivedArticle, error) {
+	if app.archive != nil {
+		articles, err := app.archive.Recent(limit)
+		if articles == nil {
+			articles = []*service.ArchivedArticle{}
+		}
+		return articles, err
+	}
+
+	articles := []*service.ArchivedArticle{}
+	for _, item := range app.publishedStore.Items() {
+		if len(articles) == limit {
+			break
+		}
+		articles = append(articles, &service.ArchivedArticle{Result: item.Result, ArchivedAt: item.AddedAt, UpdatedAt: item.AddedAt})
+	}
+	return articles, nil
+}
+
+// outboxItems は送信箱の送信待ちとデッドレターの一覧を作成する（実行中のRSSチェックを待たない）
+func (app *App) outboxItems() []*outboxItem {
+	items := []*outboxItem{}
+	add := func(status string, entries []service.OutboxEntry) {
+		for _, entry := range entries {
+			items = append(items, &outboxItem{
+				ID:          entry.ID,
+				Status:      status,
+				Destination: entry.Destination,
+				Kind:        entry.Kind,
+				Title:       entry.Title,
+				Attempts:    entry.Attempts,
+				NextAttempt: entry.NextAttempt,
+				LastError:   entry.LastError,
+			})
+		}
+	}
+	pending, dead := app.outbox.Snapshot()
+	add("pending", pending)
+	add("dead", dead)
+	return items
+}
+
+// replayDeadLetters はデッドレターを送信待ちに戻して保存し、戻した件数を返す
+// （送信箱の状態を変更するため、実行中のRSSチェックがあれば完了を待つ）
+func (app *App) replayDeadLetters(id int, all bool) (int, error) {
+	app.runMu.Lock()
+	defer app.runMu.Unlock()
+
+	replayed := 1
+	if all {
+		replayed = app.outbox.ReplayAll()
+	} else if !app.outbox.Replay(id) {
+		return 0, fmt.Errorf("dead letter %d not found", id)
+	}
+	if err := app.outbox.Save(); err != nil {
+		return 0, err
+	}
+	log.Printf("管理APIからの要求により、%d件のデッドレターを送信待ちに戻しました（次回の実行で送信します）", replayed)
+	return replayed, nil
+}
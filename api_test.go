@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+	"rss-en-to-jp-notification/service"
+)
+
+const testAdminToken = "test-admin-token-0123456789"
+
+// apiRequest は管理APIにリクエストを送り、レスポンスのJSONをoutに読み込む
+func apiRequest(t *testing.T, server *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+
+	req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: failed to decode response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func newAPITestServer(t *testing.T, env *testEnv) (*App, *httptest.Server) {
+	t.Helper()
+
+	app := newTestApp(t, env)
+	app.config.AdminToken = testAdminToken
+	server := httptest.NewServer(app.Handler())
+	t.Cleanup(server.Close)
+	return app, server
+}
+
+func TestAPIRequiresToken(t *testing.T) {
+	env := newTestEnv(t)
+	_, server := newAPITestServer(t, env)
+
+	for _, token := range []string{"", "Bearer wrong-token", "Basic " + testAdminToken} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/feeds", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET /api/feeds with %q = %d, want 401", token, resp.StatusCode)
+		}
+	}
+
+	if status := apiRequest(t, server, http.MethodGet, "/api/run", "", nil); status != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/run = %d, want 405", status)
+	}
+
+	// トークンを設定していない場合は管理APIを公開しない
+	app := newTestApp(t, env)
+	disabled := httptest.NewServer(app.Handler())
+	defer disabled.Close()
+	if status := apiRequest(t, disabled, http.MethodGet, "/api/feeds", "", nil); status != http.StatusNotFound {
+		t.Errorf("GET /api/feeds without ADMIN_TOKEN = %d, want 404", status)
+	}
+}
+
+func TestAPIFeedsAndResults(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app, server := newAPITestServer(t, env)
+	app.RunOnce()
+
+	var feeds struct {
+		Feeds []*feedStatus `json:"feeds"`
+	}
+	if status := apiRequest(t, server, http.MethodGet, "/api/feeds", "", &feeds); status != http.StatusOK {
+		t.Fatalf("GET /api/feeds = %d", status)
+	}
+	if len(feeds.Feeds) != 1 || feeds.Feeds[0].URL != env.feed.URL || feeds.Feeds[0].Status != "正常" || feeds.Feeds[0].Type != "rss" {
+		t.Errorf("feeds = %+v", feeds.Feeds[0])
+	}
+
+	var results struct {
+		Articles []*service.ArchivedArticle `json:"articles"`
+	}
+	if status := apiRequest(t, server, http.MethodGet, "/api/results?limit=5", "", &results); status != http.StatusOK {
+		t.Fatalf("GET /api/results = %d", status)
+	}
+	if len(results.Articles) != 1 || results.Articles[0].Result.TranslatedTitle != "[JA] Understanding Caches" {
+		t.Errorf("results = %+v", results.Articles)
+	}
+	if status := apiRequest(t, server, http.MethodGet, "/api/results?limit=0", "", nil); status != http.StatusBadRequest {
+		t.Errorf("GET /api/results?limit=0 = %d, want 400", status)
+	}
+}
+
+func TestAPIReadsDoNotWaitForRun(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Description: "A deep dive.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app, server := newAPITestServer(t, env)
+
+	// RSSチェックの実行中も一覧の取得とプレビューは待たされない
+	app.runMu.Lock()
+	defer app.runMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, path := range []string{"/api/feeds", "/api/outbox", "/admin"} {
+			if status := apiRequest(t, server, http.MethodGet, path, "", nil); status != http.StatusOK {
+				t.Errorf("GET %s = %d, want 200", path, status)
+			}
+		}
+		if status := apiRequest(t, server, http.MethodPost, "/api/preview", `{"link": "https://example.com/caches"}`, nil); status != http.StatusOK {
+			t.Errorf("POST /api/preview = %d, want 200", status)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("read-only requests waited for the running check")
+	}
+}
+
+func TestAPIPreviewDuringRun(t *testing.T) {
+	env := newTestEnv(t)
+	app, server := newAPITestServer(t, env)
+
+	// ログの出力は実行とプレビューを同期させてしまうため、競合を検出できるよう止める
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	// RSSチェックと並行してプレビューしても実行情報を競合して読み書きしない（go test -raceで検出する）
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				app.RunOnce()
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if status := apiRequest(t, server, http.MethodPost, "/api/preview", `{"link": "https://example.com/other", "title": "Other Post"}`, nil); status != http.StatusOK {
+			t.Errorf("POST /api/preview = %d, want 200", status)
+		}
+	}
+	close(stop)
+	<-done
+}
+
+func TestAPIRun(t *testing.T) {
+	app, server := newAPITestServer(t, newTestEnv(t))
+
+	if status := apiRequest(t, server, http.MethodPost, "/api/run", "", nil); status != http.StatusAccepted {
+		t.Errorf("POST /api/run = %d, want 202", status)
+	}
+	// 実行前に重ねて要求した場合はまとめて1回実行する
+	if status := apiRequest(t, server, http.MethodPost, "/api/run", "", nil); status != http.StatusConflict {
+		t.Errorf("second POST /api/run = %d, want 409", status)
+	}
+	select {
+	case <-app.trigger:
+	default:
+		t.Error("run was not requested")
+	}
+}
+
+func TestAPIPreview(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Description: "A deep dive.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app, server := newAPITestServer(t, env)
+
+	var preview articlePreview
+	status := apiRequest(t, server, http.MethodPost, "/api/preview", `{"link": "https://example.com/caches"}`, &preview)
+	if status != http.StatusOK {
+		t.Fatalf("POST /api/preview = %d", status)
+	}
+	if preview.Result.TranslatedTitle != "[JA] Understanding Caches" || preview.Result.Summary != "テスト用の要約です。" {
+		t.Errorf("preview result = %+v", preview.Result)
+	}
+	// 実際の通知と同じく配信元フィードの情報とGUIDを設定する
+	if preview.Result.Feed.URL != env.feed.URL || preview.Result.Feed.Name != "Example Blog" || preview.Result.GUID != "1" {
+		t.Errorf("preview feed = %+v, guid = %q", preview.Result.Feed, preview.Result.GUID)
+	}
+	// スレッド形式の通知先ではタイトルと要約の2件のメッセージになる
+	if messages := preview.Messages["default"]; len(messages) != 2 || !strings.Contains(string(messages[0].Body), "[JA] Understanding Caches") {
+		t.Errorf("preview messages = %+v", preview.Messages)
+	}
+
+	// フィードにない記事もタイトルを指定すればプレビューできる
+	status = apiRequest(t, server, http.MethodPost, "/api/preview", `{"link": "https://example.com/other", "title": "Other Post"}`, &preview)
+	if status != http.StatusOK || preview.Result.TranslatedTitle != "[JA] Other Post" {
+		t.Errorf("POST /api/preview with title = %d, %+v", status, preview.Result)
+	}
+	if preview.Result.Feed.URL != env.feed.URL || preview.Result.Feed.Name != "Example Blog" || preview.Result.GUID != "https://example.com/other" {
+		t.Errorf("preview with title feed = %+v, guid = %q", preview.Result.Feed, preview.Result.GUID)
+	}
+	if messages := preview.Messages["default"]; len(messages) == 0 || !strings.Contains(string(messages[0].Body), "Example Blog") {
+		t.Errorf("preview with title messages = %+v, want the feed name", preview.Messages)
+	}
+	body := `{"link": "https://example.com/other", "title": "Other Post", "feed_url": "https://unknown.example.com/feed"}`
+	if status := apiRequest(t, server, http.MethodPost, "/api/preview", body, nil); status != http.StatusNotFound {
+		t.Errorf("POST /api/preview for an unknown feed = %d, want 404", status)
+	}
+	if status := apiRequest(t, server, http.MethodPost, "/api/preview", `{"link": "https://example.com/missing"}`, nil); status != http.StatusNotFound {
+		t.Errorf("POST /api/preview for a missing article = %d, want 404", status)
+	}
+	if status := apiRequest(t, server, http.MethodPost, "/api/preview", `{}`, nil); status != http.StatusBadRequest {
+		t.Errorf("POST /api/preview without link = %d, want 400", status)
+	}
+
+	// プレビューでは通知も状態の保存もしない
+	if len(env.slack.Messages()) != 0 || len(app.publishedStore.Items()) != 0 {
+		t.Error("preview must not send notifications or publish results")
+	}
+}
+
+func TestAPIOutboxReplay(t *testing.T) {
+	app, server := newAPITestServer(t, newTestEnv(t))
+	entry := &service.OutboxEntry{Destination: "default", Kind: service.OutboxArticle, Title: "Dead"}
+	app.outbox.Add(entry, time.Now())
+	app.outbox.DeadLetter(entry, errors.New("status=500"))
+
+	var outbox struct {
+		Entries []*outboxItem `json:"entries"`
+	}
+	if status := apiRequest(t, server, http.MethodGet, "/api/outbox", "", &outbox); status != http.StatusOK {
+		t.Fatalf("GET /api/outbox = %d", status)
+	}
+	if len(outbox.Entries) != 1 || outbox.Entries[0].Status != "dead" || outbox.Entries[0].LastError != "status=500" {
+		t.Fatalf("outbox = %+v", outbox.Entries)
+	}
+
+	if status := apiRequest(t, server, http.MethodPost, "/api/outbox/replay", `{"id": 999}`, nil); status != http.StatusNotFound {
+		t.Errorf("POST /api/outbox/replay for an unknown id = %d, want 404", status)
+	}
+	var replay struct {
+		Replayed int `json:"replayed"`
+	}
+	body := fmt.Sprintf(`{"id": %d}`, entry.ID)
+	if status := apiRequest(t, server, http.MethodPost, "/api/outbox/replay", body, &replay); status != http.StatusOK || replay.Replayed != 1 {
+		t.Fatalf("POST /api/outbox/replay = %d, %+v", status, replay)
+	}
+	if pending := app.outbox.Pending(); len(pending) != 1 || len(app.outbox.DeadLetters()) != 0 {
+		t.Errorf("pending = %+v, want the dead letter queued again", pending)
+	}
+}
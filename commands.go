@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/service"
+)
+
+// runCommand はサブコマンドを実行する
+func runCommand(name string, args []string) error {
+	switch name {
+	case "export-opml":
+		return exportOPML(args)
+	case "feed-health":
+		return feedHealth(args)
+	case "outbox":
+		return outbox(args)
+	case "search":
+		return search(args)
+	case "daemon":
+		return daemon(args)
+	default:
+		return fmt.Errorf("不明なコマンドです: %s（export-opml, feed-health, outbox, search, daemon）", name)
+	}
+}
+
+// exportOPML は購読中のフィードをOPMLとして書き出す（-o を省略した場合は標準出力）
+func exportOPML(args []string) error {
+	fs := flag.NewFlagSet("export-opml", flag.ContinueOnError)
+	output := fs.String("o", "", "書き出すOPMLファイル（省略した場合は標準出力）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	feeds, err := config.LoadFeeds()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create OPML file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := config.WriteOPML(w, feeds); err != nil {
+		return err
+	}
+
+	skipped := 0
+	for _, feed := range feeds {
+		if feed.Type != "" && feed.Type != config.SourceRSS {
+			skipped++
+		}
+	}
+	if skipped > 0 {
+		log.Printf("RSS以外の取得元のフィード %d 件はOPMLに書き出しませんでした", skipped)
+	}
+	if *output != "" {
+		log.Printf("%d 件のフィードを %s に書き出しました", len(feeds)-skipped, *output)
+	}
+	return nil
+}
+
+// feedHealth はフィードごとの健全性を一覧表示する（-enable で無効化したフィードを再び有効にする）
+func feedHealth(args []string) error {
+	fs := flag.NewFlagSet("feed-health", flag.ContinueOnError)
+	enable := fs.String("enable", "", "再び有効にするフィードのURL")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	feeds, err := config.LoadFeeds()
+	if err != nil {
+		return err
+	}
+	store, err := service.NewHealthStore(filepath.Join(config.StateDir(), "feed_health.json"))
+	if err != nil {
+		return err
+	}
+
+	if *enable != "" {
+		if !store.Enable(*enable) {
+			return fmt.Errorf("無効化されていないフィードです: %s", *enable)
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+		log.Printf("フィード %s を有効にしました", *enable)
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "フィード\t状態\t連続失敗\t最終取得成功\t最新記事の公開\t記事数/日")
+	for _, feed := range feeds {
+		h := store.Get(feed.URL)
+		if h == nil {
+			fmt.Fprintf(w, "%s\t未取得\t-\t-\t-\t-\n", feed.URL)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%.1f\n",
+			feed.URL, healthStatus(h, now), h.ConsecutiveFailures, formatTime(h.LastSuccess), formatTime(h.LastItemAt), h.ItemsPerDay)
+	}
+	return w.Flush()
+}
+
+// outbox は送信箱の送信待ちとデッドレターを一覧表示する（-replay でデッドレターを送信待ちに戻す）
+func outbox(args []string) error {
+	fs := flag.NewFlagSet("outbox", flag.ContinueOnError)
+	replay := fs.String("replay", "", "送信待ちに戻すデッドレターのID（all ですべて）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ob, err := service.NewOutbox(filepath.Join(config.StateDir(), "outbox.json"), service.OutboxPolicy{})
+	if err != nil {
+		return err
+	}
+
+	if *replay != "" {
+		if *replay == "all" {
+			if ob.ReplayAll() == 0 {
+				return fmt.Errorf("デッドレターはありません")
+			}
+		} else {
+			id, err := strconv.Atoi(*replay)
+			if err != nil {
+				return fmt.Errorf("IDが不正です: %s", *replay)
+			}
+			if !ob.Replay(id) {
+				return fmt.Errorf("デッドレターにないIDです: %d", id)
+			}
+		}
+		if err := ob.Save(); err != nil {
+			return err
+		}
+		log.Printf("デッドレターを送信待ちに戻しました（次回の実行で送信します）: %s", *replay)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\t状態\t通知先\t種類\t件名\t失敗回数\t次回送信\t最後のエラー")
+	for _, entry := range ob.Pending() {
+		fmt.Fprintf(w, "%d\t送信待ち\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			entry.ID, entry.Destination, entry.Kind, entry.Title, entry.Attempts, formatTime(entry.NextAttempt), shorten(entry.LastError, 60))
+	}
+	for _, entry := range ob.DeadLetters() {
+		fmt.Fprintf(w, "%d\tデッドレター\t%s\t%s\t%s\t%d\t-\t%s\n",
+			entry.ID, entry.Destination, entry.Kind, entry.Title, entry.Attempts, shorten(entry.LastError, 60))
+	}
+	return w.Flush()
+}
+
+// search はアーカイブした記事を原文・翻訳の両方から全文検索する
+func search(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "表示する最大件数")
+	asJSON := fs.Bool("json", false, "検索結果をJSONで出力する")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	query := strings.Join(fs.Args(), " ")
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("検索語を指定してください（例: search キャッシュ）")
+	}
+
+	path := filepath.Join(config.StateDir(), "archive.db")
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("アーカイブがありません: %s", path)
+	}
+	archive, err := service.OpenArchive(path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	articles, err := archive.Search(query, *limit)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if articles == nil {
+			articles = []*service.ArchivedArticle{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(articles)
+	}
+
+	if len(articles) == 0 {
+		log.Printf("「%s」に一致する記事はありませんでした", query)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "公開日時\tフィード\tタイトル\t原文タイトル\tリンク")
+	for _, article := range articles {
+		r := article.Result
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			formatTime(r.Published), r.Feed.Name, shorten(r.TranslatedTitle, 40), shorten(r.OriginalTitle, 40), r.Link)
+	}
+	return w.Flush()
+}
+
+// shorten は一覧表示のために文字列を切り詰める
+func shorten(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
+
+// healthStatus はフィードの健全性を表示用の状態にする
+func healthStatus(h *service.FeedHealth, now time.Time) string {
+	switch {
+	case h.Disabled(now):
+		return "無効化中（" + formatTime(h.DisabledUntil) + "まで）"
+	case h.ConsecutiveFailures > 0:
+		return "取得失敗"
+	case h.SilentAlerted:
+		return "更新停止"
+	default:
+		return "正常"
+	}
+}
+
+// formatTime は日時を表示用の文字列にする（ゼロ値の場合は「-」）
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/service"
+)
+
+func TestExportOPML(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.json")
+	err := os.WriteFile(configFile, []byte(`{
+		"feeds": [
+			{"url": "https://go.dev/blog/feed.atom", "name": "The Go Blog", "category": "Go"},
+			{"type": "github_releases", "repo": "golang/go"}
+		]
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FEED_URLS", "https://example.com/feed.xml")
+	t.Setenv("CONFIG_FILE", configFile)
+	t.Setenv("OPML_FILE", "")
+
+	output := filepath.Join(dir, "feeds.opml")
+	if err := runCommand("export-opml", []string{"-o", output}); err != nil {
+		t.Fatalf("export-opml error = %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opml := string(data)
+	for _, want := range []string{
+		`xmlUrl="https://example.com/feed.xml"`,
+		`<outline text="Go" title="Go">`,
+		`xmlUrl="https://go.dev/blog/feed.atom"`,
+	} {
+		if !strings.Contains(opml, want) {
+			t.Errorf("OPML does not contain %s:\n%s", want, opml)
+		}
+	}
+	if strings.Contains(opml, "api.github.com") {
+		t.Errorf("OPML contains non-RSS source:\n%s", opml)
+	}
+}
+
+func TestRunCommandUnknown(t *testing.T) {
+	if err := runCommand("unknown", nil); err == nil {
+		t.Error("runCommand(unknown) error = nil, want error")
+	}
+}
+
+func TestFeedHealthEnable(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("FEED_URLS", "https://example.com/feed.xml")
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("OPML_FILE", "")
+	t.Setenv("STATE_DIR", stateDir)
+
+	err := os.WriteFile(filepath.Join(stateDir, "feed_health.json"), []byte(`{
+		"https://example.com/feed.xml": {"consecutive_failures": 1, "disabled_until": "2999-01-01T00:00:00Z"}
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCommand("feed-health", nil); err != nil {
+		t.Fatalf("feed-health error = %v", err)
+	}
+	if err := runCommand("feed-health", []string{"-enable", "https://example.com/feed.xml"}); err != nil {
+		t.Fatalf("feed-health -enable error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, "feed_health.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "2999") {
+		t.Errorf("feed is still disabled: %s", data)
+	}
+	if err := runCommand("feed-health", []string{"-enable", "https://example.com/feed.xml"}); err == nil {
+		t.Error("feed-health -enable for an enabled feed error = nil, want error")
+	}
+}
+
+func TestOutboxReplay(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("STATE_DIR", stateDir)
+
+	err := os.WriteFile(filepath.Join(stateDir, "outbox.json"), []byte(`{
+		"next_id": 3,
+		"pending": [{"id": 1, "destination": "default", "kind": "article", "title": "Queued", "attempts": 1}],
+		"dead": [{"id": 2, "destination": "default", "kind": "article", "title": "Dead", "attempts": 5, "last_error": "status=500"}]
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCommand("outbox", nil); err != nil {
+		t.Fatalf("outbox error = %v", err)
+	}
+	if err := runCommand("outbox", []string{"-replay", "1"}); err == nil {
+		t.Error("outbox -replay for a pending entry error = nil, want error")
+	}
+	if err := runCommand("outbox", []string{"-replay", "2"}); err != nil {
+		t.Fatalf("outbox -replay error = %v", err)
+	}
+
+	ob, err := service.NewOutbox(filepath.Join(stateDir, "outbox.json"), service.OutboxPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := ob.Pending(); len(pending) != 2 || pending[1].ID != 2 || pending[1].Attempts != 0 || len(ob.DeadLetters()) != 0 {
+		t.Errorf("pending = %+v, want the dead letter queued again", pending)
+	}
+	if err := runCommand("outbox", []string{"-replay", "all"}); err == nil {
+		t.Error("outbox -replay all without dead letters error = nil, want error")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("STATE_DIR", stateDir)
+
+	if err := runCommand("search", []string{"cache"}); err == nil {
+		t.Error("search without an archive error = nil, want error")
+	}
+
+	archive, err := service.OpenArchive(filepath.Join(stateDir, "archive.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = archive.Add([]*service.TranslationResult{{
+		OriginalTitle: "Understanding Caches", TranslatedTitle: "キャッシュを理解する", Link: "https://example.com/caches",
+	}}, time.Now())
+	archive.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"キャッシュ"}, {"-json", "-limit", "5", "caches"}, {"no-match"}} {
+		if err := runCommand("search", args); err != nil {
+			t.Errorf("search %v error = %v", args, err)
+		}
+	}
+	if err := runCommand("search", nil); err == nil {
+		t.Error("search without a query error = nil, want error")
+	}
+}
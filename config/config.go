@@ -3,9 +3,11 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,25 +15,196 @@ import (
 // Config はアプリケーションの設定を管理する構造体
 type Config struct {
 	// RSS フィード関連
-	FeedURLs              []string
-	MaxArticlesPerFeed    int
-	
+	FeedURLs             []string
+	Feeds                []Feed // FEED_URLS と設定ファイルのフィード定義をまとめたもの
+	MaxArticlesPerFeed   int
+	ResolveCanonicalURLs bool          // 重複判定で記事ページのリダイレクト先・canonical URLを使用する
+	MaxCatchUp           time.Duration // 前回実行から時間が空いた場合に遡る期間の上限
+	FirstRunBackfill     int           // 初回実行時に通知するフィードごとの最新記事数（0の場合はすべて既読にする）
+	OverflowPolicy       string        // MAX_ARTICLES_PER_FEEDを超えた記事の扱い（defer or notice）
+	FeedFailureThreshold int           // 取得の連続失敗がこの回数に達したらアラートする（0の場合はアラートしない）
+	FeedSilentDays       int           // 新しい記事がこの日数公開されていない場合にアラートする（0の場合はアラートしない）
+	FeedFlapThreshold    int           // 直近20回の取得で成功と失敗がこの回数入れ替わったら無効化する（0の場合は無効化しない）
+	FeedDisableDuration  time.Duration // 成功と失敗を繰り返すフィードを無効化する期間
+
 	// DeepL API 関連
-	DeepLAPIKey     string
-	DeepLAPIURL     string
-	
+	DeepLAPIKey string
+	DeepLAPIURL string
+
 	// OpenAI API 関連
-	OpenAIAPIKey    string
-	OpenAIModel     string
-	
+	OpenAIAPIKey  string
+	OpenAIModel   string
+	OpenAIBaseURL string
+
+	// 翻訳・要約に失敗した場合の扱い
+	TranslationFallback string // DeepLでの翻訳に失敗した場合の代替（openai or none）
+	DegradedPolicy      string // 翻訳・要約に失敗した記事の扱い（post or hold）
+	DegradedMaxHolds    int    // 保留して再試行する回数の上限（達した場合は失敗したまま通知する）
+
 	// Slack 関連
 	SlackWebhookURL string
 	SlackChannel    string
 	SlackUseThreads bool
-	
+
+	// 運用通知（エラー通知・健全性アラート・実行サマリー）関連
+	OpsWebhookURL string // 未指定の場合は既定の通知先のWebhook
+	OpsChannel    string // 未指定の場合は既定の通知先のチャンネル
+	RunSummary    string // 実行サマリーを送信する条件（always, on_failure, off）
+
+	// 通知の再送（送信に失敗したメッセージは送信箱に残し、次回以降の実行で再送する）
+	OutboxMaxAttempts int           // 送信にこの回数失敗したらデッドレターに移す（0の場合は移さない）
+	OutboxBackoff     time.Duration // 最初の再送までの待ち時間（失敗するごとに2倍にする）
+
+	// 通知先関連
+	Destinations []Destination
+
+	// 翻訳したフィードの出力
+	FeedOutputFile     string // 翻訳したフィードを書き出すファイル（空の場合は書き出さない）
+	FeedOutputFormat   string // ファイルに書き出すフィードの形式（atom, rss, json）
+	FeedOutputTitle    string
+	FeedOutputURL      string // 書き出したフィードを公開するURL（フィードの自己参照リンク）
+	FeedOutputMaxItems int    // フィードに含める最新の記事数
+
+	// 記事のアーカイブ（STATE_DIR/archive.dbに保存し、searchコマンドで検索する）
+	ArchiveEnabled bool
+
+	// デーモンモード（daemonコマンド）
+	CheckInterval time.Duration // RSSチェックの実行間隔
+	HTTPAddr      string        // HTTPサーバーの待ち受けアドレス
+	AdminToken    string        // 管理APIと管理画面の認証トークン（空の場合は管理APIと管理画面を無効にする）
+
+	// メトリクス（デーモンモードでは/metricsで公開し、1回だけ実行する場合はPushgatewayに送信する）
+	PushgatewayURL string // 空の場合は送信しない
+	PushgatewayJob string
+
+	// トレース（OTLPで送信する。送信先はOTEL_EXPORTER_OTLP_ENDPOINTなどの標準の環境変数で指定する）
+	TracingEnabled bool
+
+	// 関連度判定（RelevanceProfileが空の場合は判定しない）
+	RelevanceProfile   string // チームの関心事項の説明
+	RelevanceThreshold int    // この値未満（0〜100）の記事は通知しない
+
 	// アプリケーション設定
-	LogLevel        string
-	Timezone        string
+	LogLevel   string
+	Timezone   string
+	StateDir   string
+	ConfigFile string
+	OPMLFile   string // 購読フィードを読み込むOPMLファイル
+
+	// HTTP通信の記録・再生（テスト用）
+	HTTPRecordMode   string
+	HTTPCassetteFile string
+}
+
+// Feed はフィードごとの設定
+type Feed struct {
+	URL      string
+	Name     string      // 表示名（未指定の場合はフィードのタイトル）
+	IconURL  string      // アイコン画像URL（未指定の場合はフィードの画像）
+	Footer   string      // フッター文言（未指定の場合は「<表示名> RSS通知」）
+	Category string      // OPMLのカテゴリーなど、フィードの分類
+	Channel  string      // 記事を投稿するチャンネル（未指定の場合は通知先のチャンネル）
+	Include  *FilterRule // 一致する記事のみを通知する
+	Exclude  *FilterRule // 一致する記事を通知しない
+	HTTP     *FeedHTTP   // 取得時のヘッダー・認証・プロキシなど
+
+	SilentDays int // 新しい記事がない場合にアラートするまでの日数の上書き（0の場合はFEED_SILENT_DAYS）
+
+	// RSS以外の取得元（Typeが空の場合はRSS/Atom/JSON Feed）
+	Type               string
+	IncludePrereleases bool             // github_releases: プレリリースも通知する
+	MinScore           int              // hackernews / reddit: このポイント（スコア）未満の記事は通知しない
+	Selectors          *ScrapeSelectors // scrape: 記事を抽出するCSSセレクター
+	DateLayout         string           // scrape: 日付の形式（Goのレイアウト）
+}
+
+// 記事の取得元の種類
+const (
+	SourceRSS            = "rss"             // RSS / Atom / JSON Feed
+	SourceGitHubReleases = "github_releases" // GitHub Releases API
+	SourceHackerNews     = "hackernews"      // Hacker News Algolia API
+	SourceReddit         = "reddit"          // Reddit JSON API
+	SourceScrape         = "scrape"          // HTMLページをCSSセレクターで抽出
+)
+
+// 通知モード
+const (
+	ModeArticle = "article" // 1記事1メッセージ
+	ModeThread  = "thread"  // タイトル投稿 + スレッドで要約
+	ModeDigest  = "digest"  // 期間内の記事をまとめて1通のダイジェストで通知
+)
+
+// 上限を超えた記事の扱い
+const (
+	OverflowDefer  = "defer"  // 古い記事から処理し、残りは次回の実行に繰り越す
+	OverflowNotice = "notice" // 新しい記事を処理し、残りは一覧のみを超過通知で知らせる
+)
+
+// 実行サマリーを送信する条件
+const (
+	RunSummaryAlways    = "always"     // 毎回送信する
+	RunSummaryOnFailure = "on_failure" // 失敗があった場合のみ送信する
+	RunSummaryOff       = "off"        // 送信しない
+)
+
+// 翻訳の代替プロバイダー
+const (
+	FallbackNone   = "none"   // 代替せず原文のまま扱う
+	FallbackOpenAI = "openai" // OpenAIで翻訳する
+)
+
+// 翻訳・要約に失敗した記事の扱い
+const (
+	DegradedPost = "post" // 失敗したことを表示して通知する
+	DegradedHold = "hold" // 通知せずに保留し、次回の実行で再試行する
+)
+
+// メッセージ形式
+const (
+	FormatBlocks      = "blocks"      // Slack Block Kit
+	FormatAttachments = "attachments" // レガシーなAttachment（互換用）
+)
+
+// 翻訳したフィードの形式
+const (
+	FeedFormatAtom = "atom" // Atom 1.0
+	FeedFormatRSS  = "rss"  // RSS 2.0
+	FeedFormatJSON = "json" // JSON Feed 1.1
+)
+
+// 通知先の種類
+const (
+	DestinationSlack      = "slack"      // Slack Incoming Webhook
+	DestinationTeams      = "teams"      // Microsoft Teams（Adaptive Card）
+	DestinationDiscord    = "discord"    // Discord Webhook
+	DestinationMattermost = "mattermost" // Mattermost Incoming Webhook
+	DestinationWebhook    = "webhook"    // テンプレートで本文を定義する汎用JSON Webhook
+	DestinationEmail      = "email"      // SMTPでダイジェストをメール送信
+)
+
+// メールの言語
+const (
+	LanguageJapanese = "ja" // 翻訳したタイトルと要約
+	LanguageEnglish  = "en" // 原文のタイトルと概要
+)
+
+// minAdminTokenLength は推測されにくいよう管理APIの認証トークンに求める最小の長さ
+const minAdminTokenLength = 16
+
+// defaultSMTPPort はSMTPの既定のポート（STARTTLSを使うsubmissionポート）
+const defaultSMTPPort = 587
+
+// Destination は通知先ごとの設定
+type Destination struct {
+	Name         string
+	Type         string // 通知先の種類（slack, teams, discord, mattermost, webhook, email。空の場合はslack）
+	WebhookURL   string
+	Channel      string // 投稿先チャンネル（slack・mattermostのみ）
+	Mode         string
+	Format       string        // メッセージ形式（blocks or attachments。slackのみ）
+	TemplateDir  string        // 組み込みテンプレートを上書きするテンプレートのディレクトリ
+	DigestWindow time.Duration // ダイジェストモード時の集計期間
+	Email        *Email        // メール通知先のSMTP設定（typeがemailの場合のみ）
 }
 
 // LoadConfig は環境変数から設定を読み込む
@@ -43,25 +216,113 @@ func LoadConfig() *Config {
 
 	config := &Config{
 		// RSS フィード関連
-		FeedURLs:              getFeedURLs(),
-		MaxArticlesPerFeed:    getIntFromEnv("MAX_ARTICLES_PER_FEED", 10),
-		
+		FeedURLs:             getFeedURLs(),
+		MaxArticlesPerFeed:   getIntFromEnv("MAX_ARTICLES_PER_FEED", 10),
+		ResolveCanonicalURLs: getBoolFromEnv("RESOLVE_CANONICAL_URLS", false),
+		OverflowPolicy:       strings.ToLower(getEnvOrDefault("OVERFLOW_POLICY", OverflowDefer)),
+		FeedFailureThreshold: getIntFromEnv("FEED_FAILURE_THRESHOLD", 3),
+		FeedSilentDays:       getIntFromEnv("FEED_SILENT_DAYS", 14),
+		FeedFlapThreshold:    getIntFromEnv("FEED_FLAP_THRESHOLD", 6),
+
 		// DeepL API 関連
-		DeepLAPIKey:     getEnvOrPanic("DEEPL_API_KEY"),
-		DeepLAPIURL:     getEnvOrDefault("DEEPL_API_URL", "https://api-free.deepl.com/v2/translate"),
-		
+		DeepLAPIKey: getEnvOrPanic("DEEPL_API_KEY"),
+		DeepLAPIURL: getEnvOrDefault("DEEPL_API_URL", "https://api-free.deepl.com/v2/translate"),
+
 		// OpenAI API 関連
-		OpenAIAPIKey:    getEnvOrPanic("OPENAI_API_KEY"),
-		OpenAIModel:     getEnvOrDefault("OPENAI_MODEL", "gpt-3.5-turbo"),
-		
+		OpenAIAPIKey:  getEnvOrPanic("OPENAI_API_KEY"),
+		OpenAIModel:   getEnvOrDefault("OPENAI_MODEL", "gpt-3.5-turbo"),
+		OpenAIBaseURL: os.Getenv("OPENAI_BASE_URL"),
+
+		// 翻訳・要約に失敗した場合の扱い
+		TranslationFallback: strings.ToLower(getEnvOrDefault("TRANSLATION_FALLBACK", FallbackNone)),
+		DegradedPolicy:      strings.ToLower(getEnvOrDefault("DEGRADED_POLICY", DegradedPost)),
+		DegradedMaxHolds:    getIntFromEnv("DEGRADED_MAX_HOLDS", 3),
+
 		// Slack 関連
 		SlackWebhookURL: getEnvOrPanic("SLACK_WEBHOOK_URL"),
 		SlackChannel:    getEnvOrDefault("SLACK_CHANNEL", "#general"),
 		SlackUseThreads: getBoolFromEnv("SLACK_USE_THREADS", true),
-		
+
+		// 運用通知関連
+		OpsWebhookURL: os.Getenv("OPS_SLACK_WEBHOOK_URL"),
+		OpsChannel:    os.Getenv("OPS_SLACK_CHANNEL"),
+		RunSummary:    strings.ToLower(getEnvOrDefault("RUN_SUMMARY", RunSummaryOnFailure)),
+
+		// 通知の再送
+		OutboxMaxAttempts: getIntFromEnv("OUTBOX_MAX_ATTEMPTS", 5),
+
+		// 翻訳したフィードの出力
+		FeedOutputFile:     os.Getenv("FEED_OUTPUT_FILE"),
+		FeedOutputFormat:   strings.ToLower(getEnvOrDefault("FEED_OUTPUT_FORMAT", FeedFormatAtom)),
+		FeedOutputTitle:    getEnvOrDefault("FEED_OUTPUT_TITLE", "RSS 翻訳フィード"),
+		FeedOutputURL:      os.Getenv("FEED_OUTPUT_URL"),
+		FeedOutputMaxItems: getIntFromEnv("FEED_OUTPUT_MAX_ITEMS", 50),
+
+		// 記事のアーカイブ
+		ArchiveEnabled: getBoolFromEnv("ARCHIVE_ENABLED", true),
+
+		// デーモンモード
+		CheckInterval: time.Duration(getIntFromEnv("CHECK_INTERVAL_MINUTES", 30)) * time.Minute,
+		HTTPAddr:      getEnvOrDefault("HTTP_ADDR", ":8080"),
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+
+		// メトリクス
+		PushgatewayURL: os.Getenv("PUSHGATEWAY_URL"),
+		PushgatewayJob: getEnvOrDefault("PUSHGATEWAY_JOB", "rss-en-to-jp-notification"),
+
+		// トレース
+		TracingEnabled: getBoolFromEnv("TRACING_ENABLED", false),
+
 		// アプリケーション設定
-		LogLevel:        getEnvOrDefault("LOG_LEVEL", "info"),
-		Timezone:        getEnvOrDefault("TIMEZONE", "Asia/Tokyo"),
+		LogLevel:   getEnvOrDefault("LOG_LEVEL", "info"),
+		Timezone:   getEnvOrDefault("TIMEZONE", "Asia/Tokyo"),
+		StateDir:   StateDir(),
+		ConfigFile: os.Getenv("CONFIG_FILE"),
+		OPMLFile:   os.Getenv("OPML_FILE"),
+
+		// HTTP通信の記録・再生（テスト用）
+		HTTPRecordMode:   getEnvOrDefault("HTTP_RECORD_MODE", "off"),
+		HTTPCassetteFile: getEnvOrDefault("HTTP_CASSETTE_FILE", "testdata/cassette.json"),
+	}
+
+	// 新着判定の設定を読み込み
+	config.MaxCatchUp = getDurationFromEnv("MAX_CATCH_UP", 72*time.Hour)
+
+	firstRunBackfill, err := parseFirstRunPolicy(getEnvOrDefault("FIRST_RUN_POLICY", "backfill:5"))
+	if err != nil {
+		log.Fatalf("Invalid FIRST_RUN_POLICY: %v", err)
+	}
+	config.FirstRunBackfill = firstRunBackfill
+
+	// フィードの健全性チェックの設定を読み込み
+	config.FeedDisableDuration = getDurationFromEnv("FEED_DISABLE_DURATION", 7*24*time.Hour)
+
+	// 通知の再送の設定を読み込み
+	config.OutboxBackoff = getDurationFromEnv("OUTBOX_BACKOFF", 15*time.Minute)
+
+	// 設定ファイルを読み込み
+	fileConfig, err := loadFileConfig(config.ConfigFile)
+	if err != nil {
+		log.Fatalf("Failed to load config file: %v", err)
+	}
+
+	// フィードを構築（FEED_URLS + OPML + 設定ファイルのフィード定義）
+	feeds, err := config.loadFeeds(fileConfig)
+	if err != nil {
+		log.Fatalf("Failed to load feeds: %v", err)
+	}
+	config.Feeds = feeds
+
+	// 通知先を構築（環境変数のSlack設定 + 設定ファイルの追加通知先）
+	destinations, err := config.loadDestinations(fileConfig)
+	if err != nil {
+		log.Fatalf("Failed to load destinations: %v", err)
+	}
+	config.Destinations = destinations
+
+	// 関連度判定の設定を読み込み（環境変数 + 設定ファイル）
+	if err := config.loadRelevance(fileConfig); err != nil {
+		log.Fatalf("Failed to load relevance settings: %v", err)
 	}
 
 	// 設定値の検証
@@ -72,11 +333,51 @@ func LoadConfig() *Config {
 	return config
 }
 
+// LoadFeeds はフィードの設定のみを読み込む（APIキーを必要としないコマンド用）
+func LoadFeeds() ([]Feed, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found, using environment variables")
+	}
+
+	config := &Config{
+		FeedURLs:   getFeedURLs(),
+		ConfigFile: os.Getenv("CONFIG_FILE"),
+		OPMLFile:   os.Getenv("OPML_FILE"),
+	}
+	fileConfig, err := loadFileConfig(config.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return config.loadFeeds(fileConfig)
+}
+
+// StateDir は状態ファイルの保存先を返す（LoadConfigを使わないコマンド用）
+func StateDir() string {
+	return getEnvOrDefault("STATE_DIR", "state")
+}
+
 // validate は設定値の妥当性をチェックする
 func (c *Config) validate() error {
-	if len(c.FeedURLs) == 0 {
+	if len(c.Feeds) == 0 {
 		return fmt.Errorf("FEED_URLS is required")
 	}
+	for _, feed := range c.Feeds {
+		if feed.URL == "" {
+			return fmt.Errorf("feed url is required")
+		}
+		if err := feed.HTTP.validate(); err != nil {
+			return fmt.Errorf("invalid http settings for feed %s: %w", feed.URL, err)
+		}
+		switch feed.Type {
+		case "", SourceRSS, SourceGitHubReleases, SourceHackerNews, SourceReddit:
+		case SourceScrape:
+			if feed.Selectors == nil || feed.Selectors.Item == "" {
+				return fmt.Errorf("selectors.item is required for scrape feed %s", feed.URL)
+			}
+		default:
+			return fmt.Errorf("invalid type %q for feed %s (rss, github_releases, hackernews, reddit, scrape)", feed.Type, feed.URL)
+		}
+	}
 	if c.DeepLAPIKey == "" {
 		return fmt.Errorf("DEEPL_API_KEY is required")
 	}
@@ -89,6 +390,146 @@ func (c *Config) validate() error {
 	if c.MaxArticlesPerFeed <= 0 {
 		return fmt.Errorf("MAX_ARTICLES_PER_FEED must be greater than 0")
 	}
+	if c.MaxCatchUp <= 0 {
+		return fmt.Errorf("MAX_CATCH_UP must be greater than 0")
+	}
+	switch c.OverflowPolicy {
+	case OverflowDefer, OverflowNotice:
+	default:
+		return fmt.Errorf("invalid OVERFLOW_POLICY %q (defer, notice)", c.OverflowPolicy)
+	}
+	switch c.RunSummary {
+	case RunSummaryAlways, RunSummaryOnFailure, RunSummaryOff:
+	default:
+		return fmt.Errorf("invalid RUN_SUMMARY %q (always, on_failure, off)", c.RunSummary)
+	}
+	switch c.TranslationFallback {
+	case FallbackNone, FallbackOpenAI:
+	default:
+		return fmt.Errorf("invalid TRANSLATION_FALLBACK %q (openai, none)", c.TranslationFallback)
+	}
+	switch c.DegradedPolicy {
+	case DegradedPost, DegradedHold:
+	default:
+		return fmt.Errorf("invalid DEGRADED_POLICY %q (post, hold)", c.DegradedPolicy)
+	}
+	if c.DegradedMaxHolds < 0 {
+		return fmt.Errorf("DEGRADED_MAX_HOLDS must not be negative")
+	}
+	if c.OutboxMaxAttempts < 0 || c.OutboxBackoff < 0 {
+		return fmt.Errorf("OUTBOX_MAX_ATTEMPTS and OUTBOX_BACKOFF must not be negative")
+	}
+	if c.FeedFailureThreshold < 0 || c.FeedSilentDays < 0 || c.FeedFlapThreshold < 0 {
+		return fmt.Errorf("FEED_FAILURE_THRESHOLD, FEED_SILENT_DAYS and FEED_FLAP_THRESHOLD must not be negative")
+	}
+	if c.FeedFlapThreshold > 0 && c.FeedDisableDuration <= 0 {
+		return fmt.Errorf("FEED_DISABLE_DURATION must be greater than 0")
+	}
+	switch c.FeedOutputFormat {
+	case FeedFormatAtom, FeedFormatRSS, FeedFormatJSON:
+	default:
+		return fmt.Errorf("invalid FEED_OUTPUT_FORMAT %q (atom, rss, json)", c.FeedOutputFormat)
+	}
+	if c.FeedOutputMaxItems <= 0 {
+		return fmt.Errorf("FEED_OUTPUT_MAX_ITEMS must be greater than 0")
+	}
+	if c.CheckInterval <= 0 {
+		return fmt.Errorf("CHECK_INTERVAL_MINUTES must be greater than 0")
+	}
+	if c.AdminToken != "" && len(c.AdminToken) < minAdminTokenLength {
+		return fmt.Errorf("ADMIN_TOKEN must be at least %d characters", minAdminTokenLength)
+	}
+	if c.RelevanceThreshold < 0 || c.RelevanceThreshold > 100 {
+		return fmt.Errorf("RELEVANCE_THRESHOLD must be between 0 and 100")
+	}
+	names := make(map[string]bool)
+	for _, dest := range c.Destinations {
+		if err := dest.validate(); err != nil {
+			return err
+		}
+		if names[dest.Name] {
+			return fmt.Errorf("duplicate destination name: %s", dest.Name)
+		}
+		names[dest.Name] = true
+	}
+	return nil
+}
+
+// validate は通知先設定の妥当性をチェックする
+func (d Destination) validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("destination name is required")
+	}
+	if d.WebhookURL == "" && d.Type != DestinationEmail {
+		return fmt.Errorf("webhook_url is required for destination %s", d.Name)
+	}
+	switch d.Type {
+	case "", DestinationSlack:
+		switch d.Format {
+		case FormatBlocks, FormatAttachments:
+		default:
+			return fmt.Errorf("invalid format %q for destination %s (blocks, attachments)", d.Format, d.Name)
+		}
+	case DestinationTeams, DestinationDiscord, DestinationMattermost, DestinationWebhook:
+	case DestinationEmail:
+		if d.Mode != ModeDigest {
+			return fmt.Errorf("email destination %s must use digest mode", d.Name)
+		}
+		if err := d.Email.validate(); err != nil {
+			return fmt.Errorf("invalid email settings for destination %s: %w", d.Name, err)
+		}
+	default:
+		return fmt.Errorf("invalid type %q for destination %s (slack, teams, discord, mattermost, webhook, email)", d.Type, d.Name)
+	}
+	switch d.Mode {
+	case ModeArticle, ModeThread:
+	case ModeDigest:
+		if d.DigestWindow <= 0 {
+			return fmt.Errorf("digest_window must be greater than 0 for destination %s", d.Name)
+		}
+	default:
+		return fmt.Errorf("invalid mode %q for destination %s (article, thread, digest)", d.Mode, d.Name)
+	}
+	return nil
+}
+
+// validate はメール通知先のSMTP設定の妥当性をチェックする
+func (e *Email) validate() error {
+	if e == nil {
+		return fmt.Errorf("email is required")
+	}
+	if e.SMTPHost == "" || e.From == "" {
+		return fmt.Errorf("smtp_host and from are required")
+	}
+	if len(e.Recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	for _, recipient := range e.Recipients {
+		if recipient.Address == "" {
+			return fmt.Errorf("recipient address is required")
+		}
+		switch recipient.Language {
+		case LanguageJapanese, LanguageEnglish:
+		default:
+			return fmt.Errorf("invalid language %q for %s (ja, en)", recipient.Language, recipient.Address)
+		}
+	}
+	return nil
+}
+
+// validate はフィードのHTTP設定の妥当性をチェックする
+func (h *FeedHTTP) validate() error {
+	if h == nil {
+		return nil
+	}
+	if h.BasicAuth != nil && h.BasicAuth.Username == "" {
+		return fmt.Errorf("basic_auth username is required")
+	}
+	if h.Proxy != "" {
+		if u, err := url.Parse(h.Proxy); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy %q", h.Proxy)
+		}
+	}
 	return nil
 }
 
@@ -96,7 +537,7 @@ func (c *Config) validate() error {
 func getFeedURLs() []string {
 	// 複数URLをカンマ区切りで指定可能
 	feedURLsStr := getEnvOrDefault("FEED_URLS", "https://blog.bytebytego.com/feed")
-	
+
 	var urls []string
 	for _, url := range strings.Split(feedURLsStr, ",") {
 		url = strings.TrimSpace(url)
@@ -104,10 +545,48 @@ func getFeedURLs() []string {
 			urls = append(urls, url)
 		}
 	}
-	
+
 	return urls
 }
 
+// defaultMode はSLACK_USE_THREADSから既定の通知モードを決定する
+func defaultMode(useThreads bool) string {
+	if useThreads {
+		return ModeThread
+	}
+	return ModeArticle
+}
+
+// parseDigestWindow はダイジェストの集計期間を解析する（daily, weekly, またはGoのduration形式）
+func parseDigestWindow(value string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "daily":
+		return 24 * time.Hour, nil
+	case "weekly":
+		return 7 * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// parseFirstRunPolicy は初回実行時の扱いを解析し、通知する記事数を返す
+// （"backfill:N" は最新N件を通知、"mark-seen" はすべて既読にする）
+func parseFirstRunPolicy(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "mark-seen" {
+		return 0, nil
+	}
+
+	count, ok := strings.CutPrefix(value, "backfill:")
+	if !ok {
+		return 0, fmt.Errorf("unknown policy %q (backfill:N, mark-seen)", value)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid backfill count %q", count)
+	}
+	return n, nil
+}
+
 // getEnvOrDefault は環境変数の値を取得し、存在しない場合はデフォルト値を返す
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -131,13 +610,30 @@ func getIntFromEnv(key string, defaultValue int) int {
 	if valueStr == "" {
 		return defaultValue
 	}
-	
+
 	value, err := strconv.Atoi(valueStr)
 	if err != nil {
 		log.Printf("Warning: Invalid value for %s, using default: %d", key, defaultValue)
 		return defaultValue
 	}
-	
+
+	return value
+}
+
+// getDurationFromEnv は環境変数から期間（Goのduration形式）を取得する
+// 解析できない値や0以下の値の場合は警告を出してデフォルト値を返す
+func getDurationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	valueStr := strings.TrimSpace(os.Getenv(key))
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil || value <= 0 {
+		log.Printf("Warning: Invalid duration for %s: %s, using default: %s", key, valueStr, defaultValue)
+		return defaultValue
+	}
+
 	return value
 }
 
@@ -147,7 +643,7 @@ func getBoolFromEnv(key string, defaultValue bool) bool {
 	if valueStr == "" {
 		return defaultValue
 	}
-	
+
 	// 各種真値パターンをサポート
 	switch strings.ToLower(valueStr) {
 	case "true", "t", "yes", "y", "1", "on", "enable", "enabled":
@@ -158,4 +654,4 @@ func getBoolFromEnv(key string, defaultValue bool) bool {
 		log.Printf("Warning: Invalid boolean value for %s: %s, using default: %t", key, valueStr, defaultValue)
 		return defaultValue
 	}
-}
\ No newline at end of file
+}
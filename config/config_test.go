@@ -0,0 +1,354 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setRequiredEnv は必須の環境変数を設定する
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("DEEPL_API_KEY", "deepl-key")
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	t.Setenv("SLACK_WEBHOOK_URL", "https://hooks.slack.com/services/T/B/X")
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg := LoadConfig()
+
+	if len(cfg.Feeds) != 1 || cfg.Feeds[0].URL != "https://blog.bytebytego.com/feed" {
+		t.Errorf("Feeds = %+v, want default feed", cfg.Feeds)
+	}
+	if cfg.MaxArticlesPerFeed != 10 || cfg.OpenAIModel != "gpt-3.5-turbo" || cfg.RelevanceProfile != "" || cfg.RelevanceThreshold != 50 ||
+		cfg.MaxCatchUp != 72*time.Hour || cfg.FirstRunBackfill != 5 || cfg.OverflowPolicy != OverflowDefer ||
+		cfg.FeedFailureThreshold != 3 || cfg.FeedSilentDays != 14 || cfg.FeedFlapThreshold != 6 || cfg.FeedDisableDuration != 7*24*time.Hour ||
+		cfg.RunSummary != RunSummaryOnFailure || cfg.OpsWebhookURL != "" ||
+		cfg.TranslationFallback != FallbackNone || cfg.DegradedPolicy != DegradedPost || cfg.DegradedMaxHolds != 3 ||
+		cfg.OutboxMaxAttempts != 5 || cfg.OutboxBackoff != 15*time.Minute ||
+		cfg.FeedOutputFile != "" || cfg.FeedOutputFormat != FeedFormatAtom || cfg.FeedOutputMaxItems != 50 ||
+		!cfg.ArchiveEnabled || cfg.CheckInterval != 30*time.Minute || cfg.HTTPAddr != ":8080" ||
+		cfg.AdminToken != "" || cfg.PushgatewayURL != "" || cfg.PushgatewayJob != "rss-en-to-jp-notification" || cfg.TracingEnabled {
+		t.Errorf("unexpected defaults: %+v", cfg)
+	}
+	if len(cfg.Destinations) != 1 {
+		t.Fatalf("Destinations = %+v, want only default", cfg.Destinations)
+	}
+	dest := cfg.Destinations[0]
+	if dest.Mode != ModeThread || dest.Format != FormatBlocks || dest.DigestWindow != 24*time.Hour {
+		t.Errorf("default destination = %+v", dest)
+	}
+}
+
+func TestLoadConfigFromEnvAndFile(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("FEED_URLS", " https://a.example.com/feed , ,https://b.example.com/feed")
+	t.Setenv("MAX_ARTICLES_PER_FEED", "3")
+	t.Setenv("SLACK_USE_THREADS", "off")
+	t.Setenv("SLACK_MESSAGE_FORMAT", FormatAttachments)
+	t.Setenv("TEMPLATE_DIR", "/etc/rss/slack-templates")
+	t.Setenv("RELEVANCE_PROFILE", "overridden by the config file")
+	t.Setenv("B_FEED_TOKEN", "secret-token")
+	t.Setenv("SMTP_PASSWORD", "smtp-secret")
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configFile, []byte(`{
+		"feeds": [
+			{"url": "https://b.example.com/feed", "name": "B Blog",
+			 "http": {"bearer_token": "${B_FEED_TOKEN}", "headers": {"X-Team": "backend"}, "basic_auth": {"username": "u", "password": "p$1"}, "proxy": "http://proxy.example.com:8080"}},
+			{"url": "https://c.example.com/feed", "icon_url": "https://c.example.com/icon.png",
+			 "filter": {"include": {"any": [{"field": "category", "contains": "go"}, {"regex": "(?i)golang"}]}, "exclude": {"field": "title", "contains": "sponsored"}}}
+		],
+		"destinations": [
+			{"name": "weekly", "webhook_url": "https://hooks.slack.com/services/T/B/Y", "mode": "digest", "digest_window": "weekly", "format": "blocks"},
+			{"name": "sre", "type": "teams", "webhook_url": "https://example.webhook.office.com/webhookb2/X", "mode": "article"},
+			{"name": "mail", "type": "email", "mode": "digest", "digest_window": "daily",
+			 "email": {"smtp_host": "smtp.example.com", "username": "rss", "password": "${SMTP_PASSWORD}", "from": "rss@example.com",
+			           "recipients": [{"address": "tanaka@example.com"}, {"address": "smith@example.com", "language": "en"}]}}
+		],
+		"relevance": {"profile": " 分散システムとデータベース ", "threshold": 70}
+	}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", configFile)
+
+	cfg := LoadConfig()
+
+	if cfg.MaxArticlesPerFeed != 3 {
+		t.Errorf("MaxArticlesPerFeed = %d, want 3", cfg.MaxArticlesPerFeed)
+	}
+	wantFeeds := []Feed{
+		{URL: "https://a.example.com/feed"},
+		{
+			URL:  "https://b.example.com/feed",
+			Name: "B Blog",
+			HTTP: &FeedHTTP{
+				Headers:     map[string]string{"X-Team": "backend"},
+				BasicAuth:   &BasicAuth{Username: "u", Password: "p$1"},
+				BearerToken: "secret-token",
+				Proxy:       "http://proxy.example.com:8080",
+			},
+		},
+		{
+			URL:     "https://c.example.com/feed",
+			IconURL: "https://c.example.com/icon.png",
+			Include: &FilterRule{Any: []FilterRule{{Field: "category", Contains: "go"}, {Regex: "(?i)golang"}}},
+			Exclude: &FilterRule{Field: "title", Contains: "sponsored"},
+		},
+	}
+	if len(cfg.Feeds) != len(wantFeeds) {
+		t.Fatalf("Feeds = %+v, want %+v", cfg.Feeds, wantFeeds)
+	}
+	for i, want := range wantFeeds {
+		if !reflect.DeepEqual(cfg.Feeds[i], want) {
+			t.Errorf("Feeds[%d] = %+v, want %+v", i, cfg.Feeds[i], want)
+		}
+	}
+
+	if len(cfg.Destinations) != 4 {
+		t.Fatalf("Destinations = %+v, want 4", cfg.Destinations)
+	}
+	if dest := cfg.Destinations[0]; dest.Type != DestinationSlack || dest.Mode != ModeArticle || dest.Format != FormatAttachments {
+		t.Errorf("default destination = %+v", dest)
+	}
+	if dest := cfg.Destinations[1]; dest.Name != "weekly" || dest.Type != DestinationSlack || dest.Mode != ModeDigest || dest.Format != FormatBlocks ||
+		dest.DigestWindow != 7*24*time.Hour || dest.TemplateDir != "/etc/rss/slack-templates" {
+		t.Errorf("file destination = %+v", dest)
+	}
+	// Slack向けのTEMPLATE_DIRはSlack以外の通知先には引き継がない
+	if dest := cfg.Destinations[2]; dest.Type != DestinationTeams || dest.TemplateDir != "" {
+		t.Errorf("teams destination = %+v", dest)
+	}
+	wantEmail := &Email{
+		SMTPHost: "smtp.example.com",
+		SMTPPort: 587,
+		Username: "rss",
+		Password: "smtp-secret",
+		From:     "rss@example.com",
+		Recipients: []EmailRecipient{
+			{Address: "tanaka@example.com", Language: LanguageJapanese},
+			{Address: "smith@example.com", Language: LanguageEnglish},
+		},
+	}
+	if dest := cfg.Destinations[3]; dest.Type != DestinationEmail || !reflect.DeepEqual(dest.Email, wantEmail) {
+		t.Errorf("email destination = %+v, email = %+v", dest, dest.Email)
+	}
+
+	if cfg.RelevanceProfile != "分散システムとデータベース" || cfg.RelevanceThreshold != 70 {
+		t.Errorf("relevance = %q / %d", cfg.RelevanceProfile, cfg.RelevanceThreshold)
+	}
+}
+
+func TestDestinationValidate(t *testing.T) {
+	valid := Destination{Name: "d", WebhookURL: "https://example.com", Mode: ModeDigest, Format: FormatBlocks, DigestWindow: time.Hour}
+	if err := valid.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	// Slack以外の通知先ではメッセージ形式を使わない
+	discord := Destination{Name: "d", Type: DestinationDiscord, WebhookURL: "https://example.com", Mode: ModeArticle}
+	if err := discord.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	// メールの通知先にはWebhook URLが不要
+	email := Email{SMTPHost: "smtp.example.com", SMTPPort: 587, From: "rss@example.com", Recipients: []EmailRecipient{{Address: "a@example.com", Language: LanguageEnglish}}}
+	mail := Destination{Name: "d", Type: DestinationEmail, Mode: ModeDigest, DigestWindow: time.Hour, Email: &email}
+	if err := mail.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+
+	invalid := []Destination{
+		{WebhookURL: "https://example.com", Mode: ModeThread, Format: FormatBlocks},
+		{Name: "d", Mode: ModeThread, Format: FormatBlocks},
+		{Name: "d", WebhookURL: "https://example.com", Mode: "unknown", Format: FormatBlocks},
+		{Name: "d", WebhookURL: "https://example.com", Mode: ModeThread, Format: "unknown"},
+		{Name: "d", WebhookURL: "https://example.com", Mode: ModeDigest, Format: FormatBlocks},
+		{Name: "d", Type: "irc", WebhookURL: "https://example.com", Mode: ModeArticle, Format: FormatBlocks},
+		// メールはダイジェストのみで、SMTPの設定と宛先が必要
+		{Name: "d", Type: DestinationEmail, Mode: ModeArticle, Email: &email},
+		{Name: "d", Type: DestinationEmail, Mode: ModeDigest, DigestWindow: time.Hour},
+		{Name: "d", Type: DestinationEmail, Mode: ModeDigest, DigestWindow: time.Hour, Email: &Email{SMTPHost: "smtp.example.com", From: "rss@example.com"}},
+		{Name: "d", Type: DestinationEmail, Mode: ModeDigest, DigestWindow: time.Hour, Email: &Email{SMTPHost: "smtp.example.com", From: "rss@example.com",
+			Recipients: []EmailRecipient{{Address: "a@example.com", Language: "fr"}}}},
+	}
+	for _, dest := range invalid {
+		if err := dest.validate(); err == nil {
+			t.Errorf("validate(%+v) error = nil, want error", dest)
+		}
+	}
+}
+
+func TestLoadFeedsSources(t *testing.T) {
+	c := &Config{FeedURLs: []string{"https://www.reddit.com/r/golang/new.json?limit=25"}}
+	feeds, err := c.loadFeeds(&FileConfig{Feeds: []FeedConfig{
+		{Type: SourceGitHubReleases, Repo: "golang/go", IncludePrereleases: true},
+		{Type: SourceHackerNews, MinScore: 100},
+		{Type: SourceReddit, Subreddit: "r/golang", Sort: "new", MinScore: 20},
+		{Type: SourceScrape, URL: "https://example.com/blog", Selectors: &ScrapeSelectors{Item: ".post"}},
+	}})
+	if err != nil {
+		t.Fatalf("loadFeeds() error = %v", err)
+	}
+
+	wantURLs := []string{
+		"https://www.reddit.com/r/golang/new.json?limit=25",
+		"https://api.github.com/repos/golang/go/releases",
+		"https://hn.algolia.com/api/v1/search?tags=front_page",
+		"https://example.com/blog",
+	}
+	if len(feeds) != len(wantURLs) {
+		t.Fatalf("feeds = %+v, want %d feeds", feeds, len(wantURLs))
+	}
+	for i, want := range wantURLs {
+		if feeds[i].URL != want {
+			t.Errorf("feeds[%d].URL = %q, want %q", i, feeds[i].URL, want)
+		}
+	}
+	// FEED_URLSと同じURLになる場合は設定ファイルの定義で上書きする
+	if feeds[0].Type != SourceReddit || feeds[0].MinScore != 20 || !feeds[1].IncludePrereleases || feeds[3].Selectors.Item != ".post" {
+		t.Errorf("feeds = %+v", feeds)
+	}
+
+	c = &Config{Feeds: feeds, DeepLAPIKey: "k", OpenAIAPIKey: "k", SlackWebhookURL: "u", MaxArticlesPerFeed: 1, MaxCatchUp: time.Hour, OverflowPolicy: OverflowDefer, RunSummary: RunSummaryOff,
+		TranslationFallback: FallbackNone, DegradedPolicy: DegradedPost, FeedOutputFormat: FeedFormatAtom, FeedOutputMaxItems: 50, CheckInterval: time.Minute}
+	if err := c.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+	c.AdminToken = "short"
+	if err := c.validate(); err == nil {
+		t.Error("validate() with a short ADMIN_TOKEN error = nil, want error")
+	}
+	c.AdminToken = ""
+	for _, invalid := range []Feed{
+		{URL: "https://example.com/blog", Type: SourceScrape},
+		{URL: "https://example.com/feed", Type: "atom"},
+	} {
+		c.Feeds = []Feed{invalid}
+		if err := c.validate(); err == nil {
+			t.Errorf("validate(%+v) error = nil, want error", invalid)
+		}
+	}
+}
+
+func TestFeedHTTPValidate(t *testing.T) {
+	valid := []*FeedHTTP{
+		nil,
+		{BasicAuth: &BasicAuth{Username: "u", Password: "p"}, Proxy: "http://proxy.example.com:8080"},
+	}
+	for _, h := range valid {
+		if err := h.validate(); err != nil {
+			t.Errorf("validate(%+v) error = %v", h, err)
+		}
+	}
+
+	invalid := []*FeedHTTP{
+		{BasicAuth: &BasicAuth{Password: "p"}},
+		{Proxy: "proxy.example.com"},
+	}
+	for _, h := range invalid {
+		if err := h.validate(); err == nil {
+			t.Errorf("validate(%+v) error = nil, want error", h)
+		}
+	}
+}
+
+func TestParseDigestWindow(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 24 * time.Hour, false},
+		{"daily", 24 * time.Hour, false},
+		{"Weekly", 7 * 24 * time.Hour, false},
+		{"72h", 72 * time.Hour, false},
+		{"monthly", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDigestWindow(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseDigestWindow(%q) = %v, %v, want %v (error: %t)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseFirstRunPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"backfill:5", 5, false},
+		{" Backfill:0 ", 0, false},
+		{"mark-seen", 0, false},
+		{"backfill", 0, true},
+		{"backfill:-1", 0, true},
+		{"all", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFirstRunPolicy(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseFirstRunPolicy(%q) = %d, %v; want %d, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGetBoolFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		def   bool
+		want  bool
+	}{
+		{"", true, true},
+		{"yes", false, true},
+		{"ENABLED", false, true},
+		{"off", true, false},
+		{"0", true, false},
+		{"maybe", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TEST_BOOL", tt.value)
+		if got := getBoolFromEnv("TEST_BOOL", tt.def); got != tt.want {
+			t.Errorf("getBoolFromEnv(%q, %t) = %t, want %t", tt.value, tt.def, got, tt.want)
+		}
+	}
+}
+
+func TestGetIntFromEnv(t *testing.T) {
+	t.Setenv("TEST_INT", "42")
+	if got := getIntFromEnv("TEST_INT", 1); got != 42 {
+		t.Errorf("getIntFromEnv() = %d, want 42", got)
+	}
+
+	t.Setenv("TEST_INT", "abc")
+	if got := getIntFromEnv("TEST_INT", 1); got != 1 {
+		t.Errorf("getIntFromEnv() = %d, want default 1", got)
+	}
+}
+
+func TestGetDurationFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", time.Hour},
+		{"15m", 15 * time.Minute},
+		{" 168h ", 168 * time.Hour},
+		{"daily", time.Hour},
+		{"0s", time.Hour},
+		{"-5m", time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("TEST_DURATION", tt.value)
+		if got := getDurationFromEnv("TEST_DURATION", time.Hour); got != tt.want {
+			t.Errorf("getDurationFromEnv(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,409 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// FileConfig はCONFIG_FILEで指定するJSON設定ファイルの構造体
+type FileConfig struct {
+	Feeds        []FeedConfig        `json:"feeds"`
+	Destinations []DestinationConfig `json:"destinations"`
+	Relevance    *RelevanceConfig    `json:"relevance"`
+
+	OPMLFile         string            `json:"opml_file"`         // 購読フィードを読み込むOPMLファイル（OPML_FILEより優先）
+	CategoryChannels map[string]string `json:"category_channels"` // カテゴリーごとの投稿先チャンネル
+}
+
+// RelevanceConfig は設定ファイル上の関連度判定の定義（環境変数より優先する）
+type RelevanceConfig struct {
+	Profile     string `json:"profile"`      // チームの関心事項の説明
+	ProfileFile string `json:"profile_file"` // 関心事項を記述したファイル（profileより優先）
+	Threshold   *int   `json:"threshold"`    // 0〜100
+}
+
+// FeedConfig は設定ファイル上のフィード定義
+// （FEED_URLSに含まれるURLの場合は表示設定の上書き、含まれない場合はフィードの追加として扱う）
+type FeedConfig struct {
+	URL      string            `json:"url"`
+	Name     string            `json:"name"`
+	IconURL  string            `json:"icon_url"`
+	Footer   string            `json:"footer"`
+	Category string            `json:"category"`
+	Channel  string            `json:"channel"` // 未指定の場合はカテゴリーから決める
+	Filter   *FeedFilterConfig `json:"filter"`
+	HTTP     *FeedHTTP         `json:"http"`
+
+	SilentDays int `json:"silent_days"` // 更新頻度の低いフィードでFEED_SILENT_DAYSを上書きする
+
+	// RSS以外の取得元（urlを省略した場合はrepoやsubredditからAPIのURLを決める）
+	Type               string           `json:"type"`                // rss（既定）, github_releases, hackernews, reddit, scrape
+	Repo               string           `json:"repo"`                // github_releases: "owner/repo"
+	IncludePrereleases bool             `json:"include_prereleases"` // github_releases
+	Subreddit          string           `json:"subreddit"`           // reddit
+	Sort               string           `json:"sort"`                // reddit: hot（既定）, new, top, rising
+	MinScore           int              `json:"min_score"`           // hackernews / reddit
+	Selectors          *ScrapeSelectors `json:"selectors"`           // scrape
+	DateLayout         string           `json:"date_layout"`         // scrape
+}
+
+// ScrapeSelectors はHTMLページから記事を抽出するCSSセレクター（item以外はitemの要素内で評価する）
+type ScrapeSelectors struct {
+	Item        string `json:"item"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	Description string `json:"description"`
+	Date        string `json:"date"`
+	Image       string `json:"image"`
+	Author      string `json:"author"`
+}
+
+// FeedHTTP はフィードの取得に使うHTTP設定（認証が必要なフィードやプロキシ経由のアクセス用）
+// 値の中の ${NAME} は環境変数の値に置き換える（トークンなどを設定ファイルに直接書かないため）
+type FeedHTTP struct {
+	Headers     map[string]string `json:"headers"`
+	UserAgent   string            `json:"user_agent"`
+	BasicAuth   *BasicAuth        `json:"basic_auth"`
+	BearerToken string            `json:"bearer_token"`
+	Cookies     map[string]string `json:"cookies"`
+	Proxy       string            `json:"proxy"`   // 未指定の場合は環境変数（HTTPS_PROXYなど）に従う
+	CAFile      string            `json:"ca_file"` // 追加で信頼するCA証明書（PEM）
+}
+
+// BasicAuth はBasic認証の資格情報
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// FeedFilterConfig は設定ファイル上のフィルター定義
+type FeedFilterConfig struct {
+	Include *FilterRule `json:"include"` // 一致する記事のみを対象にする
+	Exclude *FilterRule `json:"exclude"` // 一致する記事を対象外にする
+}
+
+// FilterRule は記事の絞り込み条件
+// （例: {"any": [{"field": "category", "contains": "go"}, {"field": "title", "regex": "(?i)\\bgolang\\b"}]}）
+type FilterRule struct {
+	All []FilterRule `json:"all"`
+	Any []FilterRule `json:"any"`
+	Not *FilterRule  `json:"not"`
+
+	Field    string `json:"field"`    // title, description, category, author（未指定の場合はタイトルと本文）
+	Contains string `json:"contains"` // 大文字小文字を区別しない部分一致
+	Regex    string `json:"regex"`
+}
+
+// DestinationConfig は設定ファイル上の通知先定義
+type DestinationConfig struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"` // slack（既定）, teams, discord, mattermost, webhook
+	WebhookURL   string `json:"webhook_url"`
+	Channel      string `json:"channel"`
+	Mode         string `json:"mode"`
+	Format       string `json:"format"`        // blocks or attachments
+	TemplateDir  string `json:"template_dir"`  // 未指定の場合はTEMPLATE_DIR
+	DigestWindow string `json:"digest_window"` // daily, weekly, または "72h" などのduration
+	Email        *Email `json:"email"`         // typeがemailの場合のSMTP設定
+}
+
+// Email はメール通知先のSMTP設定
+// （username・passwordの ${NAME} は環境変数の値に置き換える）
+type Email struct {
+	SMTPHost   string           `json:"smtp_host"`
+	SMTPPort   int              `json:"smtp_port"` // 未指定の場合は587（STARTTLSに対応したサーバーでは暗号化する）
+	Username   string           `json:"username"`  // 未指定の場合は認証しない
+	Password   string           `json:"password"`
+	From       string           `json:"from"`
+	Recipients []EmailRecipient `json:"recipients"`
+}
+
+// EmailRecipient はメールの宛先と、受け取る言語
+type EmailRecipient struct {
+	Address  string `json:"address"`
+	Language string `json:"language"` // ja（既定）: 翻訳したタイトルと要約 / en: 原文のタイトルと概要
+}
+
+// loadFileConfig は設定ファイルを読み込む（未指定の場合は空の設定を返す）
+func loadFileConfig(path string) (*FileConfig, error) {
+	if path == "" {
+		return &FileConfig{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var fileConfig FileConfig
+	if err := json.Unmarshal(data, &fileConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return &fileConfig, nil
+}
+
+// loadFeeds はFEED_URLS、OPMLファイル、設定ファイルの順にフィードの一覧を構築する
+// （同じURLのフィードは後のもので上書きする。投稿先チャンネルが未指定のフィードはカテゴリーから決める）
+func (c *Config) loadFeeds(fileConfig *FileConfig) ([]Feed, error) {
+	var feeds []Feed
+	index := make(map[string]int)
+	for _, url := range c.FeedURLs {
+		index[url] = len(feeds)
+		feeds = append(feeds, Feed{URL: url})
+	}
+
+	opmlFile := c.OPMLFile
+	if fileConfig.OPMLFile != "" {
+		opmlFile = fileConfig.OPMLFile
+	}
+	if opmlFile != "" {
+		opmlFeeds, err := LoadOPML(opmlFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, feed := range opmlFeeds {
+			if i, ok := index[feed.URL]; ok {
+				feeds[i] = feed
+				continue
+			}
+			index[feed.URL] = len(feeds)
+			feeds = append(feeds, feed)
+		}
+	}
+
+	for _, fc := range fileConfig.Feeds {
+		feed := Feed{
+			URL:                fc.sourceURL(),
+			Name:               fc.Name,
+			IconURL:            fc.IconURL,
+			Footer:             fc.Footer,
+			Category:           fc.Category,
+			Channel:            fc.Channel,
+			SilentDays:         fc.SilentDays,
+			Type:               fc.Type,
+			IncludePrereleases: fc.IncludePrereleases,
+			MinScore:           fc.MinScore,
+			Selectors:          fc.Selectors,
+			DateLayout:         fc.DateLayout,
+		}
+		if fc.Filter != nil {
+			feed.Include = fc.Filter.Include
+			feed.Exclude = fc.Filter.Exclude
+		}
+		if fc.HTTP != nil {
+			feed.HTTP = fc.HTTP.expandEnv()
+		}
+		if i, ok := index[feed.URL]; ok {
+			// OPMLで指定したカテゴリーは設定ファイルで上書きしない限り引き継ぐ
+			if feed.Category == "" {
+				feed.Category = feeds[i].Category
+			}
+			feeds[i] = feed
+			continue
+		}
+		index[feed.URL] = len(feeds)
+		feeds = append(feeds, feed)
+	}
+
+	for i := range feeds {
+		if feeds[i].Channel == "" {
+			feeds[i].Channel = categoryChannel(feeds[i].Category, fileConfig.CategoryChannels)
+		}
+	}
+
+	return feeds, nil
+}
+
+// categoryChannel はカテゴリーに対応する投稿先チャンネルを返す
+// （category_channels に定義がなく、カテゴリー名が "#" で始まる場合はそのままチャンネル名とする）
+func categoryChannel(category string, channels map[string]string) string {
+	if channel, ok := channels[category]; ok {
+		return channel
+	}
+	if strings.HasPrefix(category, "#") {
+		return category
+	}
+	return ""
+}
+
+// sourceURL はフィードの取得先URLを返す（urlを省略した場合は取得元の種類ごとの既定のAPI）
+func (fc FeedConfig) sourceURL() string {
+	if fc.URL != "" {
+		return fc.URL
+	}
+
+	switch fc.Type {
+	case SourceGitHubReleases:
+		if fc.Repo != "" {
+			return "https://api.github.com/repos/" + strings.Trim(fc.Repo, "/") + "/releases"
+		}
+	case SourceHackerNews:
+		return "https://hn.algolia.com/api/v1/search?tags=front_page"
+	case SourceReddit:
+		if fc.Subreddit != "" {
+			sort := fc.Sort
+			if sort == "" {
+				sort = "hot"
+			}
+			return fmt.Sprintf("https://www.reddit.com/r/%s/%s.json?limit=25", strings.TrimPrefix(fc.Subreddit, "r/"), sort)
+		}
+	}
+	return ""
+}
+
+// envReference は設定値の中の環境変数の参照（${NAME}）
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnvValue は値の中の ${NAME} を環境変数の値に置き換える
+func expandEnvValue(value string) string {
+	return envReference.ReplaceAllStringFunc(value, func(ref string) string {
+		return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+	})
+}
+
+// expandEnv は値の中の ${NAME} を環境変数の値に置き換えたコピーを返す
+func (h *FeedHTTP) expandEnv() *FeedHTTP {
+	expand := expandEnvValue
+	expandMap := func(values map[string]string) map[string]string {
+		if values == nil {
+			return nil
+		}
+		expanded := make(map[string]string, len(values))
+		for key, value := range values {
+			expanded[key] = expand(value)
+		}
+		return expanded
+	}
+
+	expanded := &FeedHTTP{
+		Headers:     expandMap(h.Headers),
+		UserAgent:   expand(h.UserAgent),
+		BearerToken: expand(h.BearerToken),
+		Cookies:     expandMap(h.Cookies),
+		Proxy:       expand(h.Proxy),
+		CAFile:      expand(h.CAFile),
+	}
+	if h.BasicAuth != nil {
+		expanded.BasicAuth = &BasicAuth{Username: expand(h.BasicAuth.Username), Password: expand(h.BasicAuth.Password)}
+	}
+	return expanded
+}
+
+// loadDestinations は環境変数のSlack設定と設定ファイルから通知先の一覧を構築する
+func (c *Config) loadDestinations(fileConfig *FileConfig) ([]Destination, error) {
+	digestWindow, err := parseDigestWindow(os.Getenv("SLACK_DIGEST_WINDOW"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLACK_DIGEST_WINDOW: %w", err)
+	}
+
+	format := getEnvOrDefault("SLACK_MESSAGE_FORMAT", FormatBlocks)
+	templateDir := os.Getenv("TEMPLATE_DIR")
+
+	// 環境変数で指定されたSlackを既定の通知先とする
+	destinations := []Destination{
+		{
+			Name:         "default",
+			Type:         DestinationSlack,
+			WebhookURL:   c.SlackWebhookURL,
+			Channel:      c.SlackChannel,
+			Mode:         getEnvOrDefault("SLACK_NOTIFICATION_MODE", defaultMode(c.SlackUseThreads)),
+			Format:       format,
+			TemplateDir:  templateDir,
+			DigestWindow: digestWindow,
+		},
+	}
+
+	for _, dc := range fileConfig.Destinations {
+		window, err := parseDigestWindow(dc.DigestWindow)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest_window for destination %s: %w", dc.Name, err)
+		}
+
+		mode := dc.Mode
+		if mode == "" {
+			mode = defaultMode(c.SlackUseThreads)
+		}
+
+		destType := dc.Type
+		if destType == "" {
+			destType = DestinationSlack
+		}
+
+		destFormat := dc.Format
+		if destFormat == "" {
+			destFormat = format
+		}
+
+		// TEMPLATE_DIRはSlack向けのテンプレートのため、Slack以外の通知先には引き継がない
+		destTemplateDir := dc.TemplateDir
+		if destTemplateDir == "" && destType == DestinationSlack {
+			destTemplateDir = templateDir
+		}
+
+		destinations = append(destinations, Destination{
+			Name:         dc.Name,
+			Type:         destType,
+			Email:        dc.Email.withDefaults(),
+			WebhookURL:   dc.WebhookURL,
+			Channel:      dc.Channel,
+			Mode:         mode,
+			Format:       destFormat,
+			TemplateDir:  destTemplateDir,
+			DigestWindow: window,
+		})
+	}
+
+	return destinations, nil
+}
+
+// withDefaults は未指定の値に既定値を設定し、資格情報の ${NAME} を環境変数の値に置き換えたコピーを返す
+func (e *Email) withDefaults() *Email {
+	if e == nil {
+		return nil
+	}
+	email := *e
+	if email.SMTPPort == 0 {
+		email.SMTPPort = defaultSMTPPort
+	}
+	email.Username = expandEnvValue(email.Username)
+	email.Password = expandEnvValue(email.Password)
+	email.Recipients = make([]EmailRecipient, len(e.Recipients))
+	for i, recipient := range e.Recipients {
+		if recipient.Language == "" {
+			recipient.Language = LanguageJapanese
+		}
+		email.Recipients[i] = recipient
+	}
+	return &email
+}
+
+// loadRelevance は環境変数と設定ファイルから関連度判定の設定を読み込む
+func (c *Config) loadRelevance(fileConfig *FileConfig) error {
+	profile := os.Getenv("RELEVANCE_PROFILE")
+	profileFile := os.Getenv("RELEVANCE_PROFILE_FILE")
+	c.RelevanceThreshold = getIntFromEnv("RELEVANCE_THRESHOLD", 50)
+
+	if rc := fileConfig.Relevance; rc != nil {
+		if rc.Profile != "" || rc.ProfileFile != "" {
+			profile, profileFile = rc.Profile, rc.ProfileFile
+		}
+		if rc.Threshold != nil {
+			c.RelevanceThreshold = *rc.Threshold
+		}
+	}
+
+	if profileFile != "" {
+		data, err := os.ReadFile(profileFile)
+		if err != nil {
+			return fmt.Errorf("failed to read relevance profile: %w", err)
+		}
+		profile = string(data)
+	}
+	c.RelevanceProfile = strings.TrimSpace(profile)
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// opmlDocument はOPML（フィードリーダーの購読リスト）の構造体
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title,omitempty"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []opmlOutline `xml:"outline"`
+	} `xml:"body"`
+}
+
+// opmlOutline はOPMLのoutline要素（xmlUrlを持つものがフィード、持たないものがカテゴリー）
+type opmlOutline struct {
+	Text     string        `xml:"text,attr,omitempty"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// name はoutlineの表示名を返す（titleがない場合はtext）
+func (o opmlOutline) name() string {
+	if title := strings.TrimSpace(o.Title); title != "" {
+		return title
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+// LoadOPML はOPMLファイルからフィードを読み込む
+// フィードを囲むoutlineの名前（なければcategory属性の最初の値）をカテゴリーとする
+func LoadOPML(path string) ([]Feed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OPML file: %w", err)
+	}
+
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OPML file %s: %w", path, err)
+	}
+
+	var feeds []Feed
+	var walk func(outlines []opmlOutline, category string)
+	walk = func(outlines []opmlOutline, category string) {
+		for _, outline := range outlines {
+			if outline.XMLURL == "" {
+				// フィードを持たないoutlineはカテゴリー（入れ子の場合は最も内側のもの）
+				walk(outline.Outlines, outline.name())
+				continue
+			}
+
+			feed := Feed{
+				URL:      strings.TrimSpace(outline.XMLURL),
+				Name:     outline.name(),
+				Category: category,
+			}
+			if feed.Category == "" {
+				feed.Category = opmlCategory(outline.Category)
+			}
+			feeds = append(feeds, feed)
+		}
+	}
+	walk(doc.Body.Outlines, "")
+
+	return feeds, nil
+}
+
+// opmlCategory はcategory属性（"/Tech/Go,/News" のようなカンマ区切りのパス）から最初のカテゴリー名を返す
+func opmlCategory(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	first = strings.Trim(strings.TrimSpace(first), "/")
+	if i := strings.LastIndex(first, "/"); i >= 0 {
+		first = first[i+1:]
+	}
+	return first
+}
+
+// WriteOPML はフィードをOPMLとして書き出す（カテゴリーごとにoutlineでまとめる）
+// OPMLで表現できないRSS以外の取得元（GitHub Releasesなど）は書き出さない
+func WriteOPML(w io.Writer, feeds []Feed) error {
+	var doc opmlDocument
+	doc.Version = "2.0"
+	doc.Head.Title = "RSS通知 購読フィード"
+	doc.Head.DateCreated = time.Now().Format(time.RFC1123Z)
+
+	categories := make(map[string]int)
+	for _, feed := range feeds {
+		if feed.Type != "" && feed.Type != SourceRSS {
+			continue
+		}
+
+		name := feed.Name
+		if name == "" {
+			name = feed.URL
+		}
+		outline := opmlOutline{Text: name, Title: name, Type: "rss", XMLURL: feed.URL}
+
+		// カテゴリーがない場合はチャンネルをカテゴリーとして書き出す（読み込み時に同じチャンネルになる）
+		category := feed.Category
+		if category == "" {
+			category = feed.Channel
+		}
+		if category == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+
+		i, ok := categories[category]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			categories[category] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, opmlOutline{Text: category, Title: category})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, outline)
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal OPML: %w", err)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testOPML = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>subscriptions</title></head>
+  <body>
+    <outline text="Tech">
+      <outline text="Go">
+        <outline type="rss" text="The Go Blog" xmlUrl="https://go.dev/blog/feed.atom" htmlUrl="https://go.dev/blog"/>
+      </outline>
+      <outline type="rss" text="GitHub Blog" title="The GitHub Blog" xmlUrl="https://github.blog/feed/"/>
+    </outline>
+    <outline type="rss" text="Hacker News" xmlUrl="https://news.ycombinator.com/rss" category="/News/Aggregators,/Tech"/>
+    <outline type="rss" text="Uncategorized" xmlUrl="https://example.com/feed.xml"/>
+  </body>
+</opml>`
+
+// writeOPML はテスト用のOPMLファイルを作成する
+func writeOPML(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "feeds.opml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadOPML(t *testing.T) {
+	feeds, err := LoadOPML(writeOPML(t, testOPML))
+	if err != nil {
+		t.Fatalf("LoadOPML() error = %v", err)
+	}
+
+	want := []Feed{
+		{URL: "https://go.dev/blog/feed.atom", Name: "The Go Blog", Category: "Go"},
+		{URL: "https://github.blog/feed/", Name: "The GitHub Blog", Category: "Tech"},
+		{URL: "https://news.ycombinator.com/rss", Name: "Hacker News", Category: "Aggregators"},
+		{URL: "https://example.com/feed.xml", Name: "Uncategorized"},
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("feeds = %+v, want %d feeds", feeds, len(want))
+	}
+	for i := range want {
+		if feeds[i] != want[i] {
+			t.Errorf("feeds[%d] = %+v, want %+v", i, feeds[i], want[i])
+		}
+	}
+
+	if _, err := LoadOPML(writeOPML(t, "<opml><body>")); err == nil {
+		t.Error("LoadOPML() with invalid XML error = nil, want error")
+	}
+}
+
+func TestLoadFeedsOPMLCategoryChannels(t *testing.T) {
+	c := &Config{
+		FeedURLs: []string{"https://example.com/feed.xml"},
+		OPMLFile: writeOPML(t, testOPML),
+	}
+	feeds, err := c.loadFeeds(&FileConfig{
+		CategoryChannels: map[string]string{"Go": "#golang", "Tech": "#tech"},
+		Feeds: []FeedConfig{
+			{URL: "https://github.blog/feed/", Name: "GitHub"},
+			{URL: "https://news.ycombinator.com/rss", Channel: "#hn"},
+			{URL: "https://example.org/feed", Category: "#misc"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("loadFeeds() error = %v", err)
+	}
+
+	want := map[string]string{
+		"https://example.com/feed.xml":     "",
+		"https://go.dev/blog/feed.atom":    "#golang",
+		"https://github.blog/feed/":        "#tech", // 設定ファイルで上書きしてもOPMLのカテゴリーを引き継ぐ
+		"https://news.ycombinator.com/rss": "#hn",
+		"https://example.org/feed":         "#misc",
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("feeds = %+v, want %d feeds", feeds, len(want))
+	}
+	for _, feed := range feeds {
+		if channel, ok := want[feed.URL]; !ok || feed.Channel != channel {
+			t.Errorf("feed %s channel = %q, want %q", feed.URL, feed.Channel, channel)
+		}
+	}
+	if feeds[0].Name != "Uncategorized" {
+		t.Errorf("FEED_URLS feed name = %q, want name from OPML", feeds[0].Name)
+	}
+
+	c.OPMLFile = filepath.Join(t.TempDir(), "missing.opml")
+	if _, err := c.loadFeeds(&FileConfig{}); err == nil {
+		t.Error("loadFeeds() with missing OPML error = nil, want error")
+	}
+}
+
+func TestWriteOPMLRoundTrip(t *testing.T) {
+	feeds := []Feed{
+		{URL: "https://go.dev/blog/feed.atom", Name: "The Go Blog", Category: "Go"},
+		{URL: "https://github.blog/feed/", Category: "Go"},
+		{URL: "https://example.com/feed.xml", Name: "Example", Channel: "#example"},
+		{URL: "https://example.org/feed", Name: "Plain"},
+		{URL: "https://api.github.com/repos/golang/go/releases", Type: SourceGitHubReleases},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOPML(&buf, feeds); err != nil {
+		t.Fatalf("WriteOPML() error = %v", err)
+	}
+
+	got, err := LoadOPML(writeOPML(t, buf.String()))
+	if err != nil {
+		t.Fatalf("LoadOPML() error = %v\n%s", err, buf.String())
+	}
+	want := []Feed{
+		{URL: "https://go.dev/blog/feed.atom", Name: "The Go Blog", Category: "Go"},
+		{URL: "https://github.blog/feed/", Name: "https://github.blog/feed/", Category: "Go"},
+		{URL: "https://example.com/feed.xml", Name: "Example", Category: "#example"},
+		{URL: "https://example.org/feed", Name: "Plain"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("round trip feeds = %+v, want %d feeds", got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("feeds[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
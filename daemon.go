@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/service"
+)
+
+// maxResultsLimit は検索結果や最近の記事の一覧で返す最大件数（?limitで指定できる上限）
+const maxResultsLimit = 100
+
+// daemon は常駐してCHECK_INTERVAL_MINUTESごとにRSSチェックを実行し、HTTPサーバーで翻訳フィードを配信する
+func daemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	addr := fs.String("addr", "", "HTTPサーバーの待ち受けアドレス（省略した場合はHTTP_ADDR）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.LoadConfig()
+	if *addr != "" {
+		cfg.HTTPAddr = *addr
+	}
+
+	shutdownTracing, err := setupTracing(cfg)
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing()
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		return fmt.Errorf("アプリケーションの初期化に失敗しました: %w", err)
+	}
+	defer app.Close()
+	if err := app.TestConnections(); err != nil {
+		return fmt.Errorf("接続テストに失敗しました: %w", err)
+	}
+
+	// SIGINT・SIGTERMを受け取ったら実行中のRSSチェックの完了を待って終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return app.RunDaemon(ctx)
+}
+
+// RunDaemon はHTTPサーバーを起動し、ctxが終了するまで一定間隔でRunOnceを実行する
+// （実行ごとの失敗は実行サマリーで知らせ、デーモン自体は停止しない）
+func (app *App) RunDaemon(ctx context.Context) error {
+	server := &http.Server{
+		Addr:              app.config.HTTPAddr,
+		Handler:           app.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("HTTPサーバーを開始します: %s", app.config.HTTPAddr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("WARNING: HTTPサーバーの停止に失敗しました: %v", err)
+		}
+	}()
+
+	if app.config.AdminToken == "" {
+		log.Println("ADMIN_TOKENが設定されていないため、管理APIと管理画面は無効です")
+	}
+	log.Printf("デーモンモードで実行します（チェック間隔: %s）", app.config.CheckInterval)
+	ticker := time.NewTicker(app.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		app.runMu.Lock()
+		app.RunOnce()
+		app.runMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			log.Println("デーモンを停止します...")
+			return nil
+		case err := <-serverErr:
+			return fmt.Errorf("HTTPサーバーが停止しました: %w", err)
+		case <-ticker.C:
+		case <-app.trigger:
+			log.Println("管理APIからの要求によりRSSチェックを実行します")
+		}
+	}
+}
+
+// Handler はデーモンモードのHTTPサーバーのハンドラーを返す
+func (app *App) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/feed.atom", app.serveFeed(service.FeedFormatAtom))
+	mux.HandleFunc("/feed.rss", app.serveFeed(service.FeedFormatRSS))
+	mux.HandleFunc("/feed.json", app.serveFeed(service.FeedFormatJSON))
+	mux.HandleFunc("/search", app.serveSearch)
+	mux.Handle("/metrics", app.metrics.Handler())
+
+	// 管理APIと管理画面はトークンを設定した場合のみ公開する
+	if app.config.AdminToken != "" {
+		app.registerAPI(mux)
+		app.registerAdmin(mux)
+	}
+	return mux
+}
+
+// serveFeed は翻訳フィードを指定した形式で配信するハンドラーを返す
+func (app *App) serveFeed(format string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		items := app.publishedStore.Items()
+		if len(items) > 0 {
+			w.Header().Set("Last-Modified", items[0].AddedAt.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Set("Content-Type", service.FeedContentType(format))
+		if err := service.WriteFeed(w, format, app.feedMeta(requestURL(r)), items, time.Now()); err != nil {
+			log.Printf("ERROR: 翻訳フィードの配信に失敗しました: %v", err)
+		}
+	}
+}
+
+// serveSearch はアーカイブを全文検索し、結果をJSONで返す（?q=検索語&limit=件数。件数は1〜100件）
+func (app *App) serveSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if app.archive == nil {
+		http.Error(w, "archive is disabled", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	limit := 0 // 指定しない場合はアーカイブの既定の件数
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxResultsLimit)
+	}
+
+	articles, err := app.archive.Search(query, limit)
+	if err != nil {
+		log.Printf("ERROR: アーカイブの検索に失敗しました: %v", err)
+		http.Error(w, "search failed", http.StatusInternalServerError)
+		return
+	}
+	if articles == nil {
+		articles = []*service.ArchivedArticle{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"query": query, "articles": articles})
+}
+
+// writeJSON はレスポンスをJSONで書き込む
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("ERROR: レスポンスの書き込みに失敗しました: %v", err)
+	}
+}
+
+// requestURL はリクエストされたURLを返す（リバースプロキシのX-Forwarded-Proto・X-Forwarded-Hostを考慮する）
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host + r.URL.Path
+}
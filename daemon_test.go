@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+
+	"rss-en-to-jp-notification/internal/fake"
+	"rss-en-to-jp-notification/service"
+)
+
+func TestRunDaemon(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app := newTestApp(t, env)
+	app.config.HTTPAddr = "127.0.0.1:0"
+	app.config.CheckInterval = 10 * time.Millisecond
+
+	// 停止するまで一定間隔で実行し、同じ記事は重複して通知しない
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := app.RunDaemon(ctx); err != nil {
+		t.Fatalf("RunDaemon() error = %v", err)
+	}
+	if messages := env.slack.Messages(); len(messages) != 2 {
+		t.Errorf("got %d Slack messages, want the title and summary once", len(messages))
+	}
+	if items := app.publishedStore.Items(); len(items) != 1 {
+		t.Errorf("published items = %d, want 1", len(items))
+	}
+}
+
+func TestHandlerServesFeed(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Scaling Databases", Link: "https://example.com/databases", GUID: "2", Published: time.Now().Add(-2 * time.Hour)},
+	)
+	app := newTestApp(t, env)
+	app.config.FeedOutputTitle = "翻訳フィード"
+	app.RunOnce()
+
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	for path, contentType := range map[string]string{
+		"/feed.atom": "application/atom+xml",
+		"/feed.rss":  "application/rss+xml",
+		"/feed.json": "application/feed+json",
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), contentType) {
+			t.Errorf("GET %s = %d (%s)", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+			continue
+		}
+
+		feed, err := gofeed.NewParser().ParseString(string(body))
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v\n%s", path, err, body)
+		}
+		if feed.Title != "翻訳フィード" || len(feed.Items) != 2 || feed.Items[0].Link != "https://example.com/caches" ||
+			!strings.HasPrefix(feed.Items[0].Title, "[JA] ") {
+			t.Errorf("%s = %+v, items = %+v", path, feed, feed.Items)
+		}
+	}
+
+	resp, err := http.Post(server.URL+"/feed.atom", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /feed.atom = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestHandlerSearch(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Description: "Cache invalidation.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Scaling Databases", Link: "https://example.com/databases", GUID: "2", Published: time.Now().Add(-2 * time.Hour)},
+	)
+	app := newTestApp(t, env)
+	app.RunOnce()
+
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	// 原文と翻訳のどちらでも検索できる
+	for _, query := range []string{"caches", "[JA] Understanding"} {
+		resp, err := http.Get(server.URL + "/search?q=" + url.QueryEscape(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Articles []*service.ArchivedArticle `json:"articles"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /search?q=%s = %d, %v", query, resp.StatusCode, err)
+		}
+		if len(body.Articles) != 1 || body.Articles[0].Result.Link != "https://example.com/caches" {
+			t.Errorf("GET /search?q=%s = %+v", query, body.Articles)
+		}
+	}
+
+	for _, path := range []string{"/search", "/search?q=cache&limit=x", "/search?q=cache&limit=0", "/search?q=cache&limit=-1"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Description: "A deep dive.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	env.slack.SetFail(true)
+	app := newTestApp(t, env)
+	app.RunOnce()
+
+	// 送信箱からの再送に成功すると通知数に数える
+	env.slack.SetFail(false)
+	for _, entry := range app.outbox.Pending() {
+		entry.NextAttempt = time.Time{}
+	}
+	app.RunOnce()
+
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, %v", resp.StatusCode, err)
+	}
+
+	for _, want := range []string{
+		`rss_notification_feed_fetch_duration_seconds_count{feed="` + env.feed.URL + `"} 2`,
+		`rss_notification_items_found_total{feed="` + env.feed.URL + `"} 2`,
+		`rss_notification_items_new_total{feed="` + env.feed.URL + `"} 1`,
+		`rss_notification_items_posted_total{destination="default"} 1`,
+		`rss_notification_translation_characters_total{provider="deepl"} 32`,
+		`rss_notification_openai_tokens_total{operation="summarize",type="prompt"} 10`,
+		`rss_notification_notification_send_failures_total{notifier="slack"} 1`,
+		`rss_notification_runs_total{result="failure"} 1`,
+		`rss_notification_runs_total{result="success"} 1`,
+		"rss_notification_run_duration_seconds ",
+		"go_goroutines ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
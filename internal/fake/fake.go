@@ -0,0 +1,473 @@
+// Package fake はテスト用に外部サービス（RSSフィード、DeepL、OpenAI、Slack、各種Webhook、SMTP）を模したローカルサーバーを提供する
+package fake
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Item はフィードサーバーが配信する記事
+type Item struct {
+	Title       string
+	Description string
+	Link        string
+	GUID        string
+	Published   time.Time // ゼロ値の場合は pubDate を出力しない
+	Categories  []string
+	Author      string // dc:creator として出力する
+}
+
+// FeedServer はRSSフィードを配信するサーバー
+type FeedServer struct {
+	*httptest.Server
+
+	mu    sync.Mutex
+	title string
+	items []Item
+	fail  bool
+}
+
+// NewFeedServer はRSS 2.0形式でitemsを配信するサーバーを起動する
+func NewFeedServer(t *testing.T, title string, items ...Item) *FeedServer {
+	t.Helper()
+
+	fs := &FeedServer{title: title, items: items}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fs.mu.Lock()
+		fail := fs.fail
+		fs.mu.Unlock()
+		if fail {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		io.WriteString(w, fs.rss())
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+// SetItems は配信する記事を差し替える
+func (fs *FeedServer) SetItems(items ...Item) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.items = items
+}
+
+// SetFail はtrueの場合にエラーを返すようにする
+func (fs *FeedServer) SetFail(fail bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.fail = fail
+}
+
+// rss はRSS 2.0のXMLを生成する
+func (fs *FeedServer) rss() string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel>`)
+	fmt.Fprintf(&b, "<title>%s</title><link>%s</link>", fs.title, fs.URL)
+	for _, item := range fs.items {
+		b.WriteString("<item>")
+		fmt.Fprintf(&b, "<title><![CDATA[%s]]></title>", item.Title)
+		fmt.Fprintf(&b, "<description><![CDATA[%s]]></description>", item.Description)
+		fmt.Fprintf(&b, "<link>%s</link>", item.Link)
+		if item.GUID != "" {
+			fmt.Fprintf(&b, "<guid>%s</guid>", item.GUID)
+		}
+		if !item.Published.IsZero() {
+			fmt.Fprintf(&b, "<pubDate>%s</pubDate>", item.Published.Format(time.RFC1123Z))
+		}
+		for _, category := range item.Categories {
+			fmt.Fprintf(&b, "<category>%s</category>", category)
+		}
+		if item.Author != "" {
+			fmt.Fprintf(&b, "<dc:creator>%s</dc:creator>", item.Author)
+		}
+		b.WriteString("</item>")
+	}
+	b.WriteString("</channel></rss>")
+	return b.String()
+}
+
+// NewDeepLServer は受け取ったテキストに "[JA]" を付けて返すDeepL APIを起動する
+func NewDeepLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "DeepL-Auth-Key ") {
+			http.Error(w, "unauthorized", http.StatusForbidden)
+			return
+		}
+
+		var texts []string
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			var req struct {
+				Text []string `json:"text"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			texts = req.Text
+		} else {
+			r.ParseForm()
+			texts = r.Form["text"]
+		}
+
+		type translation struct {
+			DetectedSourceLanguage string `json:"detected_source_language"`
+			Text                   string `json:"text"`
+		}
+		var resp struct {
+			Translations []translation `json:"translations"`
+		}
+		for _, text := range texts {
+			resp.Translations = append(resp.Translations, translation{DetectedSourceLanguage: "EN", Text: "[JA] " + text})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// OpenAIServer はChat Completions APIを模したサーバー
+type OpenAIServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	reply     string
+	replyFunc func(prompt string) string
+	requests  int
+}
+
+// NewOpenAIServer は常にreplyを返すOpenAI APIを起動する（BaseURLには URL + "/v1" を指定する）
+func NewOpenAIServer(t *testing.T, reply string) *OpenAIServer {
+	t.Helper()
+
+	oa := &OpenAIServer{reply: reply}
+	oa.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		oa.mu.Lock()
+		oa.requests++
+		reply := oa.reply
+		if oa.replyFunc != nil && len(req.Messages) > 0 {
+			reply = oa.replyFunc(req.Messages[len(req.Messages)-1].Content)
+		}
+		oa.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":      "chatcmpl-test",
+			"object":  "chat.completion",
+			"created": time.Now().Unix(),
+			"model":   "gpt-3.5-turbo",
+			"choices": []map[string]interface{}{
+				{
+					"index":         0,
+					"message":       map[string]string{"role": "assistant", "content": reply},
+					"finish_reason": "stop",
+				},
+			},
+			"usage": map[string]int{"prompt_tokens": 10, "completion_tokens": 10, "total_tokens": 20},
+		})
+	}))
+	t.Cleanup(oa.Close)
+	return oa
+}
+
+// SetReplyFunc はリクエストの最後のメッセージ（プロンプト）に応じて返答を決める関数を設定する
+func (oa *OpenAIServer) SetReplyFunc(fn func(prompt string) string) {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	oa.replyFunc = fn
+}
+
+// BaseURL はOpenAIクライアントに指定する接続先を返す
+func (oa *OpenAIServer) BaseURL() string {
+	return oa.URL + "/v1"
+}
+
+// Requests は受け付けたリクエスト数を返す
+func (oa *OpenAIServer) Requests() int {
+	oa.mu.Lock()
+	defer oa.mu.Unlock()
+	return oa.requests
+}
+
+// SlackServer はIncoming Webhookを模したサーバー
+type SlackServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []map[string]interface{}
+	fail     bool
+}
+
+// NewSlackServer は受信したメッセージを記録するSlack Webhookを起動する
+func NewSlackServer(t *testing.T) *SlackServer {
+	t.Helper()
+
+	ss := &SlackServer{}
+	ss.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ss.mu.Lock()
+		defer ss.mu.Unlock()
+
+		if ss.fail {
+			http.Error(w, "internal_error", http.StatusInternalServerError)
+			return
+		}
+
+		var message map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		ss.messages = append(ss.messages, message)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(ss.Close)
+	return ss
+}
+
+// SetFail はtrueの場合にエラーを返すようにする
+func (ss *SlackServer) SetFail(fail bool) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.fail = fail
+}
+
+// Messages は受信したメッセージを返す
+func (ss *SlackServer) Messages() []map[string]interface{} {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return append([]map[string]interface{}(nil), ss.messages...)
+}
+
+// Texts は受信したメッセージの text を返す
+func (ss *SlackServer) Texts() []string {
+	var texts []string
+	for _, message := range ss.Messages() {
+		text, _ := message["text"].(string)
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// WebhookServer はTeams・Discordなど、2xxを返すWebhookを模したサーバー
+type WebhookServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	messages []map[string]interface{}
+	fail     bool
+}
+
+// NewWebhookServer は受信したメッセージを記録し、statusとreplyを返すWebhookを起動する
+func NewWebhookServer(t *testing.T, status int, reply string) *WebhookServer {
+	t.Helper()
+
+	ws := &WebhookServer{}
+	ws.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+
+		if ws.fail {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		var message map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		ws.messages = append(ws.messages, message)
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(ws.Close)
+	return ws
+}
+
+// SetFail はtrueの場合にエラーを返すようにする
+func (ws *WebhookServer) SetFail(fail bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.fail = fail
+}
+
+// Messages は受信したメッセージを返す
+func (ws *WebhookServer) Messages() []map[string]interface{} {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return append([]map[string]interface{}(nil), ws.messages...)
+}
+
+// SMTPMessage はSMTPサーバーが受信したメール
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string // ヘッダーと本文（ドットの重複は取り除く）
+}
+
+// SMTPServer は認証・TLSなしでメールを受け付けるSMTPサーバー
+type SMTPServer struct {
+	Host string
+	Port int
+
+	listener net.Listener
+	mu       sync.Mutex
+	messages []SMTPMessage
+	reject   map[string]bool
+}
+
+// NewSMTPServer は受信したメールを記録するSMTPサーバーを起動する
+func NewSMTPServer(t *testing.T) *SMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start SMTP server: %v", err)
+	}
+	addr := listener.Addr().(*net.TCPAddr)
+	ss := &SMTPServer{Host: addr.IP.String(), Port: addr.Port, listener: listener, reject: make(map[string]bool)}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go ss.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return ss
+}
+
+// Reject は宛先へのメールを拒否する（RCPT TOに550を返す）
+func (ss *SMTPServer) Reject(address string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.reject[address] = true
+}
+
+// Accept はRejectで拒否していた宛先へのメールを再び受け付ける
+func (ss *SMTPServer) Accept(address string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	delete(ss.reject, address)
+}
+
+// Messages は受信したメールを返す
+func (ss *SMTPServer) Messages() []SMTPMessage {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return append([]SMTPMessage(nil), ss.messages...)
+}
+
+// serve は1つの接続でSMTPのコマンドを処理する
+func (ss *SMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+	// address は "MAIL FROM:<a@example.com> BODY=8BITMIME" などからアドレスを取り出す
+	address := func(line string) string {
+		start, end := strings.Index(line, "<"), strings.Index(line, ">")
+		if start < 0 || end < start {
+			return ""
+		}
+		return line[start+1 : end]
+	}
+
+	reply("220 localhost ESMTP fake")
+	var message SMTPMessage
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message = SMTPMessage{From: address(line)}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			to := address(line)
+			ss.mu.Lock()
+			rejected := ss.reject[to]
+			ss.mu.Unlock()
+			if rejected {
+				reply("550 mailbox unavailable")
+				continue
+			}
+			message.To = append(message.To, to)
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.TrimRight(dataLine, "\r\n") == "." {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			message.Data = data.String()
+			ss.mu.Lock()
+			ss.messages = append(ss.messages, message)
+			ss.mu.Unlock()
+			reply("250 OK")
+		case command == "RSET":
+			message = SMTPMessage{}
+			reply("250 OK")
+		case command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
@@ -0,0 +1,291 @@
+// Package recorder は外部APIとのHTTP通信を記録・再生するhttp.RoundTripperを提供する
+// （一度記録したカセットを使って、DeepL・OpenAI・Slackに接続せずにパイプラインを再現する）
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode は記録・再生のモード
+type Mode string
+
+const (
+	ModeOff    Mode = "off"    // 記録も再生もしない
+	ModeRecord Mode = "record" // 実際に通信し、結果をカセットに記録する
+	ModeReplay Mode = "replay" // 通信せず、カセットの内容を返す
+)
+
+// redacted は秘匿情報を置き換える文字列
+const redacted = "REDACTED"
+
+// sensitiveHeaders は値を記録しないヘッダー
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Openai-Organization"}
+
+// Cassette は記録された通信の一覧
+type Cassette struct {
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction は1回分のリクエストとレスポンス
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request は記録されたリクエスト
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response は記録されたレスポンス
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Transport は通信を記録・再生するhttp.RoundTripper
+type Transport struct {
+	mode    Mode
+	path    string
+	inner   http.RoundTripper
+	secrets []string
+	headers []string // 値を記録しないリクエストヘッダー（sensitiveHeadersに加えて指定したもの）
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New はTransportを作成する
+// secretsに指定した文字列（APIキーやWebhook URLなど）は、記録時にカセットから取り除かれる
+func New(mode Mode, path string, inner http.RoundTripper, secrets ...string) (*Transport, error) {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
+	t := &Transport{
+		mode:     mode,
+		path:     path,
+		inner:    inner,
+		cassette: &Cassette{RecordedAt: time.Now()},
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			t.secrets = append(t.secrets, secret)
+		}
+	}
+
+	switch mode {
+	case ModeOff, ModeRecord:
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, t.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		t.used = make([]bool, len(t.cassette.Interactions))
+	default:
+		return nil, fmt.Errorf("invalid record mode %q (off, record, replay)", mode)
+	}
+
+	return t, nil
+}
+
+// RedactRequestHeaders は記録時に値を置き換えるリクエストヘッダーを追加する
+// （ヘッダーの値はsecretsと異なりボディやURLでは置き換えないため、Acceptなど一般的な値を含むヘッダーも指定できる）
+func (t *Transport) RedactRequestHeaders(names ...string) {
+	t.headers = append(t.headers, names...)
+}
+
+// Now は基準時刻を返す（再生時は記録した時刻、それ以外は現在時刻）
+func (t *Transport) Now() time.Time {
+	if t.mode == ModeReplay {
+		return t.cassette.RecordedAt
+	}
+	return time.Now()
+}
+
+// Unused は再生時にまだ使われていない記録の数を返す
+func (t *Transport) Unused() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, used := range t.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// RoundTrip はhttp.RoundTripperの実装
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch t.mode {
+	case ModeRecord:
+		return t.record(req)
+	case ModeReplay:
+		return t.replay(req)
+	default:
+		return t.inner.RoundTrip(req)
+	}
+}
+
+// record は実際に通信し、その内容をカセットに追記して保存する
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	resp, err := t.inner.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     t.redact(req.URL.String()),
+			Headers: t.redactHeaders(req.Header, t.headers...),
+			Body:    t.redact(reqBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    t.redactHeaders(resp.Header),
+			Body:       t.redact(respBody),
+		},
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// replay はカセットから一致するレスポンスを返す
+// （メソッドとURLが一致する未使用の記録のうち、ボディも一致するものを優先する）
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	url := t.redact(req.URL.String())
+	body := t.redact(reqBody)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	match := -1
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != url {
+			continue
+		}
+		if interaction.Request.Body == body {
+			match = i
+			break
+		}
+		if match == -1 {
+			match = i
+		}
+	}
+	if match == -1 {
+		return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, url)
+	}
+	t.used[match] = true
+
+	recorded := t.cassette.Interactions[match].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(recorded.Body)),
+		ContentLength: int64(len(recorded.Body)),
+		Request:       req,
+	}, nil
+}
+
+// save はカセットをファイルに書き込む（呼び出し側でロックを取得していること）
+func (t *Transport) save() error {
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// redact は秘匿情報を置き換える
+func (t *Transport) redact(s string) string {
+	for _, secret := range t.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	return s
+}
+
+// redactHeaders は秘匿情報を含むヘッダーを置き換えたコピーを返す（extraに指定したヘッダーも値を置き換える）
+func (t *Transport) redactHeaders(header http.Header, extra ...string) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		for _, value := range values {
+			clone.Add(key, t.redact(value))
+		}
+	}
+	for _, keys := range [][]string{sensitiveHeaders, extra} {
+		for _, key := range keys {
+			if clone.Get(key) != "" {
+				clone.Set(key, redacted)
+			}
+		}
+	}
+	return clone
+}
+
+// readBody はボディを読み取り、再度読めるように差し替える
+func readBody(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return "", err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
@@ -0,0 +1,131 @@
+package recorder
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		io.WriteString(w, "echo:"+string(body))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recording, err := New(ModeRecord, path, nil, "secret-key")
+	if err != nil {
+		t.Fatalf("New(record) error = %v", err)
+	}
+	client := &http.Client{Transport: recording}
+
+	for _, body := range []string{"first", "second"} {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api?key=secret-key", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret-key")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("record request error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// 秘匿情報はカセットに残らない
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cassette not written: %v", err)
+	}
+	if strings.Contains(string(data), "secret-key") {
+		t.Errorf("cassette contains secret: %s", data)
+	}
+
+	// サーバーを停止しても再生できる
+	server.Close()
+	replaying, err := New(ModeReplay, path, nil, "secret-key")
+	if err != nil {
+		t.Fatalf("New(replay) error = %v", err)
+	}
+	client = &http.Client{Transport: replaying}
+
+	// 順番が入れ替わってもボディが一致する記録を返す
+	for _, body := range []string{"second", "first"} {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/api?key=secret-key", strings.NewReader(body))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("replay request error = %v", err)
+		}
+		got, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(got) != "echo:"+body {
+			t.Errorf("replayed body = %q, want %q", got, "echo:"+body)
+		}
+	}
+	if n := replaying.Unused(); n != 0 {
+		t.Errorf("Unused() = %d, want 0", n)
+	}
+
+	// 記録にないリクエストはエラーになる
+	if _, err := client.Get(server.URL + "/api?key=secret-key"); err == nil {
+		t.Error("expected error for unrecorded request")
+	}
+}
+
+func TestRecordRedactsRequestHeaders(t *testing.T) {
+	const page = `{"content_type":"application/json","lang":"en"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, page)
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recording, err := New(ModeRecord, path, nil)
+	if err != nil {
+		t.Fatalf("New(record) error = %v", err)
+	}
+	recording.RedactRequestHeaders("Accept", "Accept-Language", "X-Api-Token")
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/feed", nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", "en")
+	req.Header.Set("X-Api-Token", "header-token")
+	resp, err := (&http.Client{Transport: recording}).Do(req)
+	if err != nil {
+		t.Fatalf("record request error = %v", err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cassette not written: %v", err)
+	}
+	if strings.Contains(string(data), "header-token") {
+		t.Errorf("cassette contains header value: %s", data)
+	}
+
+	// ヘッダーの値でレスポンスのヘッダーやボディは書き換えない
+	replaying, err := New(ModeReplay, path, nil)
+	if err != nil {
+		t.Fatalf("New(replay) error = %v", err)
+	}
+	resp, err = (&http.Client{Transport: replaying}).Get(server.URL + "/feed")
+	if err != nil {
+		t.Fatalf("replay request error = %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != page || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("replayed response = %q (Content-Type %q), want %q", got, resp.Header.Get("Content-Type"), page)
+	}
+}
+
+func TestNewInvalidMode(t *testing.T) {
+	if _, err := New("rewind", "", nil); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+	if _, err := New(ModeReplay, filepath.Join(t.TempDir(), "missing.json"), nil); err == nil {
+		t.Error("expected error for missing cassette")
+	}
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/internal/recorder"
 	"rss-en-to-jp-notification/service"
 )
 
@@ -14,18 +20,60 @@ type App struct {
 	config              *config.Config
 	feedService         *service.FeedService
 	translatorService   *service.TranslatorService
-	notificationService *service.NotificationService
+	notificationService *service.NotificationService // 既定の通知先（接続テスト用）
+	opsService          *service.NotificationService // 運用通知（エラー通知・健全性アラート・実行サマリー）の送信先
+	destinations        []*destination
+	digestStore         *service.DigestStore
+	holdStore           *service.HoldStore      // 翻訳・要約に失敗したため保留した記事
+	outbox              *service.Outbox         // 送信前のメッセージと、送信に失敗したメッセージ
+	publishedStore      *service.PublishedStore // 翻訳したフィードに出力する記事
+	archive             *service.Archive        // 翻訳・要約した記事のアーカイブ（無効の場合はnil）
+	healthStore         *service.HealthStore    // フィードの健全性（管理APIでの一覧表示用）
+	metrics             *service.Metrics        // Prometheusのメトリクス
+	runMu               sync.Mutex              // デーモンモードでRSSチェックと管理APIの状態を変更する操作（デッドレターの再送）を直列化する
+	trigger             chan struct{}           // 管理APIから要求された即時実行（デーモンモードで処理する）
+	interval            time.Duration           // API制限を考慮した記事ごとの処理間隔
+}
+
+// destination は通知先の設定と通知サービスの組
+type destination struct {
+	config.Destination
+	notificationService *service.NotificationService // メールの通知先ではnil
+	email               *service.EmailNotifier       // メール以外の通知先ではnil
+	sender              outboxSender                 // 送信箱のメッセージの送信方法
+}
+
+// outboxSender は送信箱のメッセージを送信する（NotificationServiceまたはEmailNotifier）
+type outboxSender interface {
+	Deliver(entry *service.OutboxEntry) error
 }
 
 func main() {
+	// サブコマンドが指定された場合はそちらを実行する
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatalf("%sの実行に失敗しました: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	log.Println("RSS通知システムを開始します...")
 
 	// 設定を読み込み
 	cfg := config.LoadConfig()
-	log.Printf("設定読み込み完了: フィードURL数=%d, 最大記事数/フィード=%d", len(cfg.FeedURLs), cfg.MaxArticlesPerFeed)
+	log.Printf("設定読み込み完了: フィード数=%d, 最大記事数/フィード=%d", len(cfg.Feeds), cfg.MaxArticlesPerFeed)
+
+	// トレースの送信を設定
+	shutdownTracing, err := setupTracing(cfg)
+	if err != nil {
+		log.Fatalf("トレースの設定に失敗しました: %v", err)
+	}
 
 	// アプリケーションを初期化
-	app := NewApp(cfg)
+	app, err := NewApp(cfg)
+	if err != nil {
+		log.Fatalf("アプリケーションの初期化に失敗しました: %v", err)
+	}
 
 	// 各サービスの接続テスト
 	if err := app.TestConnections(); err != nil {
@@ -33,32 +81,340 @@ func main() {
 	}
 
 	// メイン処理を実行（一回だけ）
-	app.RunOnce()
+	report := app.RunOnce()
+	if err := app.Close(); err != nil {
+		log.Printf("WARNING: アーカイブを閉じる際にエラーが発生しました: %v", err)
+	}
+
+	// 1回だけの実行ではスクレイプされる前に終了するため、メトリクスをPushgatewayに送信する
+	if cfg.PushgatewayURL != "" {
+		if err := app.metrics.Push(cfg.PushgatewayURL, cfg.PushgatewayJob); err != nil {
+			log.Printf("WARNING: メトリクスの送信に失敗しました: %v", err)
+		}
+	}
+	shutdownTracing()
 
 	log.Println("RSS通知システムを終了します...")
+
+	// 失敗があった場合はCIなどで検知できるよう終了コードを1にする
+	if report.Failed() {
+		log.Printf("%d件の失敗があったため、終了コード1で終了します", len(report.Failures))
+		os.Exit(1)
+	}
 }
 
-// NewApp は新しいAppインスタンスを作成する
-func NewApp(cfg *config.Config) *App {
+// NewApp は新しいAppインスタンスを作成する（optsは設定から決まるオプションの後に適用される）
+func NewApp(cfg *config.Config, opts ...service.Option) (*App, error) {
+	// HTTP通信の記録・再生が有効な場合は全サービスの通信を経由させる
+	serviceOpts, err := recorderOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	metrics := service.NewMetrics()
+	serviceOpts = append(append(serviceOpts, service.WithMetrics(metrics)), opts...)
+
 	// サービスを初期化
-	feedService := service.NewFeedService(cfg.FeedURLs, cfg.MaxArticlesPerFeed)
+	var feeds []service.FeedConfig
+	for _, feed := range cfg.Feeds {
+		filter, err := service.NewItemFilter(toFilterRule(feed.Include), toFilterRule(feed.Exclude))
+		if err != nil {
+			return nil, fmt.Errorf("フィード %s のフィルター設定が不正です: %w", feed.URL, err)
+		}
+		feeds = append(feeds, service.FeedConfig{
+			URL:     feed.URL,
+			Name:    feed.Name,
+			IconURL: feed.IconURL,
+			Footer:  feed.Footer,
+			Channel: feed.Channel,
+			Filter:  filter,
+			HTTP:    toFeedHTTPConfig(feed.HTTP),
+			Source:  toSource(feed),
+
+			SilentAfter: time.Duration(feed.SilentDays) * 24 * time.Hour,
+		})
+	}
+	feedState, err := service.NewFeedStateStore(filepath.Join(cfg.StateDir, "feed_state.json"))
+	if err != nil {
+		return nil, err
+	}
+	healthStore, err := service.NewHealthStore(filepath.Join(cfg.StateDir, "feed_health.json"))
+	if err != nil {
+		return nil, err
+	}
+	feedService := service.NewFeedService(
+		feeds,
+		cfg.MaxArticlesPerFeed,
+		append([]service.Option{
+			service.WithURLResolution(cfg.ResolveCanonicalURLs),
+			service.WithFeedState(feedState, service.CatchUpPolicy{
+				MaxCatchUp:       cfg.MaxCatchUp,
+				FirstRunBackfill: cfg.FirstRunBackfill,
+				Overflow:         cfg.OverflowPolicy,
+			}),
+			service.WithFeedHealth(healthStore, service.HealthPolicy{
+				FailureThreshold: cfg.FeedFailureThreshold,
+				SilentAfter:      time.Duration(cfg.FeedSilentDays) * 24 * time.Hour,
+				FlapThreshold:    cfg.FeedFlapThreshold,
+				DisableFor:       cfg.FeedDisableDuration,
+			}),
+		}, serviceOpts...)...,
+	)
 	translatorService := service.NewTranslatorService(
 		cfg.DeepLAPIKey,
 		cfg.DeepLAPIURL,
 		cfg.OpenAIAPIKey,
 		cfg.OpenAIModel,
-	)
-	notificationService := service.NewNotificationService(
-		cfg.SlackWebhookURL,
-		cfg.SlackChannel,
+		append([]service.Option{
+			service.WithOpenAIBaseURL(cfg.OpenAIBaseURL),
+			service.WithTranslationFallback(cfg.TranslationFallback),
+		}, serviceOpts...)...,
 	)
 
+	// 通知先ごとに通知サービスを初期化
+	var destinations []*destination
+	for _, dest := range cfg.Destinations {
+		// メールはダイジェストのみ送信する
+		if dest.Type == config.DestinationEmail {
+			emailNotifier, err := service.NewEmailNotifier(emailSettings(dest.Email), dest.TemplateDir, serviceOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("通知先 %s の初期化に失敗しました: %w", dest.Name, err)
+			}
+			destinations = append(destinations, &destination{Destination: dest, email: emailNotifier, sender: emailNotifier})
+			continue
+		}
+
+		notificationService, err := service.NewNotificationService(dest.WebhookURL, dest.Channel, dest.Format, dest.TemplateDir,
+			append([]service.Option{service.WithNotifierType(dest.Type)}, serviceOpts...)...)
+		if err != nil {
+			return nil, fmt.Errorf("通知先 %s の初期化に失敗しました: %w", dest.Name, err)
+		}
+		destinations = append(destinations, &destination{
+			Destination:         dest,
+			notificationService: notificationService,
+			sender:              notificationService,
+		})
+	}
+
+	// 運用通知の送信先を指定していない場合は既定の通知先に送る
+	opsService := destinations[0].notificationService
+	if cfg.OpsWebhookURL != "" || cfg.OpsChannel != "" {
+		def := cfg.Destinations[0]
+		webhookURL, channel := cfg.OpsWebhookURL, cfg.OpsChannel
+		if webhookURL == "" {
+			webhookURL = def.WebhookURL
+		}
+		if channel == "" {
+			channel = def.Channel
+		}
+		opsService, err = service.NewNotificationService(webhookURL, channel, def.Format, def.TemplateDir,
+			append([]service.Option{service.WithNotifierType(def.Type)}, serviceOpts...)...)
+		if err != nil {
+			return nil, fmt.Errorf("運用通知の送信先の初期化に失敗しました: %w", err)
+		}
+	}
+
+	digestStore, err := service.NewDigestStore(filepath.Join(cfg.StateDir, "digest_state.json"))
+	if err != nil {
+		return nil, err
+	}
+	holdStore, err := service.NewHoldStore(filepath.Join(cfg.StateDir, "held_items.json"))
+	if err != nil {
+		return nil, err
+	}
+	outbox, err := service.NewOutbox(filepath.Join(cfg.StateDir, "outbox.json"), service.OutboxPolicy{
+		MaxAttempts: cfg.OutboxMaxAttempts,
+		Backoff:     cfg.OutboxBackoff,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	publishedStore, err := service.NewPublishedStore(filepath.Join(cfg.StateDir, "published_items.json"), cfg.FeedOutputMaxItems)
+	if err != nil {
+		return nil, err
+	}
+
+	var archive *service.Archive
+	if cfg.ArchiveEnabled {
+		archive, err = service.OpenArchive(filepath.Join(cfg.StateDir, "archive.db"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &App{
 		config:              cfg,
 		feedService:         feedService,
 		translatorService:   translatorService,
-		notificationService: notificationService,
+		notificationService: destinations[0].notificationService,
+		opsService:          opsService,
+		destinations:        destinations,
+		digestStore:         digestStore,
+		holdStore:           holdStore,
+		outbox:              outbox,
+		publishedStore:      publishedStore,
+		archive:             archive,
+		healthStore:         healthStore,
+		metrics:             metrics,
+		trigger:             make(chan struct{}, 1),
+		interval:            2 * time.Second,
+	}, nil
+}
+
+// toFilterRule は設定ファイルのフィルター条件をサービスの型に変換する
+func toFilterRule(rule *config.FilterRule) *service.FilterRule {
+	if rule == nil {
+		return nil
+	}
+
+	converted := &service.FilterRule{
+		Not:      toFilterRule(rule.Not),
+		Field:    rule.Field,
+		Contains: rule.Contains,
+		Regex:    rule.Regex,
+	}
+	for i := range rule.All {
+		converted.All = append(converted.All, *toFilterRule(&rule.All[i]))
 	}
+	for i := range rule.Any {
+		converted.Any = append(converted.Any, *toFilterRule(&rule.Any[i]))
+	}
+	return converted
+}
+
+// toFeedHTTPConfig は設定ファイルのフィードのHTTP設定をサービスの型に変換する
+func toFeedHTTPConfig(h *config.FeedHTTP) *service.FeedHTTPConfig {
+	if h == nil {
+		return nil
+	}
+
+	converted := &service.FeedHTTPConfig{
+		Headers:     h.Headers,
+		UserAgent:   h.UserAgent,
+		BearerToken: h.BearerToken,
+		Cookies:     h.Cookies,
+		ProxyURL:    h.Proxy,
+		CAFile:      h.CAFile,
+	}
+	if h.BasicAuth != nil {
+		converted.Username = h.BasicAuth.Username
+		converted.Password = h.BasicAuth.Password
+	}
+	return converted
+}
+
+// toSource はフィードの種類に応じた記事の取得元を返す（RSSの場合はnil）
+func toSource(feed config.Feed) service.Source {
+	switch feed.Type {
+	case config.SourceGitHubReleases:
+		return &service.GitHubReleasesSource{IncludePrereleases: feed.IncludePrereleases}
+	case config.SourceHackerNews:
+		return &service.HackerNewsSource{MinPoints: feed.MinScore}
+	case config.SourceReddit:
+		return &service.RedditSource{MinScore: feed.MinScore}
+	case config.SourceScrape:
+		selectors := service.ScrapeSelectors{}
+		if s := feed.Selectors; s != nil {
+			selectors = service.ScrapeSelectors{
+				Item:        s.Item,
+				Title:       s.Title,
+				Link:        s.Link,
+				Description: s.Description,
+				Date:        s.Date,
+				Image:       s.Image,
+				Author:      s.Author,
+			}
+		}
+		return &service.ScrapeSource{Selectors: selectors, DateLayout: feed.DateLayout}
+	default:
+		return nil
+	}
+}
+
+// emailSettings はメール通知先の設定をEmailNotifierの設定に変換する
+func emailSettings(email *config.Email) service.EmailSettings {
+	settings := service.EmailSettings{
+		Host:     email.SMTPHost,
+		Port:     email.SMTPPort,
+		Username: email.Username,
+		Password: email.Password,
+		From:     email.From,
+	}
+	for _, recipient := range email.Recipients {
+		settings.Recipients = append(settings.Recipients, service.EmailRecipient{Address: recipient.Address, Language: recipient.Language})
+	}
+	return settings
+}
+
+// recorderOptions はHTTP_RECORD_MODEに応じて通信を記録・再生するオプションを返す
+func recorderOptions(cfg *config.Config) ([]service.Option, error) {
+	mode := recorder.Mode(cfg.HTTPRecordMode)
+	if mode == "" || mode == recorder.ModeOff {
+		return nil, nil
+	}
+
+	transport, err := recorder.New(mode, cfg.HTTPCassetteFile, nil, recorderSecrets(cfg)...)
+	if err != nil {
+		return nil, err
+	}
+	transport.RedactRequestHeaders(recorderHeaders(cfg)...)
+	log.Printf("HTTP通信を%sモードで実行します: %s", mode, cfg.HTTPCassetteFile)
+
+	return []service.Option{
+		service.WithHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second}),
+		service.WithClock(transport.Now),
+	}, nil
+}
+
+// recorderSecrets はカセットに残さない秘匿情報（APIキー、Webhook URL、フィードの認証情報など）を返す
+// （URL・ヘッダー・ボディのどこに含まれていても置き換えるため、一般的な値になりうるものは含めない）
+func recorderSecrets(cfg *config.Config) []string {
+	secrets := []string{cfg.DeepLAPIKey, cfg.OpenAIAPIKey, cfg.SlackWebhookURL, cfg.OpsWebhookURL}
+	for _, dest := range cfg.Destinations {
+		secrets = append(secrets, dest.WebhookURL)
+		if dest.Email != nil {
+			secrets = append(secrets, dest.Email.Password)
+		}
+	}
+	for _, feed := range cfg.Feeds {
+		if feed.HTTP == nil {
+			continue
+		}
+		secrets = append(secrets, feed.HTTP.BearerToken)
+		if feed.HTTP.BasicAuth != nil {
+			secrets = append(secrets, feed.HTTP.BasicAuth.Password)
+		}
+		for _, value := range feed.HTTP.Cookies {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+// recorderHeaders はカセットに値を残さないリクエストヘッダーを返す
+// （フィードのヘッダーとUser-Agentは ${NAME} で環境変数のトークンを含められるため、リクエストに送った値を記録しない）
+func recorderHeaders(cfg *config.Config) []string {
+	var headers []string
+	for _, feed := range cfg.Feeds {
+		if feed.HTTP == nil {
+			continue
+		}
+		if feed.HTTP.UserAgent != "" {
+			headers = append(headers, "User-Agent")
+		}
+		for name := range feed.HTTP.Headers {
+			headers = append(headers, name)
+		}
+	}
+	return headers
+}
+
+// Close はアプリケーションが開いているアーカイブを閉じる
+func (app *App) Close() error {
+	if app.archive == nil {
+		return nil
+	}
+	return app.archive.Close()
 }
 
 // TestConnections は各外部サービスの接続をテストする
@@ -90,123 +446,486 @@ func (app *App) TestConnections() error {
 	return nil
 }
 
-// RunOnce は一度だけRSSチェックと処理を実行する
-func (app *App) RunOnce() {
-	log.Println("過去24時間以内の新しい記事をチェックしています...")
+// RunOnce は一度だけRSSチェックと処理を実行し、実行結果のレポートを返す
+func (app *App) RunOnce() *service.RunReport {
+	log.Println("前回実行以降の新しい記事をチェックしています...")
 
-	// 過去24時間以内の新しい記事をチェック
+	// テンプレートに渡す実行情報を設定
+	report := &service.RunReport{StartedAt: time.Now()}
+	for _, dest := range app.destinations {
+		if dest.notificationService == nil {
+			continue
+		}
+		dest.notificationService.SetRunInfo(service.RunInfo{
+			StartedAt:   report.StartedAt,
+			Destination: dest.Name,
+			Mode:        dest.Mode,
+		})
+	}
+	app.opsService.SetRunInfo(service.RunInfo{StartedAt: report.StartedAt, Destination: "ops"})
+	defer app.finishRun(report)
+
+	// 前回実行以降の新しい記事をチェック
 	recentItems, err := app.feedService.CheckForRecentItems()
+	stats := app.feedService.Stats()
+	report.FeedsChecked = stats.Checked
+	report.FeedsFailed = stats.Failed
+	report.Fetched = stats.Items
+
+	// フィードの健全性アラート（取得失敗の継続・記事の途絶など）を送信する
+	app.sendHealthAlerts(report)
+
 	if err != nil {
 		errMsg := "RSSフィードのチェックに失敗しました: " + err.Error()
 		log.Printf("ERROR: %s", errMsg)
-		
-		// エラー通知を送信
-		if notifyErr := app.notificationService.SendErrorNotification(errMsg); notifyErr != nil {
-			log.Printf("WARNING: エラー通知の送信に失敗: %v", notifyErr)
+
+		// 一部のフィードのみの失敗は健全性アラートで知らせ、取得できた記事の処理を続ける
+		var fetchErr *service.FetchError
+		if !errors.As(err, &fetchErr) {
+			report.AddFailure(service.StageFetch, "", err)
+		} else {
+			for _, failure := range fetchErr.Failures {
+				report.AddFailure(service.StageFetch, failure.Feed.URL, failure.Err)
+			}
+		}
+		if fetchErr == nil || fetchErr.AllFailed() {
+			// エラー通知を送信
+			if notifyErr := app.opsService.SendErrorNotification(errMsg); notifyErr != nil {
+				log.Printf("WARNING: エラー通知の送信に失敗: %v", notifyErr)
+			}
+			return report
 		}
-		return
 	}
 
+	report.New = len(recentItems)
 	if len(recentItems) == 0 {
-		log.Println("過去24時間以内の新しい記事はありませんでした")
-		return
+		log.Println("新しい記事はありませんでした")
+	} else {
+		log.Printf("%d件の新しい記事が見つかりました", len(recentItems))
 	}
 
-	log.Printf("%d件の新しい記事が見つかりました", len(recentItems))
+	// 関心プロファイルとの関連度が低い記事を除外（翻訳・要約の前に判定する）
+	recentItems = app.filterByRelevance(recentItems, report)
+
+	// 前回までに保留した記事を先に再試行する（関連度判定は保留した時点で済んでいる）
+	held := make(map[*service.FeedItem]service.HeldItem)
+	if heldItems := app.holdStore.Items(); len(heldItems) > 0 {
+		log.Printf("保留中の%d件の記事を再試行します", len(heldItems))
+		retryItems := make([]*service.FeedItem, 0, len(heldItems)+len(recentItems))
+		for _, h := range heldItems {
+			held[h.Item] = *h
+			retryItems = append(retryItems, h.Item)
+		}
+		recentItems = append(retryItems, recentItems...)
+	}
 
 	// 各記事を処理
 	var results []*service.TranslationResult
+	var nextHeld []*service.HeldItem
 	for i, item := range recentItems {
 		log.Printf("記事 %d/%d を処理中: %s", i+1, len(recentItems), item.Title)
 
 		// 翻訳と要約を実行
-		result, err := app.translatorService.TranslateAndSummarize(item)
-		if err != nil {
-			errMsg := fmt.Sprintf("記事の翻訳・要約に失敗しました: %s - エラー: %v", item.Title, err)
-			log.Printf("ERROR: %s", errMsg)
-			continue
+		result := app.translatorService.TranslateAndSummarize(item)
+
+		// 翻訳・要約に失敗した場合は失敗として記録する（代替プロバイダーで翻訳できた場合は成功とする）
+		if result.TranslationFailed {
+			report.AddFailure(service.StageTranslate, item.Title, errors.New(result.TranslationError))
+		} else {
+			report.Translated++
+		}
+		if result.SummaryFailed {
+			report.AddFailure(service.StageSummarize, item.Title, errors.New(result.SummaryError))
+		} else {
+			report.Summarized++
 		}
 
-		results = append(results, result)
-		log.Printf("SUCCESS: 記事の処理完了: %s", result.TranslatedTitle)
+		// 設定に応じて、失敗した記事は通知せずに保留し次回の実行で再試行する
+		if h, ok := app.holdDegraded(item, result, held); ok {
+			log.Printf("WARNING: 翻訳・要約に失敗したため記事を保留します（%d回目）: %s", h.Attempts, item.Title)
+			nextHeld = append(nextHeld, h)
+			report.Held++
+		} else {
+			results = append(results, result)
+			log.Printf("SUCCESS: 記事の処理完了: %s", result.TranslatedTitle)
+		}
 		
 		// API制限を考慮して記事間に間隔を設ける
 		if i < len(recentItems)-1 {
-			time.Sleep(2 * time.Second)
+			time.Sleep(app.interval)
 		}
 	}
 
-	// 通知を送信
+	// アーカイブに保存し、翻訳したフィードに追加する（通知の成否にかかわらず、同じ記事は重複して追加しない）
+	app.archiveResults(results, report)
+	app.publishResults(results, report)
+
+	// 通知を送信（新着がなくてもダイジェストの配信期限はチェックする）
+	if !app.sendNotifications(results, report) {
+		// ウォーターマークを進めず、次回実行時に同じ記事を再度処理する
+		log.Println("WARNING: 通知の送信に失敗したため、処理済み位置を更新しません")
+		return report
+	}
+
+	if err := app.feedService.CommitState(); err != nil {
+		log.Printf("ERROR: フィードの処理済み位置の保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "feed_state.json", err)
+	}
+	app.holdStore.Replace(nextHeld)
+	if err := app.holdStore.Save(); err != nil {
+		log.Printf("ERROR: 保留した記事の保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "held_items.json", err)
+	}
+	return report
+}
+
+// holdDegraded は翻訳・要約に失敗した記事を保留するかを判定する
+// （DEGRADED_POLICY=holdの場合にDEGRADED_MAX_HOLDS回まで保留し、それ以降は失敗したことを表示して通知する）
+func (app *App) holdDegraded(item *service.FeedItem, result *service.TranslationResult, held map[*service.FeedItem]service.HeldItem) (*service.HeldItem, bool) {
+	if !result.Degraded() || app.config.DegradedPolicy != config.DegradedHold {
+		return nil, false
+	}
+
+	// 保留中の記事は複製して更新する（通知に失敗した場合は保留の状態を変えない）
+	h, ok := held[item]
+	if !ok {
+		h = service.HeldItem{Item: item, HeldAt: time.Now()}
+	}
+	h.Attempts++
+	h.LastError = result.TranslationError
+	if h.LastError == "" {
+		h.LastError = result.SummaryError
+	}
+	if h.Attempts > app.config.DegradedMaxHolds {
+		log.Printf("WARNING: 保留の上限（%d回）に達したため、失敗したまま通知します: %s", app.config.DegradedMaxHolds, item.Title)
+		return nil, false
+	}
+	return &h, true
+}
+
+// archiveResults は翻訳・要約した記事をアーカイブに保存する
+func (app *App) archiveResults(results []*service.TranslationResult, report *service.RunReport) {
+	if app.archive == nil {
+		return
+	}
+	if err := app.archive.Add(results, time.Now()); err != nil {
+		log.Printf("ERROR: 記事のアーカイブへの保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "archive.db", err)
+	}
+}
+
+// publishResults は翻訳した記事を翻訳フィードに追加し、FEED_OUTPUT_FILEが指定されていればフィードを書き出す
+func (app *App) publishResults(results []*service.TranslationResult, report *service.RunReport) {
 	if len(results) > 0 {
-		app.sendNotifications(results)
-	}
-}
-
-// sendNotifications は処理結果に基づいて通知を送信する
-func (app *App) sendNotifications(results []*service.TranslationResult) {
-	log.Printf("%d件の記事通知を送信します", len(results))
-
-	if len(results) == 1 {
-		// 単一記事の通知（設定によってスレッド形式or通常形式を選択）
-		if app.config.SlackUseThreads {
-			if err := app.notificationService.SendNewArticleNotificationWithThread(results[0]); err != nil {
-				errMsg := fmt.Sprintf("Slackスレッド通知の送信に失敗しました: %v", err)
-				log.Printf("ERROR: %s", errMsg)
-				
-				// フォールバック: 通常の通知を試行
-				log.Println("通常の通知形式にフォールバックします...")
-				if err := app.notificationService.SendNewArticleNotification(results[0]); err != nil {
-					log.Printf("ERROR: フォールバック通知も失敗しました: %v", err)
-				} else {
-					log.Println("SUCCESS: フォールバック通知を送信しました")
-				}
-			} else {
-				log.Println("SUCCESS: スレッド形式の記事通知を送信しました")
-			}
-		} else {
-			if err := app.notificationService.SendNewArticleNotification(results[0]); err != nil {
-				errMsg := fmt.Sprintf("Slack通知の送信に失敗しました: %v", err)
-				log.Printf("ERROR: %s", errMsg)
-			} else {
-				log.Println("SUCCESS: 記事通知を送信しました")
-			}
+		app.publishedStore.Add(results, time.Now())
+		if err := app.publishedStore.Save(); err != nil {
+			log.Printf("ERROR: 翻訳フィードの記事の保存に失敗しました: %v", err)
+			report.AddFailure(service.StageState, "published_items.json", err)
 		}
-	} else {
-		// 複数記事の通知
-		if app.config.SlackUseThreads {
-			log.Println("個別スレッド通知を送信します...")
-			for i, result := range results {
-				if err := app.notificationService.SendNewArticleNotificationWithThread(result); err != nil {
-					log.Printf("ERROR: 記事 %d/%d のスレッド通知送信に失敗: %v", i+1, len(results), err)
-					// フォールバック: 通常の通知
-					if err := app.notificationService.SendNewArticleNotification(result); err != nil {
-						log.Printf("ERROR: 記事 %d/%d の通常通知も失敗: %v", i+1, len(results), err)
-					} else {
-						log.Printf("SUCCESS: 記事 %d/%d の通常通知を送信しました", i+1, len(results))
-					}
-				} else {
-					log.Printf("SUCCESS: 記事 %d/%d のスレッド通知を送信しました", i+1, len(results))
-				}
-				
-				// レート制限を避けるため少し待機
-				if i < len(results)-1 {
-					time.Sleep(2 * time.Second)
-				}
+	}
+
+	if app.config.FeedOutputFile == "" {
+		return
+	}
+	meta := app.feedMeta(app.config.FeedOutputURL)
+	if err := service.WriteFeedFile(app.config.FeedOutputFile, app.config.FeedOutputFormat, meta, app.publishedStore.Items(), time.Now()); err != nil {
+		log.Printf("ERROR: 翻訳フィードの書き出しに失敗しました: %v", err)
+		report.AddFailure(service.StagePublish, app.config.FeedOutputFile, err)
+	}
+}
+
+// feedMeta は翻訳フィード自体の情報を返す（selfURLはフィードを公開するURL）
+func (app *App) feedMeta(selfURL string) service.FeedMeta {
+	return service.FeedMeta{
+		Title:       app.config.FeedOutputTitle,
+		Description: "英語の記事を日本語に翻訳・要約したフィード",
+		SelfURL:     selfURL,
+	}
+}
+
+// finishRun は実行結果をログと状態ディレクトリのrun_report.jsonに記録し、設定に応じて実行サマリーを送信する
+func (app *App) finishRun(report *service.RunReport) {
+	report.FinishedAt = time.Now()
+	app.metrics.ObserveRun(report)
+	log.Printf("実行結果: フィード%d件（取得失敗%d件）, 新着%d件, 除外%d件, 翻訳%d件, 要約%d件, 通知%d件, 保留%d件, 再送待ち%d件, 失敗%d件",
+		report.FeedsChecked, report.FeedsFailed, report.New, report.Skipped, report.Translated, report.Summarized, report.Posted, report.Held, report.Queued, len(report.Failures))
+	for _, failure := range report.Failures {
+		log.Printf("  失敗 [%s] %s: %s", failure.Stage, failure.Target, failure.Reason)
+	}
+
+	if err := report.Save(filepath.Join(app.config.StateDir, "run_report.json")); err != nil {
+		log.Printf("WARNING: 実行結果の保存に失敗しました: %v", err)
+	}
+
+	switch {
+	case app.config.RunSummary == config.RunSummaryAlways,
+		app.config.RunSummary == config.RunSummaryOnFailure && report.Failed():
+		if err := app.opsService.SendRunReport(report); err != nil {
+			log.Printf("WARNING: 実行サマリーの送信に失敗しました: %v", err)
+		}
+	}
+}
+
+// sendHealthAlerts はフィードの健全性アラートを運用通知の送信先に送信し、健全性を保存する
+// （送信に失敗したアラートは次回の実行で再送する）
+func (app *App) sendHealthAlerts(report *service.RunReport) {
+	for _, alert := range app.feedService.HealthAlerts() {
+		if err := app.opsService.SendHealthAlert(alert); err != nil {
+			log.Printf("WARNING: フィードの健全性アラートの送信に失敗: %v", err)
+			report.AddFailure(service.StageNotify, "ops", err)
+			continue
+		}
+		app.feedService.MarkHealthAlertSent(alert)
+	}
+
+	if err := app.feedService.SaveHealth(); err != nil {
+		log.Printf("ERROR: フィードの健全性の保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "feed_health.json", err)
+	}
+}
+
+// filterByRelevance は関連度判定が有効な場合に、しきい値未満の記事を除外する
+// （判定に失敗した記事は取りこぼさないよう通知対象に残す）
+func (app *App) filterByRelevance(items []*service.FeedItem, report *service.RunReport) []*service.FeedItem {
+	if app.config.RelevanceProfile == "" || len(items) == 0 {
+		return items
+	}
+
+	log.Printf("%d件の記事の関連度を判定しています（しきい値: %d）", len(items), app.config.RelevanceThreshold)
+
+	var relevant []*service.FeedItem
+	for _, item := range items {
+		relevance, err := app.translatorService.ScoreRelevance(item, app.config.RelevanceProfile)
+		if err != nil {
+			log.Printf("WARNING: 関連度の判定に失敗したため通知対象に含めます: %s - %v", item.Title, err)
+			report.AddFailure(service.StageRelevance, item.Title, err)
+			relevant = append(relevant, item)
+			continue
+		}
+
+		item.Relevance = relevance
+		if relevance.Score < app.config.RelevanceThreshold {
+			log.Printf("関連度が低いため除外しました: %s（%d: %s）", item.Title, relevance.Score, relevance.Reason)
+			report.Skipped++
+			continue
+		}
+		relevant = append(relevant, item)
+	}
+
+	log.Printf("関連度判定の結果、%d/%d件の記事が対象になりました", len(relevant), len(items))
+	return relevant
+}
+
+// sendNotifications は通知先ごとのモードに従って通知を送信する
+// （記事の通知を送信も送信箱への保存もできなかった場合はfalseを返す）
+func (app *App) sendNotifications(results []*service.TranslationResult, report *service.RunReport) bool {
+	// 前回までに送信できなかったメッセージを先に再送する
+	app.retryOutbox(report)
+
+	ok := true
+	for _, dest := range app.destinations {
+		switch dest.Mode {
+		case config.ModeDigest:
+			app.sendDigestNotification(dest, results, report)
+		default:
+			if !app.sendArticleNotifications(dest, results, report) {
+				ok = false
 			}
+			app.sendOverflowNotifications(dest, report)
+		}
+	}
+
+	// 送信結果を送信箱に反映する（送信に失敗したメッセージは次回以降に再送する）
+	report.Queued = len(app.outbox.Pending())
+	if err := app.outbox.Save(); err != nil {
+		log.Printf("ERROR: 送信箱の保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "outbox.json", err)
+	}
+
+	// ダイジェストに蓄積した記事は送信済みとして扱う（配信に失敗しても状態ファイルから再送する）
+	if err := app.digestStore.Save(); err != nil {
+		log.Printf("ERROR: ダイジェストの状態保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "digest_state.json", err)
+		ok = false
+	}
+	return ok
+}
+
+// sendArticleNotifications は記事ごとに通知を送信する（スレッド形式or通常形式）
+// 送信前にメッセージを送信箱に保存するため、送信に失敗しても次回以降の実行で再送できる
+// （メッセージの作成や送信箱への保存に失敗し、送信もできなかった記事があればfalseを返す）
+func (app *App) sendArticleNotifications(dest *destination, results []*service.TranslationResult, report *service.RunReport) bool {
+	if len(results) == 0 {
+		return true
+	}
+
+	log.Printf("通知先 %s に%d件の記事通知を送信します（モード: %s）", dest.Name, len(results), dest.Mode)
+
+	ok := true
+	for i, result := range results {
+		entry, err := dest.notificationService.ArticleEntry(result, dest.Mode == config.ModeThread)
+		if err != nil {
+			log.Printf("ERROR: 記事 %d/%d の通知の作成に失敗: %v", i+1, len(results), err)
+			report.AddFailure(service.StageNotify, dest.Name+": "+result.TranslatedTitle, err)
+			ok = false
+			continue
+		}
+
+		saved := app.enqueue(dest, entry, report)
+		if app.deliver(dest, entry, report) {
+			log.Printf("SUCCESS: 記事 %d/%d の通知を送信しました", i+1, len(results))
+		} else if !saved {
+			ok = false
+		}
+
+		// レート制限を避けるため少し待機
+		if i < len(results)-1 {
+			time.Sleep(app.interval)
+		}
+	}
+	return ok
+}
+
+// sendOverflowNotifications は上限を超えたため処理しなかった記事の一覧を通知する
+// （記事本体ではないため、失敗しても処理済み位置の更新は止めない）
+func (app *App) sendOverflowNotifications(dest *destination, report *service.RunReport) {
+	for _, overflow := range app.feedService.Overflows() {
+		entry, err := dest.notificationService.OverflowEntry(overflow)
+		if err != nil {
+			log.Printf("ERROR: 通知先 %s への超過通知の作成に失敗しました（%s）: %v", dest.Name, overflow.Feed.Name, err)
+			report.AddFailure(service.StageNotify, dest.Name+": "+overflow.Feed.Name, err)
+			continue
+		}
+
+		app.enqueue(dest, entry, report)
+		if app.deliver(dest, entry, report) {
+			log.Printf("SUCCESS: 通知先 %s に超過通知を送信しました（%s: %d件）", dest.Name, overflow.Feed.Name, len(overflow.Items))
+		}
+	}
+}
+
+// enqueue は送信前のメッセージを送信箱に保存する（保存できた場合はtrue）
+func (app *App) enqueue(dest *destination, entry *service.OutboxEntry, report *service.RunReport) bool {
+	entry.Destination = dest.Name
+	app.outbox.Add(entry, time.Now())
+	if err := app.outbox.Save(); err != nil {
+		log.Printf("ERROR: 送信箱の保存に失敗しました: %v", err)
+		report.AddFailure(service.StageState, "outbox.json", err)
+		return false
+	}
+	return true
+}
+
+// deliver は送信箱のメッセージを送信し、結果を送信箱に反映する（送信できた場合はtrue）
+func (app *App) deliver(dest *destination, entry *service.OutboxEntry, report *service.RunReport) bool {
+	if err := dest.sender.Deliver(entry); err != nil {
+		log.Printf("ERROR: 通知先 %s への送信に失敗しました（%s）: %v", dest.Name, entry.Title, err)
+		report.AddFailure(service.StageNotify, dest.Name+": "+entry.Title, err)
+		if app.outbox.Failed(entry, err, time.Now()) {
+			log.Printf("ERROR: 送信に%d回失敗したため、デッドレターに移しました（ID: %d）: %s", entry.Attempts, entry.ID, entry.Title)
 		} else {
-			log.Println("個別通知を送信します...")
-			for i, result := range results {
-				if err := app.notificationService.SendNewArticleNotification(result); err != nil {
-					log.Printf("ERROR: 記事 %d/%d の通知送信に失敗: %v", i+1, len(results), err)
-				} else {
-					log.Printf("SUCCESS: 記事 %d/%d の通知を送信しました", i+1, len(results))
-				}
-				
-				// レート制限を避けるため少し待機
-				if i < len(results)-1 {
-					time.Sleep(1 * time.Second)
-				}
-			}
+			log.Printf("WARNING: %sに再送します（ID: %d）: %s", entry.NextAttempt.Format("2006-01-02 15:04:05"), entry.ID, entry.Title)
+		}
+		return false
+	}
+
+	app.outbox.Delivered(entry)
+	switch entry.Kind {
+	case service.OutboxArticle:
+		report.Posted++
+		app.metrics.AddPosted(dest.Name, 1)
+	case service.OutboxDigest:
+		// 再送したダイジェストも送信できた時点で記事数を数える
+		report.Posted += entry.Articles
+		app.metrics.AddPosted(dest.Name, entry.Articles)
+	}
+	return true
+}
+
+// retryOutbox は送信箱のメッセージのうち再送時刻を過ぎたものを再送する
+func (app *App) retryOutbox(report *service.RunReport) {
+	due := app.outbox.Due(time.Now())
+	if len(due) == 0 {
+		return
+	}
+
+	log.Printf("送信箱の%d件のメッセージを再送します", len(due))
+	for _, entry := range due {
+		dest := app.destination(entry.Destination)
+		if dest == nil {
+			err := fmt.Errorf("通知先 %s が設定されていません", entry.Destination)
+			log.Printf("ERROR: %v（ID: %d）", err, entry.ID)
+			report.AddFailure(service.StageNotify, entry.Destination+": "+entry.Title, err)
+			app.outbox.DeadLetter(entry, err)
+			continue
+		}
+		if app.deliver(dest, entry, report) {
+			log.Printf("SUCCESS: 通知先 %s に再送しました（ID: %d）: %s", dest.Name, entry.ID, entry.Title)
 		}
 	}
-}
\ No newline at end of file
+}
+
+// destination は名前に一致する通知先を返す（見つからない場合はnil）
+func (app *App) destination(name string) *destination {
+	for _, dest := range app.destinations {
+		if dest.Name == name {
+			return dest
+		}
+	}
+	return nil
+}
+
+// sendDigestNotification は記事をダイジェストに蓄積し、集計期間が経過していればまとめて送信する
+func (app *App) sendDigestNotification(dest *destination, results []*service.TranslationResult, report *service.RunReport) {
+	now := time.Now()
+	app.digestStore.Add(dest.Name, results, now)
+
+	pending := app.digestStore.Pending(dest.Name)
+	if !app.digestStore.Due(dest.Name, dest.DigestWindow, now) {
+		log.Printf("通知先 %s: ダイジェストに%d件を蓄積中です（次回配信まで待機）", dest.Name, len(pending))
+		return
+	}
+
+	log.Printf("通知先 %s に%d件のダイジェストを送信します", dest.Name, len(pending))
+
+	// 送信前に送信箱に保存し、途中で失敗した場合は次回以降の実行で続きから再送する
+	// （メールは宛先ごとに保存するため、送信できなかった宛先にだけ再送する）
+	entries, err := app.digestEntries(dest, pending)
+	if err != nil {
+		log.Printf("ERROR: ダイジェスト通知の作成に失敗しました: %v", err)
+		report.AddFailure(service.StageNotify, dest.Name, err)
+		return
+	}
+	saved := true
+	for _, entry := range entries {
+		saved = app.enqueue(dest, entry, report) && saved
+	}
+	delivered := true
+	for _, entry := range entries {
+		delivered = app.deliver(dest, entry, report) && delivered
+	}
+	if delivered {
+		app.digestDelivered(dest)
+	} else if saved {
+		// 送信箱から再送するため、蓄積した記事は配信済みとして扱う
+		app.digestStore.Reset(dest.Name)
+	}
+}
+
+// digestEntries はダイジェストを送信箱のメッセージとして作成する（メールは宛先ごとに1件）
+func (app *App) digestEntries(dest *destination, results []*service.TranslationResult) ([]*service.OutboxEntry, error) {
+	if dest.email != nil {
+		return dest.email.DigestEntries(results, dest.DigestWindow)
+	}
+	entry, err := dest.notificationService.DigestEntry(results, dest.DigestWindow)
+	if err != nil {
+		return nil, err
+	}
+	return []*service.OutboxEntry{entry}, nil
+}
+
+// digestDelivered は送信したダイジェストの蓄積した記事をクリアする（記事数は送信時に数える）
+func (app *App) digestDelivered(dest *destination) {
+	app.digestStore.Reset(dest.Name)
+	log.Printf("SUCCESS: 通知先 %s にダイジェストを送信しました", dest.Name)
+}
@@ -0,0 +1,745 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/internal/fake"
+	"rss-en-to-jp-notification/internal/recorder"
+	"rss-en-to-jp-notification/service"
+)
+
+// update はtestdata以下のカセットをフェイクサーバーとの通信で記録し直す（go test -run Replay -update）
+var update = flag.Bool("update", false, "re-record HTTP cassettes in testdata")
+
+// testEnv はRunOnceの検証に使うフェイクサーバー一式
+type testEnv struct {
+	feed   *fake.FeedServer
+	deepL  string
+	openAI *fake.OpenAIServer
+	slack  *fake.SlackServer
+}
+
+func newTestEnv(t *testing.T, items ...fake.Item) *testEnv {
+	return &testEnv{
+		feed:   fake.NewFeedServer(t, "Example Blog", items...),
+		deepL:  fake.NewDeepLServer(t).URL,
+		openAI: fake.NewOpenAIServer(t, "テスト用の要約です。"),
+		slack:  fake.NewSlackServer(t),
+	}
+}
+
+// newTestApp はフェイクサーバーに接続するAppを作成する
+func newTestApp(t *testing.T, env *testEnv, destinations ...config.Destination) *App {
+	t.Helper()
+
+	if len(destinations) == 0 {
+		destinations = []config.Destination{
+			{Name: "default", WebhookURL: env.slack.URL, Channel: "#test", Mode: config.ModeThread, Format: config.FormatBlocks},
+		}
+	}
+
+	cfg := &config.Config{
+		FeedURLs:           []string{env.feed.URL},
+		Feeds:              []config.Feed{{URL: env.feed.URL}},
+		MaxArticlesPerFeed: 10,
+		MaxCatchUp:         24 * time.Hour,
+		FirstRunBackfill:   10,
+		DeepLAPIKey:        "deepl-key",
+		DeepLAPIURL:        env.deepL,
+		OpenAIAPIKey:       "openai-key",
+		OpenAIModel:        "gpt-3.5-turbo",
+		OpenAIBaseURL:      env.openAI.BaseURL(),
+		SlackWebhookURL:    env.slack.URL,
+		Destinations:       destinations,
+		StateDir:           t.TempDir(),
+		ArchiveEnabled:     true,
+	}
+
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	t.Cleanup(func() { app.Close() })
+	app.interval = 0
+	return app
+}
+
+func TestRunOnce(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Description: "A deep dive.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Old article", Link: "https://example.com/old", GUID: "2", Published: time.Now().Add(-72 * time.Hour)},
+	)
+	app := newTestApp(t, env)
+
+	if err := app.TestConnections(); err != nil {
+		t.Fatalf("TestConnections() error = %v", err)
+	}
+	app.RunOnce()
+
+	// 接続テスト + タイトル + スレッドの要約
+	messages := env.slack.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("got %d Slack messages, want 3: %v", len(messages), env.slack.Texts())
+	}
+
+	title := messages[1]["text"].(string)
+	if !strings.Contains(title, "Example Blog") || !strings.Contains(title, "[JA] Understanding Caches") {
+		t.Errorf("title message text = %q", title)
+	}
+	if messages[2]["thread_ts"] == nil || !strings.Contains(messages[2]["text"].(string), "テスト用の要約です。") {
+		t.Errorf("summary message = %v", messages[2])
+	}
+	if env.openAI.Requests() != 2 {
+		t.Errorf("OpenAI requests = %d, want 2 (connection test + summary)", env.openAI.Requests())
+	}
+}
+
+func TestRunOnceNoRecentItems(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Old article", Link: "https://example.com/old", GUID: "1", Published: time.Now().Add(-72 * time.Hour)},
+	)
+	app := newTestApp(t, env)
+
+	app.RunOnce()
+
+	if got := len(env.slack.Messages()); got != 0 {
+		t.Errorf("got %d Slack messages, want 0", got)
+	}
+}
+
+func TestRunOnceDigestDestination(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/1", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Second", Link: "https://example.com/2", GUID: "2", Published: time.Now().Add(-2 * time.Hour)},
+	)
+	digestSlack := fake.NewSlackServer(t)
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatAttachments},
+		config.Destination{Name: "weekly", WebhookURL: digestSlack.URL, Mode: config.ModeDigest, Format: config.FormatBlocks, DigestWindow: 7 * 24 * time.Hour},
+	)
+
+	app.RunOnce()
+
+	if got := len(env.slack.Messages()); got != 2 {
+		t.Errorf("article destination got %d messages, want 2", got)
+	}
+	// 集計期間が経過するまでダイジェストは送信されない
+	if got := len(digestSlack.Messages()); got != 0 {
+		t.Errorf("digest destination got %d messages before the window elapsed", got)
+	}
+	if got := len(app.digestStore.Pending("weekly")); got != 2 {
+		t.Errorf("pending digest results = %d, want 2", got)
+	}
+}
+
+func TestRunOnceDigestOutbox(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/1", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	digestSlack := fake.NewSlackServer(t)
+	app := newTestApp(t, env,
+		config.Destination{Name: "daily", WebhookURL: digestSlack.URL, Mode: config.ModeDigest, Format: config.FormatBlocks, DigestWindow: 24 * time.Hour},
+	)
+	startDigestWindow(t, app, "daily", time.Now().Add(-25*time.Hour))
+
+	// 送信に失敗したダイジェストは送信箱から再送する
+	digestSlack.SetFail(true)
+	report := app.RunOnce()
+	pending := app.outbox.Pending()
+	if len(pending) != 1 || pending[0].Kind != service.OutboxDigest || pending[0].Articles != 1 || len(app.digestStore.Pending("daily")) != 0 {
+		t.Fatalf("outbox = %+v, digest pending = %d; want the digest queued in the outbox", pending, len(app.digestStore.Pending("daily")))
+	}
+	if report.Posted != 0 {
+		t.Errorf("report.Posted = %d, want 0 before the digest is sent", report.Posted)
+	}
+
+	// 再送できた時点でダイジェストの記事数を投稿数に数える
+	digestSlack.SetFail(false)
+	report = app.RunOnce()
+	if got := len(digestSlack.Messages()); got != 1 {
+		t.Errorf("digest destination got %d messages after retry, want 1", got)
+	}
+	if report.Posted != 1 {
+		t.Errorf("report.Posted = %d after retry, want the digest's article counted", report.Posted)
+	}
+	if pending := app.outbox.Pending(); len(pending) != 0 {
+		t.Errorf("outbox = %+v, want empty", pending)
+	}
+}
+
+// startDigestWindow は通知先のダイジェストの集計期間がstartから始まっている状態にする
+func startDigestWindow(t *testing.T, app *App, destination string, start time.Time) {
+	t.Helper()
+
+	path := filepath.Join(app.config.StateDir, "digest_state.json")
+	data := fmt.Sprintf(`{%q: {"window_start": %q, "results": []}}`, destination, start.Format(time.RFC3339Nano))
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := service.NewDigestStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.digestStore = store
+}
+
+func TestRunOnceNotifierTypes(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/1", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	teams := fake.NewWebhookServer(t, http.StatusAccepted, "")
+	discord := fake.NewWebhookServer(t, http.StatusNoContent, "")
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+		config.Destination{Name: "sre", Type: config.DestinationTeams, WebhookURL: teams.URL, Mode: config.ModeArticle},
+		config.Destination{Name: "community", Type: config.DestinationDiscord, WebhookURL: discord.URL, Mode: config.ModeThread},
+	)
+
+	report := app.RunOnce()
+
+	if got := len(env.slack.Messages()); got != 1 {
+		t.Errorf("Slack destination got %d messages, want 1", got)
+	}
+	if messages := teams.Messages(); len(messages) != 1 || messages[0]["type"] != "message" {
+		t.Errorf("Teams destination got %v, want one Adaptive Card message", messages)
+	}
+	// Discordはスレッドに対応しないため、タイトルと要約を続けて投稿する
+	if messages := discord.Messages(); len(messages) != 2 || messages[0]["embeds"] == nil {
+		t.Errorf("Discord destination got %v, want title and summary embeds", messages)
+	}
+	if report.Posted != 3 || report.Failed() {
+		t.Errorf("report = %+v, want one post per destination", report)
+	}
+}
+
+func TestRunOnceEmailDigest(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/1", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Second", Link: "https://example.com/2", GUID: "2", Published: time.Now().Add(-2 * time.Hour)},
+	)
+	smtp := fake.NewSMTPServer(t)
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+		config.Destination{Name: "stakeholders", Type: config.DestinationEmail, Mode: config.ModeDigest, DigestWindow: time.Hour, Email: &config.Email{
+			SMTPHost: smtp.Host,
+			SMTPPort: smtp.Port,
+			From:     "rss@example.com",
+			Recipients: []config.EmailRecipient{
+				{Address: "tanaka@example.com", Language: config.LanguageJapanese},
+				{Address: "smith@example.com", Language: config.LanguageEnglish},
+			},
+		}},
+	)
+	startDigestWindow(t, app, "stakeholders", time.Now().Add(-2*time.Hour))
+
+	report := app.RunOnce()
+
+	messages := smtp.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d emails, want one per recipient", len(messages))
+	}
+	if !strings.Contains(messages[0].Data, "tanaka@example.com") || !strings.Contains(messages[1].Data, "smith@example.com") {
+		t.Errorf("emails were not addressed per recipient: %+v", messages)
+	}
+	// メールは宛先ごとに送信するため、記事数も宛先ごとに数える
+	if report.Posted != 6 || report.Failed() || len(app.digestStore.Pending("stakeholders")) != 0 {
+		t.Errorf("report = %+v, want both articles posted to each destination and recipient", report)
+	}
+}
+
+func TestRunOnceEmailDigestRetriesFailedRecipients(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/1", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	smtp := fake.NewSMTPServer(t)
+	smtp.Reject("smith@example.com")
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+		config.Destination{Name: "stakeholders", Type: config.DestinationEmail, Mode: config.ModeDigest, DigestWindow: time.Hour, Email: &config.Email{
+			SMTPHost: smtp.Host,
+			SMTPPort: smtp.Port,
+			From:     "rss@example.com",
+			Recipients: []config.EmailRecipient{
+				{Address: "tanaka@example.com", Language: config.LanguageJapanese},
+				{Address: "smith@example.com", Language: config.LanguageEnglish},
+			},
+		}},
+	)
+	startDigestWindow(t, app, "stakeholders", time.Now().Add(-2*time.Hour))
+
+	// 送信できなかった宛先のメールだけを送信箱に残す
+	report := app.RunOnce()
+	pending := app.outbox.Pending()
+	if len(pending) != 1 || pending[0].Messages[0].Channel != "smith@example.com" || len(app.digestStore.Pending("stakeholders")) != 0 {
+		t.Fatalf("outbox = %+v, want only the rejected recipient queued", pending)
+	}
+	if report.Posted != 2 || !report.Failed() {
+		t.Errorf("report = %+v, want the article and one recipient posted and one failure", report)
+	}
+
+	// 再送では送信できた宛先に2通目を送らない
+	smtp.Accept("smith@example.com")
+	app.RunOnce()
+	var to []string
+	for _, message := range smtp.Messages() {
+		to = append(to, message.To...)
+	}
+	if len(to) != 2 || to[0] != "tanaka@example.com" || to[1] != "smith@example.com" {
+		t.Errorf("emails were sent to %v, want each recipient exactly once", to)
+	}
+	if pending := app.outbox.Pending(); len(pending) != 0 {
+		t.Errorf("outbox = %+v, want empty", pending)
+	}
+}
+
+func TestRunOnceFeedOutputFile(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app := newTestApp(t, env)
+	app.config.FeedOutputFile = filepath.Join(t.TempDir(), "public", "feed.xml")
+	app.config.FeedOutputFormat = config.FeedFormatRSS
+	app.config.FeedOutputURL = "https://rss.example.com/feed.xml"
+
+	if report := app.RunOnce(); report.Failed() {
+		t.Fatalf("report failures = %+v", report.Failures)
+	}
+
+	data, err := os.ReadFile(app.config.FeedOutputFile)
+	if err != nil {
+		t.Fatalf("feed file was not written: %v", err)
+	}
+	feed := string(data)
+	for _, want := range []string{
+		`<rss version="2.0"`,
+		`<atom:link href="https://rss.example.com/feed.xml" rel="self"`,
+		"<title>[JA] Understanding Caches</title>",
+		"<link>https://example.com/caches</link>",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed does not contain %s:\n%s", want, feed)
+		}
+	}
+}
+
+// hostRouter は固定のホスト名へのリクエストをフェイクサーバーに振り分ける
+// （カセットのURLをフェイクサーバーのポート番号に依存させないため）
+type hostRouter map[string]string
+
+func (hr hostRouter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if target, ok := hr[req.URL.Host]; ok {
+		u, _ := url.Parse(target)
+		req = req.Clone(req.Context())
+		req.URL.Scheme = u.Scheme
+		req.URL.Host = u.Host
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestRunOnceReplay(t *testing.T) {
+	cassette := filepath.Join("testdata", "run_once.cassette.json")
+	const webhookURL = "http://hooks.slack.test/services/T000/B000/XXXX"
+
+	mode := recorder.ModeReplay
+	var inner http.RoundTripper
+	var slack *fake.SlackServer
+	if *update {
+		env := newTestEnv(t,
+			fake.Item{Title: "Understanding Caches", Description: "A deep dive.", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+			fake.Item{Title: "Old article", Link: "https://example.com/old", GUID: "2", Published: time.Now().Add(-72 * time.Hour)},
+		)
+		mode, slack = recorder.ModeRecord, env.slack
+		inner = hostRouter{
+			"feed.test":        env.feed.URL,
+			"deepl.test":       env.deepL,
+			"openai.test":      env.openAI.URL,
+			"hooks.slack.test": env.slack.URL,
+		}
+	}
+
+	transport, err := recorder.New(mode, cassette, inner, "deepl-key", "openai-key", webhookURL)
+	if err != nil {
+		t.Fatalf("recorder.New() error = %v", err)
+	}
+
+	cfg := &config.Config{
+		FeedURLs:           []string{"http://feed.test/rss"},
+		Feeds:              []config.Feed{{URL: "http://feed.test/rss"}},
+		MaxArticlesPerFeed: 10,
+		MaxCatchUp:         24 * time.Hour,
+		FirstRunBackfill:   10,
+		DeepLAPIKey:        "deepl-key",
+		DeepLAPIURL:        "http://deepl.test/v2/translate",
+		OpenAIAPIKey:       "openai-key",
+		OpenAIModel:        "gpt-3.5-turbo",
+		OpenAIBaseURL:      "http://openai.test/v1",
+		SlackWebhookURL:    webhookURL,
+		Destinations: []config.Destination{
+			{Name: "default", WebhookURL: webhookURL, Channel: "#test", Mode: config.ModeThread, Format: config.FormatBlocks},
+		},
+		StateDir: t.TempDir(),
+	}
+	app, err := NewApp(cfg,
+		service.WithHTTPClient(&http.Client{Transport: transport, Timeout: 30 * time.Second}),
+		service.WithClock(transport.Now),
+	)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	app.RunOnce()
+
+	if *update {
+		if got := len(slack.Messages()); got != 2 {
+			t.Fatalf("recorded %d Slack messages, want 2", got)
+		}
+		return
+	}
+	// 記録した通信（フィード取得・翻訳2件・要約・Slack投稿2件）をすべて再生している
+	if n := transport.Unused(); n != 0 {
+		t.Errorf("%d recorded interactions were not replayed", n)
+	}
+}
+
+func TestRecorderSecrets(t *testing.T) {
+	cfg := &config.Config{
+		DeepLAPIKey:  "deepl-key",
+		OpenAIAPIKey: "openai-key",
+		Feeds: []config.Feed{
+			{URL: "https://example.com/feed"},
+			{URL: "https://example.com/private", HTTP: &config.FeedHTTP{
+				Headers:     map[string]string{"X-Api-Token": "header-token"},
+				UserAgent:   "bot/1.0 (token ua-token)",
+				BearerToken: "bearer-token",
+				BasicAuth:   &config.BasicAuth{Username: "user", Password: "basic-password"},
+				Cookies:     map[string]string{"session": "cookie-value"},
+			}},
+		},
+	}
+
+	secrets := strings.Join(recorderSecrets(cfg), "\n")
+	for _, want := range []string{"deepl-key", "openai-key", "bearer-token", "basic-password", "cookie-value"} {
+		if !strings.Contains(secrets, want) {
+			t.Errorf("recorderSecrets() does not contain %q", want)
+		}
+	}
+	// ヘッダーの値はボディなどを書き換えないよう、ヘッダーごとに置き換える
+	if strings.Contains(secrets, "header-token") || strings.Contains(secrets, "ua-token") {
+		t.Errorf("recorderSecrets() contains header values: %q", secrets)
+	}
+	headers := strings.Join(recorderHeaders(cfg), ",")
+	if !strings.Contains(headers, "X-Api-Token") || !strings.Contains(headers, "User-Agent") {
+		t.Errorf("recorderHeaders() = %q, want X-Api-Token and User-Agent", headers)
+	}
+}
+
+func TestRunOnceRelevanceThreshold(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Company picnic photos", Link: "https://example.com/picnic", GUID: "2", Published: time.Now().Add(-time.Hour)},
+	)
+	env.openAI.SetReplyFunc(func(prompt string) string {
+		switch {
+		case !strings.Contains(prompt, "関心プロファイル"):
+			return "テスト用の要約です。"
+		case strings.Contains(prompt, "Caches"):
+			return `{"score": 90, "reason": "キャッシュ設計の解説"}`
+		default:
+			return `{"score": 10, "reason": "技術的な内容ではない"}`
+		}
+	})
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+	)
+	app.config.RelevanceProfile = "バックエンドのパフォーマンス改善"
+	app.config.RelevanceThreshold = 60
+
+	app.RunOnce()
+
+	messages := env.slack.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d Slack messages, want 1: %v", len(messages), env.slack.Texts())
+	}
+	if !strings.Contains(messages[0]["text"].(string), "[JA] Understanding Caches") {
+		t.Errorf("message text = %q", messages[0]["text"])
+	}
+	if blocks := fmt.Sprint(messages[0]["blocks"]); !strings.Contains(blocks, "関連度 90/100: キャッシュ設計の解説") {
+		t.Errorf("relevance is not shown in blocks: %s", blocks)
+	}
+	// 関連度判定2件 + 要約1件（除外した記事は要約しない）
+	if env.openAI.Requests() != 3 {
+		t.Errorf("OpenAI requests = %d, want 3", env.openAI.Requests())
+	}
+}
+
+func TestRunOnceWatermark(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Undated post", Link: "https://example.com/undated", GUID: "2"},
+	)
+	app := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+	)
+
+	// 通知に失敗した場合は処理済み位置を進めない
+	env.slack.SetFail(true)
+	app.RunOnce()
+	env.slack.SetFail(false)
+
+	// 初回は日付のない記事を既読として扱う
+	app.RunOnce()
+	if got := env.slack.Texts(); len(got) != 1 || !strings.Contains(got[0], "Understanding Caches") {
+		t.Fatalf("Slack messages after retry = %v, want only the dated article", got)
+	}
+
+	// 新たに現れた日付のない記事は1度だけ通知する
+	env.feed.SetItems(
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+		fake.Item{Title: "Undated post", Link: "https://example.com/undated", GUID: "2"},
+		fake.Item{Title: "Another undated post", Link: "https://example.com/undated-2", GUID: "3"},
+	)
+	app.RunOnce()
+	app.RunOnce()
+	if got := env.slack.Texts(); len(got) != 2 || !strings.Contains(got[1], "Another undated post") {
+		t.Errorf("Slack messages = %v, want the new undated article exactly once", got)
+	}
+}
+
+func TestRunOnceOverflowNotice(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Oldest", Link: "https://example.com/oldest", GUID: "1", Published: time.Now().Add(-3 * time.Hour)},
+		fake.Item{Title: "Middle", Link: "https://example.com/middle", GUID: "2", Published: time.Now().Add(-2 * time.Hour)},
+		fake.Item{Title: "Newest", Link: "https://example.com/newest", GUID: "3", Published: time.Now().Add(-time.Hour)},
+	)
+	// 上限と超過時の扱いはNewAppでフィードサービスに渡すため、設定を変更して作り直す
+	cfg := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+	).config
+	cfg.MaxArticlesPerFeed = 1
+	cfg.OverflowPolicy = config.OverflowNotice
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	app.RunOnce()
+
+	// 最新の記事 + 残り2件の超過通知
+	texts := env.slack.Texts()
+	if len(texts) != 2 || !strings.Contains(texts[0], "Newest") {
+		t.Fatalf("Slack messages = %v, want the newest article and an overflow notice", texts)
+	}
+	if blocks := fmt.Sprint(env.slack.Messages()[1]["blocks"]); !strings.Contains(blocks, "Oldest") || !strings.Contains(blocks, "Middle") {
+		t.Errorf("overflow notice does not list skipped articles: %s", blocks)
+	}
+}
+
+func TestRunOnceFeedFailures(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "Working feed article", Link: "https://example.com/working", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	broken := fake.NewFeedServer(t, "Broken Blog")
+	broken.SetFail(true)
+
+	// 健全性チェックの設定はNewAppでフィードサービスに渡すため、設定を変更して作り直す
+	cfg := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Mode: config.ModeArticle, Format: config.FormatBlocks},
+	).config
+	cfg.Feeds = append(cfg.Feeds, config.Feed{URL: broken.URL})
+	cfg.FeedFailureThreshold = 1
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	// 一部のフィードのみの失敗はアラートを送り、取得できた記事は通知する
+	app.RunOnce()
+	texts := env.slack.Texts()
+	if len(texts) != 2 || !strings.Contains(texts[0], strings.TrimPrefix(broken.URL, "http://")) || !strings.Contains(texts[0], "1回連続で失敗") ||
+		!strings.Contains(texts[1], "Working feed article") {
+		t.Fatalf("Slack messages = %v, want a broken feed alert and the article", texts)
+	}
+
+	// すべてのフィードの取得に失敗した場合はエラー通知を送る（アラートは繰り返さない）
+	env.feed.SetFail(true)
+	app.RunOnce()
+	texts = env.slack.Texts()
+	if len(texts) != 4 || !strings.Contains(texts[2], strings.TrimPrefix(env.feed.URL, "http://")) || !strings.Contains(texts[3], "エラーが発生しました") {
+		t.Fatalf("Slack messages = %v, want an alert for the newly broken feed and an error notification", texts)
+	}
+}
+
+func TestRunOnceReport(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/first", GUID: "1", Published: time.Now().Add(-2 * time.Hour)},
+		fake.Item{Title: "Second", Link: "https://example.com/second", GUID: "2", Published: time.Now().Add(-time.Hour)},
+	)
+	ops := fake.NewSlackServer(t)
+
+	// 運用通知の送信先はNewAppで決まるため、設定を変更して作り直す
+	cfg := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Channel: "#news", Mode: config.ModeArticle, Format: config.FormatBlocks},
+	).config
+	cfg.OpsWebhookURL = ops.URL
+	cfg.OpsChannel = "#ops"
+	cfg.RunSummary = config.RunSummaryOnFailure
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	env.slack.SetFail(true)
+	report := app.RunOnce()
+
+	if report.New != 2 || report.Translated != 2 || report.Summarized != 2 || report.Posted != 0 || report.FeedsChecked != 1 || report.Fetched != 2 {
+		t.Errorf("report = %+v", report)
+	}
+	if !report.Failed() || len(report.Failures) != 2 || report.Failures[0].Stage != service.StageNotify || !strings.Contains(report.Failures[0].Target, "First") {
+		t.Fatalf("failures = %+v, want notification failures for both articles", report.Failures)
+	}
+
+	// 失敗があった場合は運用通知の送信先に実行サマリーを送る
+	messages := ops.Messages()
+	if len(messages) != 1 || messages[0]["channel"] != "#ops" || !strings.Contains(fmt.Sprint(messages[0]["blocks"]), "2件の失敗") {
+		t.Errorf("ops messages = %v, want a run summary with failures", messages)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.StateDir, "run_report.json")); err != nil {
+		t.Errorf("run report was not saved: %v", err)
+	}
+
+	// 失敗がなければ on_failure では送らない
+	env.slack.SetFail(false)
+	if report := app.RunOnce(); report.Failed() || report.Posted != 2 {
+		t.Errorf("retry report = %+v, want both articles posted", report)
+	}
+	if got := len(ops.Messages()); got != 1 {
+		t.Errorf("got %d ops messages, want no summary for a successful run", got)
+	}
+}
+
+func TestRunOnceHoldDegraded(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/first", GUID: "1", Published: time.Now().Add(-2 * time.Hour)},
+	)
+
+	// DeepLの障害を切り替えられるようにする
+	var deepLDown atomic.Bool
+	deepL := fake.NewDeepLServer(t)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if deepLDown.Load() {
+			http.Error(w, "quota exceeded", 456)
+			return
+		}
+		deepL.Config.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(flaky.Close)
+	env.deepL = flaky.URL
+
+	cfg := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Channel: "#news", Mode: config.ModeArticle, Format: config.FormatBlocks},
+	).config
+	cfg.DegradedPolicy = config.DegradedHold
+	cfg.DegradedMaxHolds = 1
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	// 翻訳に失敗した記事は通知せずに保留する
+	deepLDown.Store(true)
+	report := app.RunOnce()
+	if report.Held != 1 || report.Posted != 0 || len(env.slack.Messages()) != 0 {
+		t.Fatalf("report = %+v, messages = %v, want the article held", report, env.slack.Texts())
+	}
+	if items := app.holdStore.Items(); len(items) != 1 || items[0].Attempts != 1 || items[0].LastError == "" {
+		t.Fatalf("held items = %+v", items)
+	}
+
+	// 次回の実行で再試行し、翻訳できれば通常どおり通知する
+	deepLDown.Store(false)
+	report = app.RunOnce()
+	if report.Held != 0 || report.Posted != 1 || len(app.holdStore.Items()) != 0 {
+		t.Fatalf("retry report = %+v, want the held article posted", report)
+	}
+	if texts := env.slack.Texts(); len(texts) != 1 || !strings.Contains(texts[0], "[JA] First") {
+		t.Errorf("texts = %v, want the translated title", texts)
+	}
+
+	// 保留の上限に達した記事は失敗したことを表示して通知する
+	env.feed.SetItems(fake.Item{Title: "Second", Link: "https://example.com/second", GUID: "2"})
+	deepLDown.Store(true)
+	if report := app.RunOnce(); report.Held != 1 {
+		t.Fatalf("report = %+v, want the new article held", report)
+	}
+	report = app.RunOnce()
+	if report.Held != 0 || report.Posted != 1 || len(app.holdStore.Items()) != 0 {
+		t.Fatalf("report = %+v, want the article posted after reaching the hold limit", report)
+	}
+	messages := env.slack.Messages()
+	if last := fmt.Sprint(messages[len(messages)-1]["blocks"]); !strings.Contains(last, "翻訳に失敗したため原文を表示しています") {
+		t.Errorf("last message = %s, want a degraded notice", last)
+	}
+}
+
+func TestRunOnceOutbox(t *testing.T) {
+	env := newTestEnv(t,
+		fake.Item{Title: "First", Link: "https://example.com/first", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	cfg := newTestApp(t, env,
+		config.Destination{Name: "default", WebhookURL: env.slack.URL, Channel: "#news", Mode: config.ModeThread, Format: config.FormatBlocks},
+	).config
+	cfg.OutboxMaxAttempts = 2
+	app, err := NewApp(cfg)
+	if err != nil {
+		t.Fatalf("NewApp() error = %v", err)
+	}
+	app.interval = 0
+
+	// 送信に失敗したメッセージは送信箱に残し、処理済み位置は進める
+	env.slack.SetFail(true)
+	report := app.RunOnce()
+	if report.Posted != 0 || report.Queued != 1 || !report.Failed() {
+		t.Fatalf("report = %+v, want the article queued", report)
+	}
+	reloaded, err := service.NewOutbox(filepath.Join(cfg.StateDir, "outbox.json"), service.OutboxPolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending := reloaded.Pending(); len(pending) != 1 || len(pending[0].Messages) != 2 || pending[0].Attempts != 1 {
+		t.Fatalf("persisted outbox = %+v", pending)
+	}
+
+	// 上限に達したらデッドレターに移し、再送しない
+	report = app.RunOnce()
+	if report.New != 0 || report.Queued != 0 || len(app.outbox.DeadLetters()) != 1 {
+		t.Fatalf("report = %+v, dead = %d, want the message dead-lettered", report, len(app.outbox.DeadLetters()))
+	}
+	env.slack.SetFail(false)
+	app.RunOnce()
+	if got := len(env.slack.Messages()); got != 0 {
+		t.Fatalf("got %d messages, want dead letters not to be resent", got)
+	}
+
+	// デッドレターを戻すと次回の実行でタイトルと要約を送信する
+	app.outbox.ReplayAll()
+	report = app.RunOnce()
+	if report.Posted != 1 || report.Queued != 0 || len(env.slack.Messages()) != 2 {
+		t.Errorf("report = %+v, messages = %v, want the replayed article posted", report, env.slack.Texts())
+	}
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	_ "modernc.org/sqlite" // database/sqlのドライバー（cgoを使わないSQLite）
+)
+
+// defaultSearchLimit は検索結果の件数の既定値（Searchに0以下の件数を指定した場合）
+const defaultSearchLimit = 20
+
+// minFTSQueryLength は全文検索のインデックスで検索できる語の最小の文字数
+// （日本語は単語の区切りがないためtrigramで索引付けしており、2文字以下の語は部分一致で検索する）
+const minFTSQueryLength = 3
+
+// archiveSchema はアーカイブのテーブルと全文検索のインデックス
+// （記事はフィードとGUIDで識別する。articles_ftsはarticlesを参照する外部コンテンツのFTS5テーブルで、トリガーで同期する）
+const archiveSchema = `
+CREATE TABLE IF NOT EXISTS articles (
+	id                     INTEGER PRIMARY KEY,
+	feed_url               TEXT NOT NULL,
+	guid                   TEXT NOT NULL,
+	link                   TEXT NOT NULL,
+	feed_name              TEXT NOT NULL,
+	original_title         TEXT NOT NULL,
+	translated_title       TEXT NOT NULL,
+	original_description   TEXT NOT NULL,
+	translated_description TEXT NOT NULL,
+	summary                TEXT NOT NULL,
+	published_at           INTEGER NOT NULL,
+	archived_at            INTEGER NOT NULL,
+	updated_at             INTEGER NOT NULL,
+	result                 TEXT NOT NULL,
+	UNIQUE (feed_url, guid)
+);
+CREATE INDEX IF NOT EXISTS articles_published_at ON articles (published_at);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS articles_fts USING fts5(
+	original_title, translated_title, original_description, translated_description, summary,
+	content='articles', content_rowid='id', tokenize='trigram'
+);
+
+CREATE TRIGGER IF NOT EXISTS articles_ai AFTER INSERT ON articles BEGIN
+	INSERT INTO articles_fts (rowid, original_title, translated_title, original_description, translated_description, summary)
+	VALUES (new.id, new.original_title, new.translated_title, new.original_description, new.translated_description, new.summary);
+END;
+CREATE TRIGGER IF NOT EXISTS articles_ad AFTER DELETE ON articles BEGIN
+	INSERT INTO articles_fts (articles_fts, rowid, original_title, translated_title, original_description, translated_description, summary)
+	VALUES ('delete', old.id, old.original_title, old.translated_title, old.original_description, old.translated_description, old.summary);
+END;
+CREATE TRIGGER IF NOT EXISTS articles_au AFTER UPDATE ON articles BEGIN
+	INSERT INTO articles_fts (articles_fts, rowid, original_title, translated_title, original_description, translated_description, summary)
+	VALUES ('delete', old.id, old.original_title, old.translated_title, old.original_description, old.translated_description, old.summary);
+	INSERT INTO articles_fts (rowid, original_title, translated_title, original_description, translated_description, summary)
+	VALUES (new.id, new.original_title, new.translated_title, new.original_description, new.translated_description, new.summary);
+END;
+`
+
+// searchColumns は部分一致で検索するカラム（全文検索のインデックスと同じ）
+var searchColumns = []string{"original_title", "translated_title", "original_description", "translated_description", "summary"}
+
+// ArchivedArticle はアーカイブに保存した記事
+type ArchivedArticle struct {
+	ID         int64              `json:"id"`
+	Result     *TranslationResult `json:"result"`
+	ArchivedAt time.Time          `json:"archived_at"` // 最初に保存した日時
+	UpdatedAt  time.Time          `json:"updated_at"`  // 最後に保存した日時（保留した記事を再試行した場合など）
+}
+
+// Archive は翻訳・要約した記事をSQLiteに保存し、原文と翻訳の両方を全文検索できるようにする
+type Archive struct {
+	db *sql.DB
+}
+
+// OpenArchive はアーカイブのデータベースを開く（存在しない場合は作成する）
+func OpenArchive(path string) (*Archive, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	if _, err := db.Exec(archiveSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize archive %s: %w", path, err)
+	}
+
+	return &Archive{db: db}, nil
+}
+
+// archiveKey は記事を識別するGUIDを返す（GUIDがない場合はリンク、リンクもない場合は原文タイトル）
+func archiveKey(r *TranslationResult) string {
+	switch {
+	case r.GUID != "":
+		return r.GUID
+	case r.Link != "":
+		return r.Link
+	}
+	return r.OriginalTitle
+}
+
+// resultKey はフィードのURLとGUIDで記事を識別するキーを返す（リンクのない記事も区別する）
+func resultKey(r *TranslationResult) string {
+	return r.Feed.URL + "\n" + archiveKey(r)
+}
+
+// Close はデータベースを閉じる
+func (a *Archive) Close() error {
+	return a.db.Close()
+}
+
+// Add は記事を保存する（同じフィード・GUIDの記事は最新の翻訳結果で上書きし、最初に保存した日時は変えない）
+func (a *Archive) Add(results []*TranslationResult, now time.Time) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin archive transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO articles (feed_url, guid, link, feed_name, original_title, translated_title, original_description, translated_description,
+			summary, published_at, archived_at, updated_at, result)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (feed_url, guid) DO UPDATE SET
+			link = excluded.link,
+			feed_name = excluded.feed_name,
+			original_title = excluded.original_title,
+			translated_title = excluded.translated_title,
+			original_description = excluded.original_description,
+			translated_description = excluded.translated_description,
+			summary = excluded.summary,
+			published_at = excluded.published_at,
+			updated_at = excluded.updated_at,
+			result = excluded.result`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare archive statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to marshal archived article: %w", err)
+		}
+		_, err = stmt.Exec(r.Feed.URL, archiveKey(r), r.Link, r.Feed.Name, r.OriginalTitle, r.TranslatedTitle, r.OriginalDescription, r.TranslatedDescription,
+			r.Summary, r.Published.Unix(), now.Unix(), now.Unix(), string(data))
+		if err != nil {
+			return fmt.Errorf("failed to archive %s: %w", r.Link, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit archive transaction: %w", err)
+	}
+	return nil
+}
+
+// Search は原文と翻訳のタイトル・概要・要約を全文検索する（空白で区切った語をすべて含む記事を返す）
+// 3文字以上の語は全文検索のインデックスで関連度順に、2文字以下の語のみの場合は部分一致で新しい順に検索する
+func (a *Archive) Search(query string, limit int) ([]*ArchivedArticle, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	var phrases, conditions []string
+	var args []any
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) >= minFTSQueryLength {
+			phrases = append(phrases, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+			continue
+		}
+		var likes []string
+		for _, column := range searchColumns {
+			likes = append(likes, "a."+column+` LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+escapeLike(term)+"%")
+		}
+		conditions = append(conditions, "("+strings.Join(likes, " OR ")+")")
+	}
+
+	from, order := "articles a", "a.published_at DESC"
+	if len(phrases) > 0 {
+		from = "articles_fts f JOIN articles a ON a.id = f.rowid"
+		conditions = append([]string{"articles_fts MATCH ?"}, conditions...)
+		args = append([]any{strings.Join(phrases, " AND ")}, args...)
+		order = "bm25(articles_fts), a.published_at DESC"
+	}
+	args = append(args, limit)
+
+	return a.query(fmt.Sprintf("SELECT a.id, a.result, a.archived_at, a.updated_at FROM %s WHERE %s ORDER BY %s LIMIT ?",
+		from, strings.Join(conditions, " AND "), order), args...)
+}
+
+// Recent は最近保存した記事を新しい順に返す
+func (a *Archive) Recent(limit int) ([]*ArchivedArticle, error) {
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	return a.query("SELECT id, result, archived_at, updated_at FROM articles ORDER BY updated_at DESC, id DESC LIMIT ?", limit)
+}
+
+// query は記事を検索するクエリを実行し、保存した翻訳結果を復元する
+func (a *Archive) query(query string, args ...any) ([]*ArchivedArticle, error) {
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search archive: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []*ArchivedArticle
+	for rows.Next() {
+		var article ArchivedArticle
+		var result string
+		var archivedAt, updatedAt int64
+		if err := rows.Scan(&article.ID, &result, &archivedAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to read archived article: %w", err)
+		}
+		if err := json.Unmarshal([]byte(result), &article.Result); err != nil {
+			return nil, fmt.Errorf("failed to parse archived article %d: %w", article.ID, err)
+		}
+		article.ArchivedAt = time.Unix(archivedAt, 0)
+		article.UpdatedAt = time.Unix(updatedAt, 0)
+		articles = append(articles, &article)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search archive: %w", err)
+	}
+	return articles, nil
+}
+
+// escapeLike はLIKEのパターンで特別な意味を持つ文字をエスケープする
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
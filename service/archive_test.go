@@ -0,0 +1,122 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "archive.db")
+	archive, err := OpenArchive(path)
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+
+	feed := FeedInfo{URL: "https://example.com/feed", Name: "Example"}
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	err = archive.Add([]*TranslationResult{
+		{
+			OriginalTitle: "Understanding Distributed Caches", TranslatedTitle: "分散キャッシュを理解する",
+			OriginalDescription: "Cache invalidation strategies.", Summary: "キャッシュの無効化戦略を解説します。",
+			Link: "https://example.com/caches", Feed: feed, Published: now.Add(-2 * time.Hour),
+		},
+		{
+			OriginalTitle: "Scaling Databases", TranslatedTitle: "データベースのスケーリング",
+			OriginalDescription: "Sharding and replication.", Summary: "シャーディングとレプリケーションの要約です。",
+			Link: "https://example.com/databases", Feed: feed, Published: now.Add(-time.Hour),
+			SummaryFailed: true,
+		},
+	}, now)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	// 同じリンクの記事は最新の翻訳結果で上書きする
+	err = archive.Add([]*TranslationResult{{
+		OriginalTitle: "Scaling Databases", TranslatedTitle: "データベースのスケーリング",
+		OriginalDescription: "Sharding and replication.", Summary: "シャーディングとレプリケーションを解説します。",
+		Link: "https://example.com/databases", Feed: feed, Published: now.Add(-time.Hour),
+	}}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	archive.Close()
+
+	// 保存した記事は開き直しても検索できる
+	archive, err = OpenArchive(path)
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// 原文（大文字小文字を区別しない）
+		{"cache", []string{"https://example.com/caches"}},
+		// 翻訳（空白で区切った語をすべて含む記事）
+		{"キャッシュ 無効化", []string{"https://example.com/caches"}},
+		{"sharding キャッシュ", nil},
+		// 2文字以下の語は部分一致で新しい順に検索する
+		{"解説", []string{"https://example.com/databases", "https://example.com/caches"}},
+		// 上書きする前の要約は検索されない
+		{"要約です", nil},
+		{`"quoted`, nil},
+	}
+	for _, tt := range tests {
+		articles, err := archive.Search(tt.query, 10)
+		if err != nil {
+			t.Errorf("Search(%q) error = %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for _, article := range articles {
+			got = append(got, article.Result.Link)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	recent, err := archive.Recent(1)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(recent) != 1 || recent[0].Result.Link != "https://example.com/databases" || recent[0].Result.SummaryFailed ||
+		!recent[0].ArchivedAt.Equal(now) || !recent[0].UpdatedAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Recent() = %+v", recent[0])
+	}
+
+	if _, err := archive.Search("  ", 10); err == nil {
+		t.Error("Search(empty) error = nil, want error")
+	}
+}
+
+func TestArchiveItemsWithoutLink(t *testing.T) {
+	archive, err := OpenArchive(filepath.Join(t.TempDir(), "archive.db"))
+	if err != nil {
+		t.Fatalf("OpenArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	// リンクのない記事もフィードとGUIDで区別して保存する
+	feed := FeedInfo{URL: "https://example.com/scrape"}
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	err = archive.Add([]*TranslationResult{
+		{OriginalTitle: "First Notice", TranslatedTitle: "最初のお知らせ", GUID: "notice-1", Feed: feed},
+		{OriginalTitle: "Second Notice", TranslatedTitle: "次のお知らせ", GUID: "notice-2", Feed: feed},
+		{OriginalTitle: "First Notice", TranslatedTitle: "別フィードのお知らせ", GUID: "notice-1", Feed: FeedInfo{URL: "https://example.com/other"}},
+	}, now)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	recent, err := archive.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent() error = %v", err)
+	}
+	if len(recent) != 3 {
+		t.Errorf("Recent() = %d articles, want 3", len(recent))
+	}
+}
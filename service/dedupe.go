@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// titleSimilarityDistance はタイトルが同じ話題とみなすSimHashのハミング距離の上限
+const titleSimilarityDistance = 3
+
+// minSimilarityTokens はタイトルの類似判定を行う最小の単語数（短いタイトルは誤判定しやすいため）
+const minSimilarityTokens = 4
+
+// maxCanonicalPageSize は canonical URL を探すために読み込むページサイズの上限
+const maxCanonicalPageSize = 512 * 1024
+
+// aggregatorSuffix はアグリゲーターがタイトル末尾に付ける注記（"(2023)" や "[pdf]" など）
+var aggregatorSuffix = regexp.MustCompile(`\s*[(\[](\d{4}|pdf|video)[)\]]\s*$`)
+
+// trackingParams は正規化時に取り除く広告・計測用のクエリパラメータ（utm_ で始まるものも取り除く）
+// ref や source などはサイトによって記事の内容を指定するため取り除かない
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "_hsenc": true, "_hsmi": true, "igshid": true,
+}
+
+// canonicalAccept は canonical URL を探すために記事ページを取得する際に送るAcceptヘッダー
+const canonicalAccept = "text/html, application/xhtml+xml;q=0.9, */*;q=0.8"
+
+// ItemSource は記事の配信元（重複をまとめた場合は複数になる）
+type ItemSource struct {
+	Feed FeedInfo `json:"feed"`
+	Link string   `json:"link"`
+}
+
+// mergeDuplicates は異なるフィードから届いた同じ記事を1件にまとめる
+// （正規化したURLが一致するか、タイトルのSimHashが近いものを重複とみなす）
+func (fs *FeedService) mergeDuplicates(ctx context.Context, items []*FeedItem) []*FeedItem {
+	type entry struct {
+		item    *FeedItem
+		url     string
+		simhash uint64
+		tokens  int
+		merged  []*FeedItem
+	}
+
+	var entries []*entry
+	for _, item := range items {
+		e := &entry{item: item, url: fs.canonicalURL(ctx, item)}
+		tokens := titleTokens(item.Title)
+		e.tokens = len(tokens)
+		e.simhash = simHash(tokens)
+
+		var dup *entry
+		for _, other := range entries {
+			if other.item.Feed.URL == item.Feed.URL {
+				continue // 同じフィード内の記事はまとめない
+			}
+			if e.url != "" && e.url == other.url {
+				dup = other
+				break
+			}
+			if e.tokens >= minSimilarityTokens && other.tokens >= minSimilarityTokens &&
+				bits.OnesCount64(e.simhash^other.simhash) <= titleSimilarityDistance {
+				dup = other
+				break
+			}
+		}
+
+		if dup == nil {
+			entries = append(entries, e)
+			continue
+		}
+		log.Printf("Duplicate item merged: %s (%s) = %s (%s)", item.Title, item.Feed.Name, dup.item.Title, dup.item.Feed.Name)
+		dup.merged = append(dup.merged, item)
+	}
+
+	var result []*FeedItem
+	for _, e := range entries {
+		if len(e.merged) == 0 {
+			result = append(result, e.item)
+			continue
+		}
+		result = append(result, mergeItems(append([]*FeedItem{e.item}, e.merged...)))
+	}
+	return result
+}
+
+// mergeItems は重複した記事を1件にまとめる
+// 記事のリンク先と同じサイトのフィード（アグリゲーターではなく配信元のブログなど）の記事を代表とし、全配信元を記録する
+func mergeItems(items []*FeedItem) *FeedItem {
+	primary := items[0]
+	for _, item := range items {
+		if isOriginFeed(item) {
+			primary = item
+			break
+		}
+	}
+
+	merged := *primary
+	merged.Sources = nil
+	for _, item := range items {
+		merged.Sources = append(merged.Sources, ItemSource{Feed: item.Feed, Link: item.Link})
+		if merged.Description == "" {
+			merged.Description = item.Description
+		}
+		for _, category := range item.Categories {
+			if !containsString(merged.Categories, category) {
+				merged.Categories = append(merged.Categories, category)
+			}
+		}
+	}
+	return &merged
+}
+
+// isOriginFeed は記事のリンク先がフィードのサイトと同じホストかを判定する
+func isOriginFeed(item *FeedItem) bool {
+	link, err := url.Parse(item.Link)
+	if err != nil {
+		return false
+	}
+	site, err := url.Parse(item.Feed.Link)
+	if err != nil || site.Host == "" {
+		return false
+	}
+	return trimWWW(strings.ToLower(link.Host)) == trimWWW(strings.ToLower(site.Host))
+}
+
+// canonicalURL は記事URLを正規化する
+// resolveURLsが有効な場合はリダイレクトを辿り、ページの <link rel="canonical"> を優先する
+func (fs *FeedService) canonicalURL(ctx context.Context, item *FeedItem) string {
+	link := item.Link
+	if link == "" {
+		return ""
+	}
+	if fs.resolveURLs {
+		if resolved, err := fs.resolveCanonicalURL(ctx, fs.feedConfig(item.Feed.URL), link); err != nil {
+			log.Printf("Failed to resolve canonical URL for %s: %v", link, err)
+		} else {
+			link = resolved
+		}
+	}
+	return normalizeURL(link)
+}
+
+// feedConfig はURLが一致するフィードの設定を返す（見つからない場合はURLのみの設定）
+func (fs *FeedService) feedConfig(feedURL string) FeedConfig {
+	for _, fc := range fs.feeds {
+		if fc.URL == feedURL {
+			return fc
+		}
+	}
+	return FeedConfig{URL: feedURL}
+}
+
+// resolveCanonicalURL は記事ページを取得し、リダイレクト後のURLまたは canonical URL を返す
+// フィードのプロキシ・CA証明書の設定を使い、ヘッダーや認証情報はフィードと同じホストの記事にのみ送る
+func (fs *FeedService) resolveCanonicalURL(ctx context.Context, fc FeedConfig, link string) (string, error) {
+	client, err := fs.feedClient(fc)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	config := fc.HTTP
+	if !sameHost(link, fc.URL) {
+		config = nil
+	}
+	config.apply(req, canonicalAccept)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	final := resp.Request.URL
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return final.String(), nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxCanonicalPageSize))
+	if err != nil {
+		return final.String(), nil
+	}
+	href, ok := doc.Find(`link[rel="canonical"]`).First().Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		return final.String(), nil
+	}
+	canonical, err := final.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return final.String(), nil
+	}
+	return canonical.String(), nil
+}
+
+// normalizeURL は比較用にURLを正規化する
+// （スキーム・www・フラグメント・末尾のスラッシュの違いとトラッキング用のパラメータを無視する）
+func normalizeURL(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return strings.TrimSpace(link)
+	}
+
+	query := u.Query()
+	for key := range query {
+		if strings.HasPrefix(strings.ToLower(key), "utm_") || trackingParams[strings.ToLower(key)] {
+			query.Del(key)
+		}
+	}
+
+	host := trimWWW(strings.ToLower(u.Host))
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+
+	normalized := host + path
+	if len(query) > 0 {
+		normalized += "?" + query.Encode() // Encode はキー順に並べる
+	}
+	return normalized
+}
+
+// titleTokens はタイトルを小文字の単語に分割する（"Show HN:" などの接頭辞や "(2023)" などの注記は除く）
+func titleTokens(title string) []string {
+	title = aggregatorSuffix.ReplaceAllString(strings.ToLower(title), "")
+	for _, prefix := range []string{"show hn:", "ask hn:", "launch hn:", "tell hn:"} {
+		title = strings.TrimPrefix(title, prefix)
+	}
+
+	return strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// simHash は単語と隣接する単語の組から64bitのSimHashを計算する
+func simHash(tokens []string) uint64 {
+	features := append([]string(nil), tokens...)
+	for i := 0; i+1 < len(tokens); i++ {
+		features = append(features, tokens[i]+" "+tokens[i+1])
+	}
+
+	var weights [64]int
+	for _, feature := range features {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// sameHost は2つのURLのホストが同じかを判定する
+func sameHost(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil || ua.Host == "" {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Host, ub.Host)
+}
+
+// trimWWW はホスト名の先頭の "www." を取り除く
+func trimWWW(host string) string {
+	return strings.TrimPrefix(host, "www.")
+}
+
+// containsString はスライスに文字列が含まれるかを判定する
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://www.Example.com/post/?utm_source=hn&utm_medium=rss#comments", "example.com/post"},
+		{"http://example.com/post", "example.com/post"},
+		{"https://example.com/post?id=2&fbclid=x&a=1", "example.com/post?a=1&id=2"},
+		{"https://example.com/search?source=docs&ref=main&gclid=x", "example.com/search?ref=main&source=docs"},
+		{"not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeURL(tt.in); got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	distance := func(a, b string) int {
+		return bits.OnesCount64(simHash(titleTokens(a)) ^ simHash(titleTokens(b)))
+	}
+
+	similar := [][2]string{
+		{"Show HN: I built a Rust compiler in Go", "I built a Rust compiler in Go"},
+		{"Understanding Caches in Distributed Systems", "Understanding caches in distributed systems (2023)"},
+	}
+	for _, pair := range similar {
+		if d := distance(pair[0], pair[1]); d > titleSimilarityDistance {
+			t.Errorf("distance(%q, %q) = %d, want <= %d", pair[0], pair[1], d, titleSimilarityDistance)
+		}
+	}
+
+	different := [][2]string{
+		{"Announcing Rust 1.75.0", "Announcing Rust 1.76.0"},
+		{"Understanding Caches in Distributed Systems", "Understanding Queues in Distributed Systems"},
+	}
+	for _, pair := range different {
+		if d := distance(pair[0], pair[1]); d <= titleSimilarityDistance {
+			t.Errorf("distance(%q, %q) = %d, want > %d", pair[0], pair[1], d, titleSimilarityDistance)
+		}
+	}
+}
+
+func TestCheckForRecentItemsMergesDuplicates(t *testing.T) {
+	now := time.Now()
+	origin := fake.NewFeedServer(t, "Origin Blog")
+	origin.SetItems(
+		fake.Item{Title: "Understanding Caches", Description: "Original post.", Link: origin.URL + "/caches", Published: now},
+		fake.Item{Title: "Unrelated post", Link: origin.URL + "/other", Published: now},
+	)
+	aggregator := fake.NewFeedServer(t, "Aggregator",
+		fake.Item{Title: "Understanding Caches", Link: origin.URL + "/caches/?utm_source=aggregator", Published: now},
+		fake.Item{Title: "Show HN: A tiny database written in Go", Link: "https://github.com/example/tinydb", Published: now},
+	)
+	hn := fake.NewFeedServer(t, "Hacker News",
+		fake.Item{Title: "A tiny database written in Go", Link: "https://example.org/tinydb", Published: now},
+	)
+
+	fs := NewFeedService([]FeedConfig{{URL: aggregator.URL}, {URL: origin.URL}, {URL: hn.URL}}, 10)
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("got %d items, want 3: %+v", len(items), items)
+	}
+
+	// URLが一致する記事は配信元のブログの記事を代表とする
+	caches := items[0]
+	if caches.Feed.Name != "Origin Blog" || caches.Description != "Original post." || len(caches.Sources) != 2 {
+		t.Errorf("merged item = %+v", caches)
+	}
+	if caches.Sources[0].Feed.Name != "Aggregator" || caches.Sources[1].Feed.Name != "Origin Blog" {
+		t.Errorf("Sources = %+v", caches.Sources)
+	}
+
+	// タイトルが類似する記事はURLが異なってもまとめる
+	tinydb := items[1]
+	if len(tinydb.Sources) != 2 || tinydb.Sources[1].Feed.Name != "Hacker News" {
+		t.Errorf("similar title was not merged: %+v", tinydb)
+	}
+	if len(items[2].Sources) != 0 {
+		t.Errorf("unrelated item has sources: %+v", items[2])
+	}
+}
+
+func TestResolveCanonicalURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/amp/post", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/amp/post", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, `<html><head><link rel="canonical" href="/post"></head><body></body></html>`)
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "no html")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fs := NewFeedService(nil, 10, WithURLResolution(true))
+	ctx := context.Background()
+	if got := fs.canonicalURL(ctx, &FeedItem{Link: server.URL + "/short?utm_source=x"}); got != normalizeURL(server.URL+"/post") {
+		t.Errorf("canonicalURL() = %q, want canonical link", got)
+	}
+	if got := fs.canonicalURL(ctx, &FeedItem{Link: server.URL + "/plain"}); got != normalizeURL(server.URL+"/plain") {
+		t.Errorf("canonicalURL() = %q, want final URL", got)
+	}
+
+	// 取得がキャンセルされた場合は記事URLをそのまま使う
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if got := fs.canonicalURL(canceled, &FeedItem{Link: server.URL + "/short"}); got != normalizeURL(server.URL+"/short") {
+		t.Errorf("canonicalURL() with canceled context = %q, want original URL", got)
+	}
+}
+
+func TestResolveCanonicalURLUsesFeedHTTPSettings(t *testing.T) {
+	var mu sync.Mutex
+	tokens := make(map[string]string)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		tokens[r.Host] = r.Header.Get("X-Api-Key")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html></html>`)
+	}
+	private := httptest.NewServer(http.HandlerFunc(handler))
+	defer private.Close()
+	other := httptest.NewServer(http.HandlerFunc(handler))
+	defer other.Close()
+
+	feed := FeedConfig{URL: private.URL + "/feed", HTTP: &FeedHTTPConfig{Headers: map[string]string{"X-Api-Key": "secret"}}}
+	fs := NewFeedService([]FeedConfig{feed}, 10, WithURLResolution(true))
+	ctx := context.Background()
+	fs.canonicalURL(ctx, &FeedItem{Link: private.URL + "/post", Feed: FeedInfo{URL: feed.URL}})
+	fs.canonicalURL(ctx, &FeedItem{Link: other.URL + "/post", Feed: FeedInfo{URL: feed.URL}})
+
+	// フィードのヘッダーは同じホストの記事にのみ送る
+	if got := tokens[strings.TrimPrefix(private.URL, "http://")]; got != "secret" {
+		t.Errorf("header sent to feed host = %q, want secret", got)
+	}
+	if got, ok := tokens[strings.TrimPrefix(other.URL, "http://")]; !ok || got != "" {
+		t.Errorf("header sent to other host = %q (requested %v), want none", got, ok)
+	}
+}
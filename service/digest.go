@@ -0,0 +1,131 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// digestGracePeriod はcronの起動時刻の揺れを吸収するための猶予の上限
+// （日次実行で集計期間が24時間の場合に、数秒の差で1日遅れるのを防ぐ）
+const digestGracePeriod = time.Hour
+
+// DigestStore はダイジェスト配信待ちの記事を通知先ごとに永続化する
+type DigestStore struct {
+	path   string
+	queues map[string]*DigestQueue
+}
+
+// DigestQueue は1つの通知先に対する配信待ちの記事
+type DigestQueue struct {
+	WindowStart time.Time            `json:"window_start"`
+	Results     []*TranslationResult `json:"results"`
+}
+
+// NewDigestStore は状態ファイルを読み込んでDigestStoreを作成する
+func NewDigestStore(path string) (*DigestStore, error) {
+	ds := &DigestStore{
+		path:   path,
+		queues: make(map[string]*DigestQueue),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read digest state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &ds.queues); err != nil {
+		return nil, fmt.Errorf("failed to parse digest state %s: %w", path, err)
+	}
+
+	return ds, nil
+}
+
+// Add は配信待ちの記事を追加する（同じフィードの同じGUIDの記事は重複して追加しない）
+func (ds *DigestStore) Add(destination string, results []*TranslationResult, now time.Time) {
+	if len(results) == 0 {
+		return
+	}
+
+	queue := ds.queue(destination)
+	if queue.WindowStart.IsZero() {
+		queue.WindowStart = now
+	}
+
+	seen := make(map[string]bool)
+	for _, result := range queue.Results {
+		seen[resultKey(result)] = true
+	}
+	for _, result := range results {
+		if seen[resultKey(result)] {
+			continue
+		}
+		seen[resultKey(result)] = true
+		queue.Results = append(queue.Results, result)
+	}
+}
+
+// Due は集計期間が経過し、ダイジェストを配信すべきかを判定する
+// （猶予は集計期間の1/10までとし、短い集計期間で毎回配信されないようにする）
+func (ds *DigestStore) Due(destination string, window time.Duration, now time.Time) bool {
+	queue, ok := ds.queues[destination]
+	if !ok || len(queue.Results) == 0 {
+		return false
+	}
+	grace := min(digestGracePeriod, window/10)
+	return !now.Before(queue.WindowStart.Add(window - grace))
+}
+
+// Pending は配信待ちの記事を返す
+func (ds *DigestStore) Pending(destination string) []*TranslationResult {
+	if queue, ok := ds.queues[destination]; ok {
+		return queue.Results
+	}
+	return nil
+}
+
+// Reset は配信済みとして配信待ちの記事をクリアする
+func (ds *DigestStore) Reset(destination string) {
+	delete(ds.queues, destination)
+}
+
+// Save は状態ファイルに書き込む
+func (ds *DigestStore) Save() error {
+	data, err := json.MarshalIndent(ds.queues, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest state: %w", err)
+	}
+
+	if err := writeFileAtomic(ds.path, data); err != nil {
+		return fmt.Errorf("failed to write digest state: %w", err)
+	}
+	return nil
+}
+
+// queue は通知先のキューを取得する（存在しない場合は作成する）
+func (ds *DigestStore) queue(destination string) *DigestQueue {
+	queue, ok := ds.queues[destination]
+	if !ok {
+		queue = &DigestQueue{}
+		ds.queues[destination] = queue
+	}
+	return queue
+}
+
+// writeFileAtomic は一時ファイル経由でファイルを書き込む（途中で中断しても壊れないように）
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
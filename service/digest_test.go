@@ -0,0 +1,74 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDigestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "digest_state.json")
+	store, err := NewDigestStore(path)
+	if err != nil {
+		t.Fatalf("NewDigestStore() error = %v", err)
+	}
+
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	a := &TranslationResult{Link: "https://example.com/a"}
+	b := &TranslationResult{Link: "https://example.com/b"}
+
+	store.Add("weekly", []*TranslationResult{a}, start)
+	store.Add("weekly", []*TranslationResult{a, b}, start.Add(24*time.Hour))
+	if got := len(store.Pending("weekly")); got != 2 {
+		t.Errorf("Pending() = %d results, want 2 (duplicates skipped)", got)
+	}
+
+	week := 7 * 24 * time.Hour
+	if store.Due("weekly", week, start.Add(6*24*time.Hour)) {
+		t.Error("Due() = true before the window elapsed")
+	}
+	// cronの起動時刻が多少早くても配信する
+	if !store.Due("weekly", week, start.Add(week-time.Minute)) {
+		t.Error("Due() = false within the grace period")
+	}
+
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewDigestStore(path)
+	if err != nil {
+		t.Fatalf("NewDigestStore() error = %v", err)
+	}
+	if got := len(reloaded.Pending("weekly")); got != 2 {
+		t.Errorf("reloaded Pending() = %d results, want 2", got)
+	}
+
+	reloaded.Reset("weekly")
+	if reloaded.Due("weekly", week, start.Add(30*24*time.Hour)) {
+		t.Error("Due() = true after Reset")
+	}
+}
+
+func TestDigestStoreKeysAndGrace(t *testing.T) {
+	store, err := NewDigestStore(filepath.Join(t.TempDir(), "digest_state.json"))
+	if err != nil {
+		t.Fatalf("NewDigestStore() error = %v", err)
+	}
+
+	// リンクのない記事はフィードとGUIDで区別する
+	feed := FeedInfo{URL: "https://example.com/feed"}
+	start := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store.Add("hourly", []*TranslationResult{{GUID: "a", Feed: feed}, {GUID: "b", Feed: feed}, {GUID: "a", Feed: feed}}, start)
+	if got := len(store.Pending("hourly")); got != 2 {
+		t.Errorf("Pending() = %d results, want 2", got)
+	}
+
+	// 短い集計期間では猶予も短くし、毎回配信されないようにする
+	if store.Due("hourly", time.Hour, start) || store.Due("hourly", time.Hour, start.Add(50*time.Minute)) {
+		t.Error("Due() = true before the hourly window elapsed")
+	}
+	if !store.Due("hourly", time.Hour, start.Add(55*time.Minute)) {
+		t.Error("Due() = false within the scaled grace period")
+	}
+}
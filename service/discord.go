@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DiscordNotifier はDiscordのWebhookにEmbed形式のメッセージを送信する
+type DiscordNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// Send はテンプレートが生成したメッセージをそのままDiscordに送信する（スレッドには対応しない）
+func (dn *DiscordNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	status, body, err := postJSON(ctx, dn.httpClient, dn.webhookURL, message.Body)
+	if err != nil {
+		return "", err
+	}
+
+	// 成功時は204（?wait=true を付けた場合は200で送信したメッセージ）を返す
+	if !isSuccess(status) {
+		return "", fmt.Errorf("Discord API error: status=%d, body=%s", status, string(body))
+	}
+	return "", nil
+}
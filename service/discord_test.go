@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestDiscordNotifier(t *testing.T) {
+	server := fake.NewWebhookServer(t, http.StatusNoContent, "")
+	ns, err := NewNotificationService(server.URL, "", FormatBlocks, "", WithNotifierType(NotifierDiscord))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []*TranslationResult
+	for i := 0; i < 12; i++ {
+		results = append(results, &TranslationResult{
+			TranslatedTitle: fmt.Sprintf("記事%d", i),
+			Link:            fmt.Sprintf("https://example.com/%d", i),
+			Feed:            FeedInfo{URL: "https://example.com/feed", Name: "Example"},
+		})
+	}
+	entry, err := ns.DigestEntry(results, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("DigestEntry() error = %v", err)
+	}
+	if err := ns.Deliver(entry); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// スレッドに対応しないため、2ページ目も続けて投稿する
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if !strings.Contains(fmt.Sprint(messages[0]["content"]), "過去1日間 / 12件") || !strings.Contains(fmt.Sprint(messages[1]["content"]), "続き（2/2）") {
+		t.Errorf("digest headers = %v / %v", messages[0]["content"], messages[1]["content"])
+	}
+	if !strings.Contains(fmt.Sprint(messages[0]["embeds"]), "[記事0](https://example.com/0)") {
+		t.Errorf("embeds = %v", messages[0]["embeds"])
+	}
+
+	server.SetFail(true)
+	if err := ns.SendErrorNotification("failed"); err == nil || !strings.Contains(err.Error(), "Discord API error: status=500") {
+		t.Errorf("SendErrorNotification() error = %v, want Discord API error", err)
+	}
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// メールの言語
+const (
+	LanguageJapanese = "ja" // 翻訳したタイトルと要約
+	LanguageEnglish  = "en" // 原文のタイトルと概要
+)
+
+// EmailSettings はSMTPでメールを送信する設定
+type EmailSettings struct {
+	Host       string
+	Port       int
+	Username   string // 空の場合は認証しない
+	Password   string
+	From       string // "RSS通知 <rss@example.com>" のように表示名を含めてもよい
+	Recipients []EmailRecipient
+}
+
+// EmailRecipient はメールの宛先と、受け取る言語（LanguageJapanese or LanguageEnglish）
+type EmailRecipient struct {
+	Address  string
+	Language string
+}
+
+// EmailDigestData はメールのダイジェストのテンプレートに渡すデータ
+type EmailDigestData struct {
+	Groups []DigestGroup
+	Total  int
+	Window string // 集計期間の表示用文字列（言語に合わせる）
+	Now    time.Time
+}
+
+// emailTemplates は1つの言語のメールのテンプレート
+// （テキストのテンプレートでは "subject" という名前で件名を定義する）
+type emailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// EmailNotifier はダイジェストをHTMLとテキストのマルチパートメールとしてSMTPで送信する
+type EmailNotifier struct {
+	settings  EmailSettings
+	from      *mail.Address
+	templates map[string]*emailTemplates
+	now       func() time.Time
+}
+
+// NewEmailNotifier は新しいEmailNotifierを作成する
+// （templateDirを指定すると、同名のテンプレートファイルで組み込みテンプレートを上書きする）
+func NewEmailNotifier(settings EmailSettings, templateDir string, opts ...Option) (*EmailNotifier, error) {
+	o := applyOptions(opts)
+
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", settings.From, err)
+	}
+
+	en := &EmailNotifier{
+		settings:  settings,
+		from:      from,
+		templates: make(map[string]*emailTemplates),
+		now:       o.now,
+	}
+	for _, language := range []string{LanguageJapanese, LanguageEnglish} {
+		textSource, err := readEmailTemplate("digest."+language+".txt.tmpl", templateDir)
+		if err != nil {
+			return nil, err
+		}
+		htmlSource, err := readEmailTemplate("digest."+language+".html.tmpl", templateDir)
+		if err != nil {
+			return nil, err
+		}
+
+		text, err := texttemplate.New("text").Funcs(templateFuncs).Parse(textSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template digest.%s.txt.tmpl: %w", language, err)
+		}
+		if text.Lookup("subject") == nil {
+			return nil, fmt.Errorf("email template digest.%s.txt.tmpl must define \"subject\"", language)
+		}
+		html, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(htmlSource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template digest.%s.html.tmpl: %w", language, err)
+		}
+		en.templates[language] = &emailTemplates{text: text, html: html}
+	}
+
+	return en, nil
+}
+
+// readEmailTemplate は組み込みのメールテンプレートを読み込む（templateDirに同名のファイルがあればそちらを優先する）
+func readEmailTemplate(filename, templateDir string) (string, error) {
+	if templateDir != "" {
+		custom, err := os.ReadFile(filepath.Join(templateDir, filename))
+		if err == nil {
+			return string(custom), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read template %s: %w", filename, err)
+		}
+	}
+
+	text, err := builtinTemplates.ReadFile("templates/email/" + filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read builtin template %s: %w", filename, err)
+	}
+	return string(text), nil
+}
+
+// DigestEntries は記事をフィードごとにまとめたダイジェストを、宛先ごとの言語のメールとして送信箱のメッセージにする
+// （宛先ごとに1件ずつ作成し、ほかの宛先のアドレスは見えないようにする。一部の宛先への送信に失敗しても、
+// 送信できた宛先には再送しない。メールはJSONの文字列としてBodyに、宛先のアドレスはChannelに保存する）
+func (en *EmailNotifier) DigestEntries(results []*TranslationResult, window time.Duration) ([]*OutboxEntry, error) {
+	now := en.now()
+	groups := groupByFeed(results)
+	var entries []*OutboxEntry
+	for i, recipient := range en.settings.Recipients {
+		data := &EmailDigestData{
+			Groups: groups,
+			Total:  len(results),
+			Window: formatWindowIn(recipient.Language, window),
+			Now:    now,
+		}
+		subject, text, html, err := en.render(recipient.Language, data)
+		if err != nil {
+			return nil, err
+		}
+
+		messageID := fmt.Sprintf("<rss-digest.%d.%d@%s>", now.UnixNano(), i, mailDomain(en.from.Address))
+		message, err := buildMultipartEmail(en.from.String(), recipient.Address, subject, messageID, text, html, now)
+		if err != nil {
+			return nil, err
+		}
+		body, err := json.Marshal(string(message))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal digest email: %w", err)
+		}
+
+		entries = append(entries, &OutboxEntry{
+			Kind:     OutboxDigest,
+			Title:    fmt.Sprintf("ダイジェスト（%d件）: %s", len(results), recipient.Address),
+			Articles: len(results),
+			Messages: []*Message{{Channel: recipient.Address, Body: body}},
+		})
+	}
+	return entries, nil
+}
+
+// Deliver は送信箱のメールのうち未送信のものを宛先に送信する
+func (en *EmailNotifier) Deliver(entry *OutboxEntry) (err error) {
+	_, span := startSpan(context.Background(), "EmailNotifier.Deliver",
+		attribute.String("outbox.destination", entry.Destination), attribute.String("outbox.kind", entry.Kind), attribute.Int("outbox.attempts", entry.Attempts))
+	defer func() { endSpan(span, err) }()
+
+	for entry.Sent < len(entry.Messages) {
+		message := entry.Messages[entry.Sent]
+		var data string
+		if err := json.Unmarshal(message.Body, &data); err != nil {
+			return fmt.Errorf("failed to parse digest email: %w", err)
+		}
+		log.Printf("Sending digest email to %s", message.Channel)
+		if err := en.send(message.Channel, []byte(data)); err != nil {
+			return fmt.Errorf("failed to send digest email to %s: %w", message.Channel, err)
+		}
+		entry.Sent++
+	}
+	return nil
+}
+
+// render は言語に応じたテンプレートで件名・テキスト・HTMLを生成する
+func (en *EmailNotifier) render(language string, data *EmailDigestData) (string, string, string, error) {
+	tmpl, ok := en.templates[language]
+	if !ok {
+		return "", "", "", fmt.Errorf("unsupported email language %q", language)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return "", "", "", fmt.Errorf("failed to execute email subject template: %w", err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to execute email text template: %w", err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to execute email HTML template: %w", err)
+	}
+	return strings.TrimSpace(subject.String()), text.String(), html.String(), nil
+}
+
+// send はSMTPサーバーに接続してメールを1通送信する
+// （サーバーがSTARTTLSに対応していれば暗号化し、ユーザー名が設定されていればPLAIN認証する）
+func (en *EmailNotifier) send(to string, message []byte) error {
+	addr := net.JoinHostPort(en.settings.Host, strconv.Itoa(en.settings.Port))
+	conn, err := net.DialTimeout("tcp", addr, defaultHTTPTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(defaultHTTPTimeout))
+
+	client, err := smtp.NewClient(conn, en.settings.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: en.settings.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if en.settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", en.settings.Username, en.settings.Password, en.settings.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(en.from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("RCPT TO rejected: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMultipartEmail はテキストとHTMLを含むmultipart/alternativeのメールを作成する
+func buildMultipartEmail(from, to, subject, messageID, text, html string, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", part.contentType)
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		pw, err := mw.CreatePart(header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create message part: %w", err)
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to encode message part: %w", err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart message: %w", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "Message-ID: %s\r\n", messageID)
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n", mw.Boundary())
+	fmt.Fprintf(&message, "\r\n")
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// mailDomain はメールアドレスのドメインを返す（Message-IDに使う）
+func mailDomain(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
+
+// formatWindowIn は集計期間を言語に合わせた表示用の文字列に変換する
+func formatWindowIn(language string, window time.Duration) string {
+	if language != LanguageEnglish {
+		return formatWindow(window)
+	}
+
+	day := 24 * time.Hour
+	switch {
+	case window == day:
+		return "the last day"
+	case window%day == 0:
+		return fmt.Sprintf("the last %d days", window/day)
+	default:
+		return fmt.Sprintf("the last %s", window)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+// readEmail はメールを解析し、件名とContent-Typeごとの本文を返す
+func readEmail(t *testing.T, data string) (mail.Header, string, map[string]string) {
+	t.Helper()
+
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("failed to decode subject: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", message.Header.Get("Content-Type"))
+	}
+	parts := make(map[string]string)
+	mr := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("NextPart() error = %v", err)
+		}
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[contentType] = string(body)
+	}
+	return message.Header, subject, parts
+}
+
+func TestEmailNotifierSendsDigestPerLanguage(t *testing.T) {
+	server := fake.NewSMTPServer(t)
+	notifier, err := NewEmailNotifier(EmailSettings{
+		Host: server.Host,
+		Port: server.Port,
+		From: "RSS通知 <rss@example.com>",
+		Recipients: []EmailRecipient{
+			{Address: "tanaka@example.com", Language: LanguageJapanese},
+			{Address: "smith@example.com", Language: LanguageEnglish},
+		},
+	}, "")
+	if err != nil {
+		t.Fatalf("NewEmailNotifier() error = %v", err)
+	}
+
+	data := sampleTemplateData()
+	data.Result.OriginalDescription = "An article about <caching> & consistency."
+	degraded := *data.Result
+	degraded.Link = "https://example.com/failed"
+	degraded.SummaryFailed = true
+	degraded.Summary = ""
+	if err := deliverDigest(t, notifier, []*TranslationResult{data.Result, &degraded}, 7*24*time.Hour); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d emails, want one per recipient", len(messages))
+	}
+
+	// 日本語の宛先には翻訳したタイトルと要約を送る
+	ja := messages[0]
+	if ja.From != "rss@example.com" || len(ja.To) != 1 || ja.To[0] != "tanaka@example.com" {
+		t.Errorf("envelope = %s -> %v", ja.From, ja.To)
+	}
+	header, subject, parts := readEmail(t, ja.Data)
+	if subject != "新着記事ダイジェスト（過去7日間 / 2件）" || header.Get("To") != "tanaka@example.com" {
+		t.Errorf("subject = %q, To = %q", subject, header.Get("To"))
+	}
+	if from, err := header.AddressList("From"); err != nil || from[0].Name != "RSS通知" {
+		t.Errorf("From = %q, want the display name to be encoded", header.Get("From"))
+	}
+	text, html := parts["text/plain"], parts["text/html"]
+	if !strings.Contains(text, "・翻訳タイトル（関連度 80）") || !strings.Contains(text, "要約") || !strings.Contains(text, "※ 要約を生成できませんでした") {
+		t.Errorf("Japanese text part = %s", text)
+	}
+	if !strings.Contains(html, `<a href="https://example.com/post?a=1&amp;b=2"`) || !strings.Contains(html, "Title with &#34;quotes&#34; &amp; &lt;tags&gt;") {
+		t.Errorf("Japanese HTML part is not escaped: %s", html)
+	}
+
+	// 英語の宛先には原文のタイトルと概要を送る
+	_, subject, parts = readEmail(t, messages[1].Data)
+	if subject != "New articles digest (the last 7 days / 2 articles)" {
+		t.Errorf("subject = %q", subject)
+	}
+	text, html = parts["text/plain"], parts["text/html"]
+	if !strings.Contains(text, `- Title with "quotes" & <tags>`) || strings.Contains(text, "翻訳タイトル") {
+		t.Errorf("English text part = %s", text)
+	}
+	if !strings.Contains(html, "An article about &lt;caching&gt; &amp; consistency.") || strings.Contains(html, "要約") {
+		t.Errorf("English HTML part = %s", html)
+	}
+}
+
+func TestEmailNotifierRejectedRecipient(t *testing.T) {
+	server := fake.NewSMTPServer(t)
+	server.Reject("gone@example.com")
+	notifier, err := NewEmailNotifier(EmailSettings{
+		Host: server.Host,
+		Port: server.Port,
+		From: "rss@example.com",
+		Recipients: []EmailRecipient{
+			{Address: "gone@example.com", Language: LanguageJapanese},
+			{Address: "tanaka@example.com", Language: LanguageJapanese},
+		},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := notifier.DigestEntries([]*TranslationResult{sampleTemplateData().Result}, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("DigestEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Articles != 1 {
+		t.Fatalf("entries = %+v, want one per recipient", entries)
+	}
+
+	// 一部の宛先に送信できなくても、ほかの宛先には送信する
+	if err := notifier.Deliver(entries[0]); err == nil || !strings.Contains(err.Error(), "gone@example.com") {
+		t.Errorf("Deliver() error = %v, want the rejected recipient", err)
+	}
+	if err := notifier.Deliver(entries[1]); err != nil {
+		t.Errorf("Deliver() error = %v", err)
+	}
+	if messages := server.Messages(); len(messages) != 1 || messages[0].To[0] != "tanaka@example.com" {
+		t.Errorf("messages = %+v, want only the accepted recipient", messages)
+	}
+
+	// 再送では送信できなかった宛先にだけ同じメールを送信する
+	server.Accept("gone@example.com")
+	if err := notifier.Deliver(entries[0]); err != nil {
+		t.Errorf("Deliver() error = %v on retry", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 2 || messages[1].To[0] != "gone@example.com" {
+		t.Errorf("messages = %+v, want the rejected recipient retried once", messages)
+	}
+}
+
+// deliverDigest はダイジェストのメールを作成し、すべての宛先に送信する
+func deliverDigest(t *testing.T, notifier *EmailNotifier, results []*TranslationResult, window time.Duration) error {
+	t.Helper()
+
+	entries, err := notifier.DigestEntries(results, window)
+	if err != nil {
+		t.Fatalf("DigestEntries() error = %v", err)
+	}
+	for _, entry := range entries {
+		if err := notifier.Deliver(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEmailDigestWithoutLink(t *testing.T) {
+	notifier, err := NewEmailNotifier(EmailSettings{
+		Host:       "localhost",
+		From:       "rss@example.com",
+		Recipients: []EmailRecipient{{Address: "tanaka@example.com", Language: LanguageJapanese}},
+	}, "")
+	if err != nil {
+		t.Fatalf("NewEmailNotifier() error = %v", err)
+	}
+
+	result := &TranslationResult{OriginalTitle: "No link", TranslatedTitle: "リンクなし", Feed: FeedInfo{Name: "Example"}}
+	for _, language := range []string{LanguageJapanese, LanguageEnglish} {
+		data := &EmailDigestData{Groups: groupByFeed([]*TranslationResult{result}), Total: 1, Window: formatWindowIn(language, time.Hour)}
+		_, text, html, err := notifier.render(language, data)
+		if err != nil {
+			t.Fatalf("%s: render() error = %v", language, err)
+		}
+		// リンクのない記事は空のリンクや空行を出さずにタイトルだけを表示する
+		if strings.Contains(html, `href=""`) || strings.Contains(text, "\n  \n") {
+			t.Errorf("%s: digest contains an empty link:\n%s\n%s", language, text, html)
+		}
+	}
+}
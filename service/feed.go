@@ -1,110 +1,495 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mmcdole/gofeed"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // FeedService はRSSフィードの監視を管理する
 type FeedService struct {
-	feedURLs           []string
+	feeds              []FeedConfig
 	maxArticlesPerFeed int
-	parser             *gofeed.Parser
+	httpClient         *http.Client
+	baseClient         *http.Client // 計装前のHTTPクライアント（フィードごとのプロキシ設定の元にする）
+	now                func() time.Time
+	resolveURLs        bool // 重複判定で記事ページの canonical URL を使用する
+	state              *FeedStateStore
+	policy             CatchUpPolicy
+	pending            map[string]*FeedState // CommitStateで反映する処理後の状態
+	overflows          []*FeedOverflow       // 直前のチェックで上限を超えたため処理しなかった記事
+	clientsMu          sync.Mutex
+	clients            map[string]*http.Client // プロキシやCA証明書を設定したフィードごとのHTTPクライアント
+	health             *HealthStore
+	healthPolicy       HealthPolicy
+	healthAlerts       []*HealthAlert // 直前のチェックで発生した健全性アラート
+	stats              CheckStats     // 直前のチェックの集計
+	metrics            *Metrics
+}
+
+// CheckStats はCheckForRecentItemsでのフィードの取得結果の集計
+type CheckStats struct {
+	Checked int // 取得を試みたフィード数（無効化中のフィードは含まない）
+	Failed  int // 取得に失敗したフィード数
+	Items   int // 取得できたフィードに含まれていた記事数
+}
+
+// FeedConfig は監視するフィードの設定（表示名などは未指定ならフィードのメタデータから決定する）
+type FeedConfig struct {
+	URL     string
+	Name    string          // 表示名の上書き
+	IconURL string          // アイコン画像URLの上書き
+	Footer  string          // フッター文言の上書き
+	Channel string          // 記事を投稿するチャンネル（空の場合は通知先のチャンネル）
+	Filter  *ItemFilter     // 翻訳前に適用する記事のフィルター（nilの場合はすべて通す）
+	HTTP    *FeedHTTPConfig // 取得時のヘッダー・認証・プロキシなど（nilの場合は既定の設定）
+	Source  Source          // 記事の取得元（nilの場合はURLをRSS/Atom/JSON Feedとして取得する）
+
+	SilentAfter time.Duration // 新しい記事がない場合にアラートするまでの期間の上書き（0の場合はHealthPolicyの値）
+}
+
+// FetchError は取得に失敗したフィードの一覧
+type FetchError struct {
+	Failures []*FeedFailure
+	Checked  int // 取得を試みたフィードの数（無効化中のフィードは含まない）
+}
+
+// FeedFailure は1つのフィードの取得失敗
+type FeedFailure struct {
+	Feed FeedInfo
+	Err  error
+}
+
+// Error は失敗したフィードとエラーの一覧を返す
+func (e *FetchError) Error() string {
+	var failures []string
+	for _, f := range e.Failures {
+		failures = append(failures, fmt.Sprintf("%s: %v", f.Feed.URL, f.Err))
+	}
+	return fmt.Sprintf("failed to fetch %d/%d feeds: %s", len(e.Failures), e.Checked, strings.Join(failures, "; "))
+}
+
+// AllFailed はすべてのフィードの取得に失敗したかを返す
+func (e *FetchError) AllFailed() bool {
+	return len(e.Failures) == e.Checked
+}
+
+// FeedInfo は記事の配信元フィードの情報
+type FeedInfo struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`      // 表示名（上書き > フィードのタイトル > ホスト名）
+	Link     string `json:"link"`      // フィードのサイトURL
+	ImageURL string `json:"image_url"` // アイコン画像（上書き > フィードの画像）
+	Footer   string `json:"footer"`
+	Channel  string `json:"channel,omitempty"` // 記事を投稿するチャンネル（空の場合は通知先のチャンネル）
 }
 
 // FeedItem は処理対象のフィードアイテム
 type FeedItem struct {
 	Title       string
 	Description string
+	Content     string // 本文（Atomの content、RSSの content:encoded、JSON Feedの content_html / content_text）
 	Link        string
 	Published   time.Time
 	GUID        string
-	FeedURL     string // どのフィードからの記事かを識別
+	Categories  []string
+	Author      string
+	ImageURL    string       // 代表画像（item image、media:thumbnail、画像の media:content / enclosure）
+	Media       []FeedMedia  // 添付メディア
+	Feed        FeedInfo     // どのフィードからの記事かを識別
+	Relevance   *Relevance   // 関連度判定の結果（判定していない場合はnil）
+	Sources     []ItemSource // 複数のフィードから届いた記事をまとめた場合の全配信元
+}
+
+// FeedOverflow はフィードごとの上限を超えたため翻訳・通知しなかった記事
+type FeedOverflow struct {
+	Feed  FeedInfo
+	Items []*FeedItem
 }
 
 // NewFeedService は新しいFeedServiceを作成する
-func NewFeedService(feedURLs []string, maxArticlesPerFeed int) *FeedService {
+func NewFeedService(feeds []FeedConfig, maxArticlesPerFeed int, opts ...Option) *FeedService {
+	o := applyOptions(opts)
+
 	return &FeedService{
-		feedURLs:           feedURLs,
+		feeds:              feeds,
 		maxArticlesPerFeed: maxArticlesPerFeed,
-		parser:             gofeed.NewParser(),
+		httpClient:         instrumentHTTPClient(o.httpClient),
+		baseClient:         o.httpClient,
+		now:                o.now,
+		resolveURLs:        o.resolveURLs,
+		state:              o.feedState,
+		policy:             o.catchUpPolicy,
+		clients:            make(map[string]*http.Client),
+		health:             o.feedHealth,
+		healthPolicy:       o.healthPolicy,
+		metrics:            o.metrics,
 	}
 }
 
-// CheckForRecentItems は過去24時間以内の新しいRSSアイテムをチェックする
+// Feeds は設定されたフィードの情報を返す（メタデータ取得前のため、上書き設定とURLから決定する）
+func (fs *FeedService) Feeds() []FeedInfo {
+	var feeds []FeedInfo
+	for _, fc := range fs.feeds {
+		feeds = append(feeds, newFeedInfo(fc, nil))
+	}
+	return feeds
+}
+
+// CheckForRecentItems は前回正常に処理した時点（ウォーターマーク）以降の新しいRSSアイテムをチェックする
+// 状態を保存しない場合（WithFeedStateを指定しない場合）は過去24時間以内の記事を対象とする
+// 取得に失敗したフィードがある場合は *FetchError を返す（取得できたフィードの記事はあわせて返す）
 func (fs *FeedService) CheckForRecentItems() ([]*FeedItem, error) {
-	log.Printf("Checking %d RSS feeds for recent items", len(fs.feedURLs))
-	
-	since := time.Now().Add(-24 * time.Hour)
+	ctx, span := startSpan(context.Background(), "FeedService.CheckForRecentItems", attribute.Int("feed.count", len(fs.feeds)))
+	items, err := fs.checkForRecentItems(ctx)
+	span.SetAttributes(attribute.Int("items.new", len(items)), attribute.Int("feed.failed", fs.stats.Failed))
+	var feedURLs []string
+	for _, fc := range fs.feeds {
+		feedURLs = append(feedURLs, fc.URL)
+	}
+	endSpan(span, redactError(err, feedURLs...))
+	return items, err
+}
+
+// checkForRecentItems はフィードを順に取得し、新しい記事をまとめる
+func (fs *FeedService) checkForRecentItems(ctx context.Context) ([]*FeedItem, error) {
+	log.Printf("Checking %d RSS feeds for recent items", len(fs.feeds))
+
+	fs.pending = make(map[string]*FeedState)
+	fs.overflows = nil
+	fs.healthAlerts = nil
+	fs.stats = CheckStats{}
+	fetchErr := &FetchError{}
 	var allRecentItems []*FeedItem
-	
-	for _, feedURL := range fs.feedURLs {
+
+	for _, fc := range fs.feeds {
+		feedURL := fc.URL
+
+		// 成功と失敗を繰り返したため無効化したフィードは期間が過ぎるまで取得しない
+		if health := fs.health.Get(fc.URL); health.Disabled(fs.now()) {
+			log.Printf("Skipping disabled feed %s (until %s)", feedURL, health.DisabledUntil.Format("2006-01-02 15:04:05"))
+			continue
+		}
 		log.Printf("Checking RSS feed: %s", feedURL)
-		
-		// RSSフィードを取得
-		feed, err := fs.parser.ParseURL(feedURL)
+		fetchErr.Checked++
+
+		// フィード（またはAPI・Webページ）から記事を取得
+		fetchedAt, start := fs.now(), time.Now()
+		fetchCtx, span := startSpan(ctx, "FeedService.fetch", AttrFeedURL.String(redactURL(feedURL)))
+		feed, err := fs.fetchSource(fetchCtx, fc)
+		fs.metrics.ObserveFetch(feedURL, time.Since(start), err)
 		if err != nil {
-			log.Printf("Failed to parse RSS feed %s: %v", feedURL, err)
-			continue // エラーがあっても他のフィードは処理を続ける
+			endSpan(span, redactError(err, feedURL))
+			log.Printf("Failed to fetch feed %s: %v", feedURL, err)
+			fetchErr.Failures = append(fetchErr.Failures, &FeedFailure{Feed: newFeedInfo(fc, nil), Err: err})
+			fs.recordFailure(fc, err, fetchedAt)
+			continue // エラーがあっても他のフィードは処理を続ける（ウォーターマークは進めない）
 		}
+		fs.recordSuccess(fc, feed, fetchedAt)
+		fs.stats.Items += len(feed.Items)
 
 		log.Printf("Found %d items in RSS feed: %s", len(feed.Items), feedURL)
+		recentItems := fs.recentItems(fc, feed, fetchedAt)
 
-		var recentItems []*FeedItem
-		
-		// 各アイテムをチェック（最大件数まで）
-		for i, item := range feed.Items {
-			if i >= fs.maxArticlesPerFeed {
-				log.Printf("Reached max articles limit (%d) for feed: %s", fs.maxArticlesPerFeed, feedURL)
-				break
-			}
+		log.Printf("Found %d new items from feed: %s", len(recentItems), feedURL)
+		fs.metrics.AddItems(feedURL, len(feed.Items), len(recentItems))
+		span.SetAttributes(attribute.Int("items.found", len(feed.Items)), attribute.Int("items.new", len(recentItems)))
+		span.End()
+		allRecentItems = append(allRecentItems, recentItems...)
+	}
+
+	// 異なるフィードから届いた同じ記事をまとめる
+	if len(fs.feeds) > 1 {
+		allRecentItems = fs.mergeDuplicates(ctx, allRecentItems)
+	}
+
+	log.Printf("Total recent items found across all feeds: %d", len(allRecentItems))
+	fs.stats.Checked = fetchErr.Checked
+	fs.stats.Failed = len(fetchErr.Failures)
+	if len(fetchErr.Failures) > 0 {
+		return allRecentItems, fetchErr
+	}
+	return allRecentItems, nil
+}
+
+// fetchSource はフィードごとのHTTP設定で記事の取得元から記事を取得する
+func (fs *FeedService) fetchSource(ctx context.Context, fc FeedConfig) (*SourceFeed, error) {
+	client, err := fs.feedClient(fc)
+	if err != nil {
+		return nil, err
+	}
+
+	// gofeed.Parserは解析中の状態を持つため、管理APIのプレビューとRSSチェックが並行しても共有しないよう取得ごとに作成する
+	source := fc.Source
+	if source == nil {
+		source = &rssSource{parser: gofeed.NewParser()}
+	}
+	return source.Fetch(fc.URL, &SourceClient{ctx: ctx, client: client, config: fc.HTTP})
+}
+
+// feedClient はフィードの取得に使うHTTPクライアントを返す（プロキシやCA証明書の指定がある場合は専用のもの）
+func (fs *FeedService) feedClient(fc FeedConfig) (*http.Client, error) {
+	if !fc.HTTP.needsTransport() {
+		return fs.httpClient, nil
+	}
+	fs.clientsMu.Lock()
+	defer fs.clientsMu.Unlock()
+	if client, ok := fs.clients[fc.URL]; ok {
+		return client, nil
+	}
+
+	client, err := fc.HTTP.newClient(fs.baseClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http settings for feed %s: %w", fc.URL, err)
+	}
+	client = instrumentHTTPClient(client)
+	fs.clients[fc.URL] = client
+	return client, nil
+}
+
+// rssSource はURLをRSS・Atom・JSON Feedとして取得する既定の取得元
+type rssSource struct {
+	parser *gofeed.Parser
+}
+
+// Fetch はフィードを取得して解析し、記事を変換する
+func (s *rssSource) Fetch(feedURL string, client *SourceClient) (*SourceFeed, error) {
+	resp, err := client.Get(feedURL, feedAccept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	feed, err := s.parser.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	result := &SourceFeed{Title: feed.Title, Link: feed.Link}
+	if feed.Image != nil {
+		result.ImageURL = feed.Image.URL
+	}
+	for _, item := range feed.Items {
+		if item != nil {
+			result.Items = append(result.Items, newFeedItem(item))
+		}
+	}
+	return result, nil
+}
+
+// newFeedItem はフィードの記事を変換する（公開日時がない場合は更新日時、どちらもない場合はゼロ値）
+func newFeedItem(item *gofeed.Item) *FeedItem {
+	var published time.Time
+	if item.PublishedParsed != nil {
+		published = *item.PublishedParsed
+	} else if item.UpdatedParsed != nil {
+		published = *item.UpdatedParsed
+	}
+
+	content := cleanText(item.Content)
+	media := itemMedia(item)
+	return &FeedItem{
+		Title:       cleanText(item.Title),
+		Description: itemDescription(item, content),
+		Content:     content,
+		Link:        item.Link,
+		Published:   published,
+		GUID:        item.GUID,
+		Categories:  item.Categories,
+		Author:      itemAuthor(item),
+		ImageURL:    itemImage(item, media),
+		Media:       media,
+	}
+}
+
+// recentItems はフィードの記事のうち未処理のものを古い順に返し、処理後の状態をpendingに記録する
+// 未処理の記事がMaxArticlesPerFeedを超える場合は、超過分を次回に繰り越すか超過通知の対象にする
+func (fs *FeedService) recentItems(fc FeedConfig, feed *SourceFeed, fetchedAt time.Time) []*FeedItem {
+	feedInfo := newFeedInfo(fc, feed)
 
-			if item == nil {
+	// 新着とみなす基準時刻を決める
+	var state *FeedState
+	since := fetchedAt.Add(-24 * time.Hour)
+	firstRun := false
+	if fs.state != nil {
+		state = fs.state.Get(fc.URL)
+		since = fetchedAt.Add(-fs.policy.MaxCatchUp)
+		if state == nil {
+			firstRun = true
+		} else if state.Watermark.After(since) {
+			since = state.Watermark
+		} else {
+			log.Printf("Watermark for %s is older than the max catch-up window, catching up since %s", fc.URL, since.Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	undatedSeen := make(map[string]bool)
+	watermarkSeen := make(map[string]bool)
+	if state != nil {
+		for _, guid := range state.UndatedSeen {
+			undatedSeen[guid] = true
+		}
+		for _, guid := range state.WatermarkSeen {
+			watermarkSeen[guid] = true
+		}
+	}
+	next := &FeedState{}
+
+	// フィードの並び順に依存せず、すべての記事から未処理のものを集める
+	var recentItems []*FeedItem
+	undated := make(map[*FeedItem]bool)
+	handled := make(map[string]time.Time) // 処理済みにする日付のある記事（GUID → 公開日時）
+	for _, feedItem := range feed.Items {
+		// アイテムのユニークIDを生成（GUID or Link）
+		guid := feedItem.GUID
+		if guid == "" {
+			guid = feedItem.Link
+		}
+
+		// 記事の公開日時をチェック
+		publishedTime := feedItem.Published
+
+		isUndated := publishedTime.IsZero()
+		if isUndated {
+			// 日付情報がない記事はGUIDで処理済みかを判定する（初回実行時は処理済みとして扱う）
+			if firstRun || undatedSeen[guid] {
+				next.UndatedSeen = append(next.UndatedSeen, guid)
 				continue
 			}
+			publishedTime = fetchedAt
+		} else if publishedTime.Before(since) {
+			continue
+		} else if watermarkSeen[guid] {
+			// ウォーターマークと同時刻の記事や公開日時が未来の記事は、GUIDで処理済みかを判定する
+			handled[guid] = publishedTime
+			continue
+		}
+
+		feedItem.GUID = guid
+		feedItem.Published = publishedTime
+		feedItem.Feed = feedInfo
 
-			// 記事の公開日時をチェック
-			var publishedTime time.Time
-			if item.PublishedParsed != nil {
-				publishedTime = *item.PublishedParsed
-			} else if item.UpdatedParsed != nil {
-				publishedTime = *item.UpdatedParsed
+		// 翻訳（有料API）の前に対象外の記事を除外する
+		if !fc.Filter.Match(feedItem) {
+			log.Printf("Item filtered out: %s", feedItem.Title)
+			if isUndated {
+				next.UndatedSeen = append(next.UndatedSeen, guid)
 			} else {
-				// 日付情報がない場合は現在時刻を使用（安全側に倒す）
-				publishedTime = time.Now()
+				handled[guid] = publishedTime
 			}
+			continue
+		}
 
-			// 過去24時間以内の記事のみ処理
-			if publishedTime.After(since) {
-				// アイテムのユニークIDを生成（GUID or Link）
-				guid := item.GUID
-				if guid == "" {
-					guid = item.Link
-				}
+		if isUndated {
+			undated[feedItem] = true
+		}
+		recentItems = append(recentItems, feedItem)
+		log.Printf("Recent item found: %s (published: %s)", feedItem.Title, publishedTime.Format("2006-01-02 15:04:05"))
+	}
 
-				feedItem := &FeedItem{
-					Title:       cleanText(item.Title),
-					Description: cleanText(item.Description),
-					Link:        item.Link,
-					Published:   publishedTime,
-					GUID:        guid,
-					FeedURL:     feedURL,
-				}
+	// 公開日時の古い順に並べる（日付のない記事は取得時刻を公開日時とするため最後になる）
+	sort.SliceStable(recentItems, func(i, j int) bool {
+		return recentItems[i].Published.Before(recentItems[j].Published)
+	})
 
-				recentItems = append(recentItems, feedItem)
-				log.Printf("Recent item found: %s (published: %s)", feedItem.Title, publishedTime.Format("2006-01-02 15:04:05"))
+	// 初回実行時は最新の記事のみを通知し、それ以前の記事は処理済みとして扱う
+	if firstRun {
+		if len(recentItems) > fs.policy.FirstRunBackfill {
+			for _, item := range recentItems[:len(recentItems)-fs.policy.FirstRunBackfill] {
+				handled[item.GUID] = item.Published
 			}
+			recentItems = recentItems[len(recentItems)-fs.policy.FirstRunBackfill:]
 		}
+		log.Printf("First run for feed %s: backfilling %d items", fc.URL, len(recentItems))
+	}
 
-		log.Printf("Found %d recent items (within 24h) from feed: %s", len(recentItems), feedURL)
-		allRecentItems = append(allRecentItems, recentItems...)
+	// 上限を超えた分は次回に繰り越すか、超過通知で一覧のみを知らせる
+	var deferred []*FeedItem
+	if limit := fs.maxArticlesPerFeed; len(recentItems) > limit {
+		if fs.policy.Overflow == OverflowNotice || fs.state == nil {
+			// 新しい記事を優先して処理し、古い記事は超過通知の対象にする
+			skipped := recentItems[:len(recentItems)-limit]
+			recentItems = recentItems[len(recentItems)-limit:]
+			fs.overflows = append(fs.overflows, &FeedOverflow{Feed: feedInfo, Items: skipped})
+			for _, item := range skipped {
+				if undated[item] {
+					next.UndatedSeen = append(next.UndatedSeen, item.GUID)
+				} else {
+					handled[item.GUID] = item.Published
+				}
+			}
+			log.Printf("Reached max articles limit (%d) for feed %s: %d older items are listed in an overflow notice", limit, fc.URL, len(skipped))
+		} else {
+			// 古い記事から処理し、残りは処理済み位置を手前に留めて次回に繰り越す
+			deferred = recentItems[limit:]
+			recentItems = recentItems[:limit]
+			log.Printf("Reached max articles limit (%d) for feed %s: %d items are deferred to the next run", limit, fc.URL, len(deferred))
+		}
 	}
 
-	log.Printf("Total recent items found across all feeds: %d", len(allRecentItems))
-	return allRecentItems, nil
+	for _, item := range recentItems {
+		if undated[item] {
+			next.UndatedSeen = append(next.UndatedSeen, item.GUID)
+		} else {
+			handled[item.GUID] = item.Published
+		}
+	}
+
+	// 処理済み位置は処理した記事の最新の公開日時にする（取得後に遅れて現れる記事を取りこぼさないよう取得時刻は使わない）
+	// 公開日時が未来の記事はWatermarkSeenで判定し、処理済み位置を進めない。繰り越した記事がある場合はその公開日時より先に進めない
+	next.Watermark = since
+	for _, published := range handled {
+		if published.After(next.Watermark) && !published.After(fetchedAt) {
+			next.Watermark = published
+		}
+	}
+	for _, item := range deferred {
+		if !undated[item] && item.Published.Before(next.Watermark) {
+			next.Watermark = item.Published
+		}
+	}
+	for guid, published := range handled {
+		if !published.Before(next.Watermark) {
+			next.WatermarkSeen = append(next.WatermarkSeen, guid)
+		}
+	}
+	sort.Strings(next.WatermarkSeen)
+
+	fs.pending[fc.URL] = next
+	return recentItems
+}
+
+// Stats は直前のCheckForRecentItemsでのフィードの取得結果の集計を返す
+func (fs *FeedService) Stats() CheckStats {
+	return fs.stats
+}
+
+// Overflows は直前のCheckForRecentItemsで上限を超えたため処理しなかった記事をフィードごとに返す
+// （OverflowNoticeの場合のみ。OverflowDeferの場合は次回に繰り越すため含まれない）
+func (fs *FeedService) Overflows() []*FeedOverflow {
+	return fs.overflows
+}
+
+// CommitState は直前のCheckForRecentItemsで取得できたフィードのウォーターマークを進めて保存する
+// （通知まで正常に完了した場合に呼び出す。呼び出さなければ次回実行時に同じ記事を再度処理する）
+func (fs *FeedService) CommitState() error {
+	if fs.state == nil {
+		return nil
+	}
+	for feedURL, state := range fs.pending {
+		fs.state.Set(feedURL, state)
+	}
+	fs.pending = nil
+	return fs.state.Save()
 }
 
 // cleanText はテキストから不要な文字を除去する
@@ -138,7 +523,87 @@ func cleanText(text string) string {
 	return strings.Join(cleanLines, "\n")
 }
 
-// GetFeedInfo はフィードの基本情報を取得する（デバッグ用）
-func (fs *FeedService) GetFeedInfo(feedURL string) (*gofeed.Feed, error) {
-	return fs.parser.ParseURL(feedURL)
-}
\ No newline at end of file
+// itemAuthor は記事の著者名を返す（複数の場合はカンマ区切り）
+func itemAuthor(item *gofeed.Item) string {
+	var names []string
+	for _, author := range item.Authors {
+		if author != nil && author.Name != "" {
+			names = append(names, author.Name)
+		}
+	}
+	if len(names) == 0 && item.Author != nil {
+		names = append(names, item.Author.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// newFeedInfo はフィードの設定と取得したメタデータから表示用のフィード情報を作成する
+func newFeedInfo(fc FeedConfig, feed *SourceFeed) FeedInfo {
+	info := FeedInfo{
+		URL:      fc.URL,
+		Name:     fc.Name,
+		ImageURL: fc.IconURL,
+		Footer:   fc.Footer,
+		Channel:  fc.Channel,
+	}
+
+	if feed != nil {
+		if info.Name == "" {
+			info.Name = cleanText(feed.Title)
+		}
+		info.Link = feed.Link
+		if info.ImageURL == "" {
+			info.ImageURL = feed.ImageURL
+		}
+	}
+
+	// タイトルがない場合はホスト名を表示名とする
+	if info.Name == "" {
+		info.Name = fc.URL
+		if u, err := url.Parse(fc.URL); err == nil && u.Host != "" {
+			info.Name = u.Host
+		}
+	}
+	if info.Footer == "" {
+		info.Footer = fmt.Sprintf("%s RSS通知", info.Name)
+	}
+
+	return info
+}
+
+// GetFeedInfo はフィードの基本情報を取得する（デバッグ用。設定済みのフィードの場合はそのHTTP設定を使う）
+func (fs *FeedService) GetFeedInfo(feedURL string) (*SourceFeed, error) {
+	return fs.fetchSource(context.Background(), fs.feedConfig(feedURL))
+}
+
+// FetchFeedInfo はフィードを取得し、通知に表示するフィードの情報を返す
+// （取得に失敗した場合は設定のみから作成した情報とエラーを返す）
+func (fs *FeedService) FetchFeedInfo(feedURL string) (FeedInfo, error) {
+	fc := fs.feedConfig(feedURL)
+	feed, err := fs.fetchSource(context.Background(), fc)
+	if err != nil {
+		return newFeedInfo(fc, nil), err
+	}
+	return newFeedInfo(fc, feed), nil
+}
+
+// FindItem はフィードを取得し、リンクが一致する記事を返す（見つからない場合はnil）
+// 新着として処理する記事と同様に配信元フィードの情報とGUIDを設定する
+func (fs *FeedService) FindItem(feedURL, link string) (*FeedItem, error) {
+	fc := fs.feedConfig(feedURL)
+	feed, err := fs.fetchSource(context.Background(), fc)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range feed.Items {
+		if item.Link != link {
+			continue
+		}
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+		item.Feed = newFeedInfo(fc, feed)
+		return item, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Hello, World", "Hello, World"},
+		{"br tags", "line1<br>line2<br/>line3<br />line4", "line1\nline2\nline3\nline4"},
+		{"html tags", "<p>Hello <b>World</b></p>", "Hello World"},
+		{"blank lines", "  a  \n\n   \n b ", "a\nb"},
+		{"unclosed tag", "a < b", "a < b"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanText(tt.in); got != tt.want {
+				t.Errorf("cleanText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFeedInfo(t *testing.T) {
+	feedServer := fake.NewFeedServer(t, "Example Blog")
+	fs := NewFeedService(nil, 10)
+	feed, err := fs.GetFeedInfo(feedServer.URL)
+	if err != nil {
+		t.Fatalf("GetFeedInfo() error = %v", err)
+	}
+
+	info := newFeedInfo(FeedConfig{URL: feedServer.URL}, feed)
+	if info.Name != "Example Blog" || info.Footer != "Example Blog RSS通知" {
+		t.Errorf("newFeedInfo() = %+v, want name and footer from feed title", info)
+	}
+
+	info = newFeedInfo(FeedConfig{URL: feedServer.URL, Name: "Override", IconURL: "https://example.com/icon.png", Footer: "custom"}, feed)
+	if info.Name != "Override" || info.ImageURL != "https://example.com/icon.png" || info.Footer != "custom" {
+		t.Errorf("newFeedInfo() = %+v, want overrides applied", info)
+	}
+
+	info = newFeedInfo(FeedConfig{URL: "https://example.com/feed.xml"}, nil)
+	if info.Name != "example.com" {
+		t.Errorf("newFeedInfo() name = %q, want host name", info.Name)
+	}
+}
+
+func TestCheckForRecentItems(t *testing.T) {
+	now := time.Now()
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "New <b>post</b>", Description: "<p>Body</p>", Link: "https://example.com/new", GUID: "new", Published: now.Add(-time.Hour)},
+		fake.Item{Title: "Old post", Link: "https://example.com/old", GUID: "old", Published: now.Add(-48 * time.Hour)},
+		fake.Item{Title: "No GUID", Link: "https://example.com/no-guid", Published: now.Add(-2 * time.Hour)},
+	)
+
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 10)
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	// 公開日時の古い順に並ぶ
+	if items[1].Title != "New post" || items[1].Description != "Body" {
+		t.Errorf("item was not cleaned: %+v", items[1])
+	}
+	if items[0].GUID != "https://example.com/no-guid" {
+		t.Errorf("GUID = %q, want link as fallback", items[0].GUID)
+	}
+	if items[0].Feed.Name != "Example Blog" || items[0].Feed.URL != feedServer.URL {
+		t.Errorf("Feed = %+v, want feed metadata", items[0].Feed)
+	}
+}
+
+func TestCheckForRecentItemsMaxArticles(t *testing.T) {
+	now := time.Now()
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "1", Link: "https://example.com/1", Published: now},
+		fake.Item{Title: "2", Link: "https://example.com/2", Published: now},
+		fake.Item{Title: "3", Link: "https://example.com/3", Published: now},
+	)
+
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 2)
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("got %d items, want 2", len(items))
+	}
+}
+
+func TestCheckForRecentItemsFilter(t *testing.T) {
+	now := time.Now()
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "Go 1.22 released", Link: "https://example.com/1", Published: now, Categories: []string{"Go", "Release"}, Author: "Alice"},
+		fake.Item{Title: "Rust news", Link: "https://example.com/2", Published: now, Categories: []string{"Rust"}, Author: "Bob"},
+		fake.Item{Title: "Go sponsored post", Link: "https://example.com/3", Published: now, Categories: []string{"Go"}, Author: "Alice"},
+	)
+
+	filter, err := NewItemFilter(
+		&FilterRule{Field: FilterFieldCategory, Contains: "go"},
+		&FilterRule{Field: FilterFieldTitle, Contains: "sponsored"},
+	)
+	if err != nil {
+		t.Fatalf("NewItemFilter() error = %v", err)
+	}
+
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL, Filter: filter}}, 10)
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	if len(items) != 1 || items[0].Title != "Go 1.22 released" {
+		t.Fatalf("got %+v, want only the Go release post", items)
+	}
+	if items[0].Author != "Alice" || len(items[0].Categories) != 2 {
+		t.Errorf("author/categories = %q/%v", items[0].Author, items[0].Categories)
+	}
+}
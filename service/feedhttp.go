@@ -0,0 +1,107 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// defaultFeedUserAgent はUser-Agentを指定しない場合にフィードの取得で使用する値
+const defaultFeedUserAgent = "Gofeed/1.0"
+
+// feedAccept はフィードの取得時に送るAcceptヘッダー（JSON Feedを返すサーバーにも対応する）
+const feedAccept = "application/rss+xml, application/atom+xml, application/feed+json, application/json;q=0.9, application/xml;q=0.9, */*;q=0.8"
+
+// FeedHTTPConfig はフィードの取得に使うHTTP設定（認証が必要なフィードやプロキシ経由のアクセス用）
+type FeedHTTPConfig struct {
+	Headers     map[string]string // 追加のリクエストヘッダー
+	UserAgent   string
+	Username    string // Basic認証
+	Password    string
+	BearerToken string            // Authorization: Bearer（Basic認証より優先）
+	Cookies     map[string]string // Cookieヘッダーとして送る値
+	ProxyURL    string            // 未指定の場合は環境変数（HTTPS_PROXYなど）に従う
+	CAFile      string            // 追加で信頼するCA証明書（PEM）
+}
+
+// apply はリクエストにヘッダー・認証情報・Cookieを設定する（acceptはHeadersで上書きできる）
+func (c *FeedHTTPConfig) apply(req *http.Request, accept string) {
+	req.Header.Set("User-Agent", defaultFeedUserAgent)
+	req.Header.Set("Accept", accept)
+	if c == nil {
+		return
+	}
+
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	for name, value := range c.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.BearerToken)
+	} else if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	for name, value := range c.Cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+}
+
+// needsTransport はプロキシやCA証明書のために専用のTransportが必要かを返す
+func (c *FeedHTTPConfig) needsTransport() bool {
+	return c != nil && (c.ProxyURL != "" || c.CAFile != "")
+}
+
+// newClient はプロキシやCA証明書を設定したHTTPクライアントを作成する（baseのタイムアウトを引き継ぐ）
+func (c *FeedHTTPConfig) newClient(base *http.Client) (*http.Client, error) {
+	if !c.needsTransport() {
+		return base, nil
+	}
+
+	var transport *http.Transport
+	switch t := base.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		// 通信の記録・再生など独自のTransportを使っている場合はそちらを優先する
+		log.Printf("Warning: proxy and CA settings are ignored because a custom HTTP transport is in use")
+		return base, nil
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", c.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	client := *base
+	client.Transport = transport
+	return &client, nil
+}
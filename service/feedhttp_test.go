@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const minimalRSS = `<?xml version="1.0"?><rss version="2.0"><channel><title>Private Feed</title><link>https://example.com</link></channel></rss>`
+
+func TestFetchFeedWithHTTPConfig(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(minimalRSS))
+	}))
+	defer server.Close()
+
+	fc := FeedConfig{URL: server.URL, HTTP: &FeedHTTPConfig{
+		Headers:     map[string]string{"X-Team": "backend"},
+		UserAgent:   "my-agent/1.0",
+		Username:    "ignored",
+		BearerToken: "secret-token",
+		Cookies:     map[string]string{"session": "abc"},
+	}}
+	fs := NewFeedService([]FeedConfig{fc}, 10)
+
+	feed, err := fs.fetchSource(context.Background(), fc)
+	if err != nil {
+		t.Fatalf("fetchSource() error = %v", err)
+	}
+	if feed.Title != "Private Feed" {
+		t.Errorf("Title = %q", feed.Title)
+	}
+	if got.UserAgent() != "my-agent/1.0" || got.Header.Get("X-Team") != "backend" {
+		t.Errorf("headers = %v", got.Header)
+	}
+	if cookie, err := got.Cookie("session"); err != nil || cookie.Value != "abc" {
+		t.Errorf("session cookie = %v, %v", cookie, err)
+	}
+
+	// 認証情報がない場合はHTTPエラーになる
+	if _, err := fs.fetchSource(context.Background(), FeedConfig{URL: server.URL}); err == nil {
+		t.Error("fetchSource() without token error = nil, want error")
+	}
+	if got.UserAgent() != defaultFeedUserAgent {
+		t.Errorf("default User-Agent = %q", got.UserAgent())
+	}
+}
+
+func TestFetchFeedBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "alice" || pass != "p@ss" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(minimalRSS))
+	}))
+	defer server.Close()
+
+	fc := FeedConfig{URL: server.URL, HTTP: &FeedHTTPConfig{Username: "alice", Password: "p@ss"}}
+	if _, err := NewFeedService([]FeedConfig{fc}, 10).fetchSource(context.Background(), fc); err != nil {
+		t.Errorf("fetchSource() error = %v", err)
+	}
+}
+
+func TestFetchFeedWithCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(minimalRSS))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 自己署名証明書のサーバーはCAを指定しない場合は取得できない
+	if _, err := NewFeedService(nil, 10).fetchSource(context.Background(), FeedConfig{URL: server.URL}); err == nil {
+		t.Fatal("fetchSource() without CA error = nil, want certificate error")
+	}
+
+	fc := FeedConfig{URL: server.URL, HTTP: &FeedHTTPConfig{CAFile: caFile}}
+	if _, err := NewFeedService([]FeedConfig{fc}, 10).fetchSource(context.Background(), fc); err != nil {
+		t.Errorf("fetchSource() with CA error = %v", err)
+	}
+
+	invalid := FeedConfig{URL: server.URL, HTTP: &FeedHTTPConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}}
+	if _, err := NewFeedService(nil, 10).fetchSource(context.Background(), invalid); err == nil {
+		t.Error("fetchSource() with missing CA file error = nil, want error")
+	}
+}
+
+func TestFetchFeedViaProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// プロキシには絶対URLでリクエストが届く
+		proxied = r.URL.String()
+		w.Write([]byte(minimalRSS))
+	}))
+	defer proxy.Close()
+
+	fc := FeedConfig{URL: "http://feed.internal.test/rss", HTTP: &FeedHTTPConfig{ProxyURL: proxy.URL}}
+	if _, err := NewFeedService([]FeedConfig{fc}, 10).fetchSource(context.Background(), fc); err != nil {
+		t.Fatalf("fetchSource() error = %v", err)
+	}
+	if proxied != fc.URL {
+		t.Errorf("proxied request = %q, want %q", proxied, fc.URL)
+	}
+}
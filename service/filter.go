@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// フィルター条件の対象フィールド
+const (
+	FilterFieldTitle       = "title"
+	FilterFieldDescription = "description"
+	FilterFieldCategory    = "category"
+	FilterFieldAuthor      = "author"
+)
+
+// FilterRule は記事を絞り込む条件
+// All・Any・Notで条件を組み合わせ、末端の条件はFieldに対するContains（大文字小文字を区別しない部分一致）またはRegexで判定する
+type FilterRule struct {
+	All []FilterRule // すべての条件に一致
+	Any []FilterRule // いずれかの条件に一致
+	Not *FilterRule  // 条件に一致しない
+
+	Field    string // title, description, category, author（未指定の場合はタイトルと本文）
+	Contains string
+	Regex    string
+}
+
+// ItemFilter はフィードごとの記事フィルター
+// Includeに一致し（未指定なら全件）、かつExcludeに一致しない記事のみを通す
+type ItemFilter struct {
+	include *compiledRule
+	exclude *compiledRule
+}
+
+// compiledRule は正規表現をコンパイル済みのFilterRule
+type compiledRule struct {
+	all      []*compiledRule
+	any      []*compiledRule
+	not      *compiledRule
+	field    string
+	contains string
+	regex    *regexp.Regexp
+}
+
+// NewItemFilter はinclude・excludeの条件からフィルターを作成する（どちらも未指定の場合はnilを返す）
+func NewItemFilter(include, exclude *FilterRule) (*ItemFilter, error) {
+	if include == nil && exclude == nil {
+		return nil, nil
+	}
+
+	f := &ItemFilter{}
+	var err error
+	if include != nil {
+		if f.include, err = compileRule(include); err != nil {
+			return nil, fmt.Errorf("invalid include filter: %w", err)
+		}
+	}
+	if exclude != nil {
+		if f.exclude, err = compileRule(exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude filter: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// Match は記事がフィルターを通過するかを判定する（nilのフィルターはすべて通す）
+func (f *ItemFilter) Match(item *FeedItem) bool {
+	if f == nil {
+		return true
+	}
+	if f.include != nil && !f.include.match(item) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.match(item) {
+		return false
+	}
+	return true
+}
+
+// compileRule は条件を検証し、正規表現をコンパイルする
+func compileRule(rule *FilterRule) (*compiledRule, error) {
+	c := &compiledRule{
+		field:    rule.Field,
+		contains: strings.ToLower(rule.Contains),
+	}
+
+	switch rule.Field {
+	case "", FilterFieldTitle, FilterFieldDescription, FilterFieldCategory, FilterFieldAuthor:
+	default:
+		return nil, fmt.Errorf("unknown field %q (title, description, category, author)", rule.Field)
+	}
+
+	if rule.Regex != "" {
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", rule.Regex, err)
+		}
+		c.regex = re
+	}
+
+	for i := range rule.All {
+		sub, err := compileRule(&rule.All[i])
+		if err != nil {
+			return nil, err
+		}
+		c.all = append(c.all, sub)
+	}
+	for i := range rule.Any {
+		sub, err := compileRule(&rule.Any[i])
+		if err != nil {
+			return nil, err
+		}
+		c.any = append(c.any, sub)
+	}
+	if rule.Not != nil {
+		sub, err := compileRule(rule.Not)
+		if err != nil {
+			return nil, err
+		}
+		c.not = sub
+	}
+
+	if c.all == nil && c.any == nil && c.not == nil && c.contains == "" && c.regex == nil {
+		return nil, fmt.Errorf("empty filter rule")
+	}
+	return c, nil
+}
+
+// match は条件に一致するかを判定する（指定された条件はすべて満たす必要がある）
+func (c *compiledRule) match(item *FeedItem) bool {
+	for _, sub := range c.all {
+		if !sub.match(item) {
+			return false
+		}
+	}
+	if c.any != nil {
+		matched := false
+		for _, sub := range c.any {
+			if sub.match(item) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if c.not != nil && c.not.match(item) {
+		return false
+	}
+
+	if c.contains == "" && c.regex == nil {
+		return true
+	}
+	for _, value := range c.values(item) {
+		if c.contains != "" && !strings.Contains(strings.ToLower(value), c.contains) {
+			continue
+		}
+		if c.regex != nil && !c.regex.MatchString(value) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// values は判定対象のフィールドの値を返す（カテゴリーは1件ずつ判定する）
+func (c *compiledRule) values(item *FeedItem) []string {
+	switch c.field {
+	case FilterFieldTitle:
+		return []string{item.Title}
+	case FilterFieldDescription:
+		return []string{item.Description}
+	case FilterFieldCategory:
+		return item.Categories
+	case FilterFieldAuthor:
+		return []string{item.Author}
+	default:
+		return []string{item.Title, item.Description}
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import "testing"
+
+func TestItemFilter(t *testing.T) {
+	item := &FeedItem{
+		Title:       "Announcing Go 1.22",
+		Description: "Range over integers and loop variable changes.",
+		Categories:  []string{"Go", "Release"},
+		Author:      "The Go Team",
+	}
+
+	tests := []struct {
+		name    string
+		include *FilterRule
+		exclude *FilterRule
+		want    bool
+	}{
+		{"no rules", nil, nil, true},
+		{"title contains (case-insensitive)", &FilterRule{Field: FilterFieldTitle, Contains: "go 1.22"}, nil, true},
+		{"title and description by default", &FilterRule{Contains: "loop variable"}, nil, true},
+		{"category matches one of them", &FilterRule{Field: FilterFieldCategory, Contains: "release"}, nil, true},
+		{"category does not match", &FilterRule{Field: FilterFieldCategory, Contains: "rust"}, nil, false},
+		{"author regex", &FilterRule{Field: FilterFieldAuthor, Regex: `^The .* Team$`}, nil, true},
+		{"exclude wins", &FilterRule{Contains: "go"}, &FilterRule{Field: FilterFieldCategory, Contains: "release"}, false},
+		{"all", &FilterRule{All: []FilterRule{{Contains: "go"}, {Field: FilterFieldCategory, Contains: "rust"}}}, nil, false},
+		{"any", &FilterRule{Any: []FilterRule{{Contains: "python"}, {Field: FilterFieldCategory, Contains: "go"}}}, nil, true},
+		{"not", &FilterRule{Not: &FilterRule{Field: FilterFieldAuthor, Contains: "team"}}, nil, false},
+		{"nested", &FilterRule{All: []FilterRule{
+			{Any: []FilterRule{{Field: FilterFieldTitle, Regex: `(?i)\bgo\b`}, {Field: FilterFieldTitle, Contains: "golang"}}},
+			{Not: &FilterRule{Field: FilterFieldTitle, Contains: "sponsored"}},
+		}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewItemFilter(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("NewItemFilter() error = %v", err)
+			}
+			if got := filter.Match(item); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewItemFilterInvalid(t *testing.T) {
+	invalid := []*FilterRule{
+		{Field: "body", Contains: "go"},
+		{Regex: "("},
+		{},
+		{Any: []FilterRule{{Field: FilterFieldTitle}}},
+	}
+	for _, rule := range invalid {
+		if _, err := NewItemFilter(rule, nil); err == nil {
+			t.Errorf("NewItemFilter(%+v) expected error", rule)
+		}
+	}
+}
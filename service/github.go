@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHubReleasesSource はGitHub Releases API（/repos/{owner}/{repo}/releases）から記事を取得する
+// （プライベートリポジトリやレート制限の緩和にはFeedHTTPConfigのBearerTokenを指定する）
+type GitHubReleasesSource struct {
+	IncludePrereleases bool // プレリリースも対象にする
+}
+
+// githubRelease はReleases APIのレスポンスのうち使用する項目
+type githubRelease struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	TagName     string    `json:"tag_name"`
+	HTMLURL     string    `json:"html_url"`
+	Body        string    `json:"body"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Author      struct {
+		Login string `json:"login"`
+	} `json:"author"`
+}
+
+// Fetch はリリースの一覧を取得する
+func (s *GitHubReleasesSource) Fetch(apiURL string, client *SourceClient) (*SourceFeed, error) {
+	var releases []githubRelease
+	if err := client.getJSON(apiURL, "application/vnd.github+json", &releases); err != nil {
+		return nil, err
+	}
+
+	repo := githubRepo(apiURL)
+	feed := &SourceFeed{Title: repo + " Releases"}
+	if owner, _, ok := strings.Cut(repo, "/"); ok {
+		feed.Link = "https://github.com/" + repo + "/releases"
+		feed.ImageURL = "https://github.com/" + owner + ".png"
+	}
+
+	for _, release := range releases {
+		if release.Draft || (release.Prerelease && !s.IncludePrereleases) {
+			continue
+		}
+
+		name := release.Name
+		if name == "" {
+			name = release.TagName
+		}
+		body := cleanText(release.Body)
+		item := &FeedItem{
+			Title:       fmt.Sprintf("%s %s", repo, name),
+			Description: truncateText(body, maxContentDescriptionLength),
+			Content:     body,
+			Link:        release.HTMLURL,
+			Published:   release.PublishedAt,
+			GUID:        strconv.FormatInt(release.ID, 10),
+			Author:      release.Author.Login,
+		}
+		if release.Prerelease {
+			item.Categories = []string{"prerelease"}
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// githubRepo はReleases APIのURLから "owner/repo" を取り出す（取り出せない場合はURLのパス）
+func githubRepo(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return apiURL
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+2 < len(parts); i++ {
+		if parts[i] == "repos" {
+			return parts[i+1] + "/" + parts[i+2]
+		}
+	}
+	return u.Path
+}
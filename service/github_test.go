@@ -0,0 +1,49 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitHubReleasesSource(t *testing.T) {
+	var accept, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept, auth = r.Header.Get("Accept"), r.Header.Get("Authorization")
+		w.Write([]byte(`[
+  {"id": 3, "name": "", "tag_name": "v2.0.0-rc1", "html_url": "https://github.com/acme/widget/releases/tag/v2.0.0-rc1", "prerelease": true, "published_at": "2024-01-15T08:00:00Z"},
+  {"id": 2, "name": "Widget 1.1", "tag_name": "v1.1.0", "html_url": "https://github.com/acme/widget/releases/tag/v1.1.0", "body": "## Changes\r\n- Faster", "published_at": "2024-01-14T08:00:00Z", "author": {"login": "octocat"}},
+  {"id": 1, "name": "draft", "tag_name": "v1.2.0", "draft": true}
+]`))
+	}))
+	defer server.Close()
+
+	client := &SourceClient{client: http.DefaultClient, config: &FeedHTTPConfig{BearerToken: "ghp_token"}}
+	feed, err := (&GitHubReleasesSource{}).Fetch(server.URL+"/repos/acme/widget/releases", client)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if accept != "application/vnd.github+json" || auth != "Bearer ghp_token" {
+		t.Errorf("Accept = %q, Authorization = %q", accept, auth)
+	}
+	if feed.Title != "acme/widget Releases" || feed.Link != "https://github.com/acme/widget/releases" {
+		t.Errorf("feed = %+v", feed)
+	}
+	// 下書きとプレリリースは除外する
+	if len(feed.Items) != 1 {
+		t.Fatalf("got %d items, want 1", len(feed.Items))
+	}
+	item := feed.Items[0]
+	if item.Title != "acme/widget Widget 1.1" || item.GUID != "2" || item.Author != "octocat" || item.Description != "## Changes\n- Faster" || item.Published.IsZero() {
+		t.Errorf("item = %+v", item)
+	}
+
+	feed, err = (&GitHubReleasesSource{IncludePrereleases: true}).Fetch(server.URL+"/repos/acme/widget/releases", client)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(feed.Items) != 2 || feed.Items[0].Title != "acme/widget v2.0.0-rc1" || len(feed.Items[0].Categories) != 1 {
+		t.Errorf("items with prereleases = %+v", feed.Items)
+	}
+}
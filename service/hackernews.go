@@ -0,0 +1,81 @@
+package service
+
+import (
+	"strings"
+	"time"
+)
+
+// hackerNewsItemURL はHacker Newsの記事ページ（コメント欄）のURL
+const hackerNewsItemURL = "https://news.ycombinator.com/item?id="
+
+// HackerNewsSource はHacker News Algolia API（/api/v1/search など）の検索結果から記事を取得する
+// （例: https://hn.algolia.com/api/v1/search?tags=front_page）
+type HackerNewsSource struct {
+	MinPoints int // このポイント未満の記事は対象にしない
+}
+
+// hackerNewsResponse はAlgolia APIのレスポンスのうち使用する項目
+type hackerNewsResponse struct {
+	Hits []struct {
+		ObjectID    string   `json:"objectID"`
+		Title       string   `json:"title"`
+		URL         string   `json:"url"`
+		Author      string   `json:"author"`
+		Points      int      `json:"points"`
+		StoryText   string   `json:"story_text"`
+		CreatedAtI  int64    `json:"created_at_i"`
+		NumComments int      `json:"num_comments"`
+		Tags        []string `json:"_tags"`
+	} `json:"hits"`
+}
+
+// Fetch は検索結果の記事を取得する（リンク先のない Ask HN などはコメント欄を記事のURLとする）
+func (s *HackerNewsSource) Fetch(apiURL string, client *SourceClient) (*SourceFeed, error) {
+	var resp hackerNewsResponse
+	if err := client.getJSON(apiURL, "application/json", &resp); err != nil {
+		return nil, err
+	}
+
+	feed := &SourceFeed{
+		Title:    "Hacker News",
+		Link:     "https://news.ycombinator.com/",
+		ImageURL: "https://news.ycombinator.com/y18.svg",
+	}
+	for _, hit := range resp.Hits {
+		if hit.Title == "" || hit.Points < s.MinPoints {
+			continue
+		}
+
+		link := hit.URL
+		if link == "" {
+			link = hackerNewsItemURL + hit.ObjectID
+		}
+		text := cleanText(hit.StoryText)
+		item := &FeedItem{
+			Title:       cleanText(hit.Title),
+			Description: truncateText(text, maxContentDescriptionLength),
+			Content:     text,
+			Link:        link,
+			GUID:        hackerNewsItemURL + hit.ObjectID,
+			Author:      hit.Author,
+			Categories:  hackerNewsCategories(hit.Tags),
+		}
+		if hit.CreatedAtI > 0 {
+			item.Published = time.Unix(hit.CreatedAtI, 0).UTC()
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// hackerNewsCategories はAlgoliaのタグのうち記事の種類を表すもの（front_page, show_hn, ask_hn など）を返す
+func hackerNewsCategories(tags []string) []string {
+	var categories []string
+	for _, tag := range tags {
+		if tag == "story" || strings.HasPrefix(tag, "author_") || strings.HasPrefix(tag, "story_") {
+			continue
+		}
+		categories = append(categories, tag)
+	}
+	return categories
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHackerNewsSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"hits": [
+  {"objectID": "101", "title": "Understanding Caches", "url": "https://example.com/caches", "author": "pg", "points": 250, "created_at_i": 1705305600, "_tags": ["story", "author_pg", "story_101", "front_page"]},
+  {"objectID": "102", "title": "Ask HN: How do you review PRs?", "author": "dang", "points": 120, "story_text": "<p>Curious about your process.</p>", "created_at_i": 1705302000, "_tags": ["story", "ask_hn"]},
+  {"objectID": "103", "title": "Low scoring post", "url": "https://example.com/low", "points": 3, "created_at_i": 1705302000}
+]}`))
+	}))
+	defer server.Close()
+
+	client := &SourceClient{client: http.DefaultClient}
+	feed, err := (&HackerNewsSource{MinPoints: 100}).Fetch(server.URL+"/api/v1/search?tags=front_page", client)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if feed.Title != "Hacker News" || len(feed.Items) != 2 {
+		t.Fatalf("feed = %+v, want 2 items", feed)
+	}
+	story := feed.Items[0]
+	if story.Link != "https://example.com/caches" || story.GUID != "https://news.ycombinator.com/item?id=101" || story.Published.Unix() != 1705305600 {
+		t.Errorf("story = %+v", story)
+	}
+	if !reflect.DeepEqual(story.Categories, []string{"front_page"}) {
+		t.Errorf("Categories = %v", story.Categories)
+	}
+	// リンク先のない投稿はコメント欄を記事のURLにする
+	ask := feed.Items[1]
+	if ask.Link != "https://news.ycombinator.com/item?id=102" || ask.Description != "Curious about your process." {
+		t.Errorf("ask = %+v", ask)
+	}
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// フィードの健全性アラートの種類
+const (
+	HealthBroken    = "broken"    // 取得に連続して失敗している
+	HealthSilent    = "silent"    // 一定期間新しい記事が公開されていない
+	HealthDisabled  = "disabled"  // 取得の成功と失敗を繰り返すため一時的に無効化した
+	HealthRecovered = "recovered" // 取得に失敗していたフィードが復旧した
+)
+
+const (
+	// healthHistorySize は無効化の判定に使う直近の取得結果の件数
+	healthHistorySize = 20
+	// velocityWindow は1日あたりの記事数を計算する期間
+	velocityWindow = 30 * 24 * time.Hour
+)
+
+// HealthPolicy はフィードの健全性の判定とアラートの設定
+type HealthPolicy struct {
+	FailureThreshold int           // 取得の連続失敗がこの回数に達したらアラートする（0の場合はアラートしない）
+	SilentAfter      time.Duration // 最新記事の公開からこの期間が経過したらアラートする（0の場合はアラートしない）
+	FlapThreshold    int           // 直近の取得結果で成功と失敗がこの回数入れ替わったら無効化する（0の場合は無効化しない）
+	DisableFor       time.Duration // 無効化する期間
+}
+
+// FeedHealth は1つのフィードの健全性
+type FeedHealth struct {
+	LastSuccess         time.Time `json:"last_success"`
+	LastFailure         time.Time `json:"last_failure"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastItemAt          time.Time `json:"last_item_at"`  // 最新記事の公開日時
+	ItemsPerDay         float64   `json:"items_per_day"` // 直近30日に公開された記事の1日あたりの件数
+	// History は直近の取得結果（o: 成功、x: 失敗。新しいものが末尾）
+	History       string    `json:"history,omitempty"`
+	DisabledUntil time.Time `json:"disabled_until"`           // この時刻まで取得しない
+	BrokenAlerted bool      `json:"broken_alerted,omitempty"` // 取得失敗のアラートを送信済み
+	SilentAlerted bool      `json:"silent_alerted,omitempty"` // 更新停止のアラートを送信済み
+}
+
+// Disabled は指定した時刻にフィードが無効化されているかを返す
+func (h *FeedHealth) Disabled(now time.Time) bool {
+	return h != nil && now.Before(h.DisabledUntil)
+}
+
+// flaps は直近の取得結果で成功と失敗が入れ替わった回数を返す
+func (h *FeedHealth) flaps() int {
+	n := 0
+	for i := 1; i < len(h.History); i++ {
+		if h.History[i] != h.History[i-1] {
+			n++
+		}
+	}
+	return n
+}
+
+// record は取得結果を履歴に追加する
+func (h *FeedHealth) record(ok bool) {
+	result := "x"
+	if ok {
+		result = "o"
+	}
+	h.History += result
+	if len(h.History) > healthHistorySize {
+		h.History = h.History[len(h.History)-healthHistorySize:]
+	}
+}
+
+// HealthAlert はフィードの健全性に関するアラート
+type HealthAlert struct {
+	Kind   string     // HealthBroken, HealthSilent, HealthDisabled, HealthRecovered
+	Feed   FeedInfo   // メタデータ取得前の場合は上書き設定とURLから決定したもの
+	Health FeedHealth // アラート時点の健全性
+}
+
+// HealthStore はフィードごとの健全性を永続化する（デーモンモードでは管理APIからも参照する）
+type HealthStore struct {
+	mu    sync.Mutex
+	path  string
+	feeds map[string]*FeedHealth
+}
+
+// NewHealthStore は状態ファイルを読み込んでHealthStoreを作成する
+func NewHealthStore(path string) (*HealthStore, error) {
+	hs := &HealthStore{
+		path:  path,
+		feeds: make(map[string]*FeedHealth),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return hs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed health: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &hs.feeds); err != nil {
+		return nil, fmt.Errorf("failed to parse feed health %s: %w", path, err)
+	}
+
+	return hs, nil
+}
+
+// Get はフィードの健全性の複製を返す（一度も取得していない場合や記録していない場合はnil）
+func (hs *HealthStore) Get(feedURL string) *FeedHealth {
+	if hs == nil {
+		return nil
+	}
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	h, ok := hs.feeds[feedURL]
+	if !ok {
+		return nil
+	}
+	health := *h
+	return &health
+}
+
+// Enable は無効化したフィードを再び有効にする（無効化していない場合はfalse）
+func (hs *HealthStore) Enable(feedURL string) bool {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	h := hs.feeds[feedURL]
+	if h == nil || h.DisabledUntil.IsZero() {
+		return false
+	}
+	h.DisabledUntil = time.Time{}
+	h.History = ""
+	return true
+}
+
+// Save は状態をファイルに書き込む
+func (hs *HealthStore) Save() error {
+	hs.mu.Lock()
+	data, err := json.MarshalIndent(hs.feeds, "", "  ")
+	hs.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed health: %w", err)
+	}
+	return writeFileAtomic(hs.path, data)
+}
+
+// update はロックを取得してフィードの健全性を更新する（一度も取得していない場合は作成する）
+func (hs *HealthStore) update(feedURL string, fn func(h *FeedHealth)) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	h, ok := hs.feeds[feedURL]
+	if !ok {
+		h = &FeedHealth{}
+		hs.feeds[feedURL] = h
+	}
+	fn(h)
+}
+
+// recordFailure はフィードの取得失敗を記録し、必要に応じてアラートを追加する
+func (fs *FeedService) recordFailure(fc FeedConfig, fetchErr error, now time.Time) {
+	if fs.health == nil {
+		return
+	}
+
+	fs.health.update(fc.URL, func(h *FeedHealth) {
+		h.LastFailure = now
+		h.LastError = fetchErr.Error()
+		h.ConsecutiveFailures++
+		h.record(false)
+
+		if threshold := fs.healthPolicy.FailureThreshold; threshold > 0 && h.ConsecutiveFailures >= threshold && !h.BrokenAlerted {
+			fs.addHealthAlert(HealthBroken, newFeedInfo(fc, nil), h)
+		}
+		fs.checkFlapping(fc, nil, h, now)
+	})
+}
+
+// recordSuccess はフィードの取得成功と記事の公開状況を記録し、必要に応じてアラートを追加する
+func (fs *FeedService) recordSuccess(fc FeedConfig, feed *SourceFeed, now time.Time) {
+	if fs.health == nil {
+		return
+	}
+
+	fs.health.update(fc.URL, func(h *FeedHealth) {
+		if h.BrokenAlerted {
+			fs.addHealthAlert(HealthRecovered, newFeedInfo(fc, feed), h)
+		}
+		h.LastSuccess = now
+		h.LastError = ""
+		h.ConsecutiveFailures = 0
+		h.record(true)
+
+		// 記事の公開頻度（直近30日）と最新記事の公開日時を更新する
+		recent := 0
+		for _, item := range feed.Items {
+			if item.Published.IsZero() {
+				continue
+			}
+			if item.Published.After(h.LastItemAt) && !item.Published.After(now) {
+				h.LastItemAt = item.Published
+			}
+			if now.Sub(item.Published) <= velocityWindow {
+				recent++
+			}
+		}
+		h.ItemsPerDay = float64(recent) / (velocityWindow.Hours() / 24)
+
+		// 日付のある記事を一度も取得していないフィードは判定しない
+		silentAfter := fs.healthPolicy.SilentAfter
+		if fc.SilentAfter > 0 {
+			silentAfter = fc.SilentAfter
+		}
+		if silentAfter > 0 && !h.LastItemAt.IsZero() {
+			if silent := now.Sub(h.LastItemAt) > silentAfter; !silent {
+				h.SilentAlerted = false
+			} else if !h.SilentAlerted {
+				fs.addHealthAlert(HealthSilent, newFeedInfo(fc, feed), h)
+			}
+		}
+		fs.checkFlapping(fc, feed, h, now)
+	})
+}
+
+// checkFlapping は取得の成功と失敗を繰り返すフィードを一定期間無効化する
+func (fs *FeedService) checkFlapping(fc FeedConfig, feed *SourceFeed, h *FeedHealth, now time.Time) {
+	threshold := fs.healthPolicy.FlapThreshold
+	if threshold <= 0 || h.flaps() < threshold {
+		return
+	}
+
+	h.DisabledUntil = now.Add(fs.healthPolicy.DisableFor)
+	h.History = ""
+	log.Printf("Feed %s is flapping, disabled until %s", fc.URL, h.DisabledUntil.Format("2006-01-02 15:04:05"))
+	fs.addHealthAlert(HealthDisabled, newFeedInfo(fc, feed), h)
+}
+
+// addHealthAlert はアラートを追加する（アラート時点の健全性を複製して保持する）
+func (fs *FeedService) addHealthAlert(kind string, feed FeedInfo, h *FeedHealth) {
+	log.Printf("Feed health alert (%s): %s", kind, feed.URL)
+	fs.healthAlerts = append(fs.healthAlerts, &HealthAlert{Kind: kind, Feed: feed, Health: *h})
+}
+
+// HealthAlerts は直前のCheckForRecentItemsで発生したフィードの健全性アラートを返す
+func (fs *FeedService) HealthAlerts() []*HealthAlert {
+	return fs.healthAlerts
+}
+
+// MarkHealthAlertSent はアラートを送信できたことを記録する
+// （送信に失敗したアラートは記録しないため、次回の実行で再びアラートする）
+func (fs *FeedService) MarkHealthAlertSent(alert *HealthAlert) {
+	if fs.health == nil {
+		return
+	}
+
+	fs.health.update(alert.Feed.URL, func(h *FeedHealth) {
+		switch alert.Kind {
+		case HealthBroken:
+			h.BrokenAlerted = true
+		case HealthSilent:
+			h.SilentAlerted = true
+		case HealthRecovered:
+			h.BrokenAlerted = false
+		}
+	})
+}
+
+// SaveHealth はフィードの健全性を保存する（通知の成否に関わらず呼び出す）
+func (fs *FeedService) SaveHealth() error {
+	if fs.health == nil {
+		return nil
+	}
+	return fs.health.Save()
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+// newHealthService はフェイクのフィードを健全性を記録しながら取得するFeedServiceを作成する
+func newHealthService(t *testing.T, now *time.Time, policy HealthPolicy, feeds ...FeedConfig) (*FeedService, *HealthStore) {
+	t.Helper()
+	store, err := NewHealthStore(filepath.Join(t.TempDir(), "feed_health.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := NewFeedService(feeds, 10,
+		WithClock(func() time.Time { return *now }),
+		WithFeedHealth(store, policy),
+	)
+	return fs, store
+}
+
+// alertKinds は直前のチェックで発生したアラートの種類を返す（アラートは送信できたものとして記録する）
+func alertKinds(fs *FeedService) []string {
+	var kinds []string
+	for _, alert := range fs.HealthAlerts() {
+		kinds = append(kinds, alert.Kind)
+		fs.MarkHealthAlertSent(alert)
+	}
+	return kinds
+}
+
+func TestCheckForRecentItemsFetchError(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	ok := fake.NewFeedServer(t, "OK", fake.Item{Title: "Post", Link: "https://example.com/post", Published: now.Add(-time.Hour)})
+	broken := fake.NewFeedServer(t, "Broken")
+	broken.SetFail(true)
+
+	fs, _ := newHealthService(t, &now, HealthPolicy{}, FeedConfig{URL: ok.URL}, FeedConfig{URL: broken.URL})
+	items, err := fs.CheckForRecentItems()
+
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		t.Fatalf("CheckForRecentItems() error = %v, want *FetchError", err)
+	}
+	if len(fetchErr.Failures) != 1 || fetchErr.Failures[0].Feed.URL != broken.URL || fetchErr.AllFailed() {
+		t.Errorf("FetchError = %+v", fetchErr)
+	}
+	if len(items) != 1 {
+		t.Errorf("items = %d, want items from the working feed", len(items))
+	}
+
+	ok.SetFail(true)
+	if _, err := fs.CheckForRecentItems(); !errors.As(err, &fetchErr) || !fetchErr.AllFailed() {
+		t.Errorf("CheckForRecentItems() error = %v, want all feeds failed", err)
+	}
+}
+
+func TestHealthBrokenAndRecovered(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	feedServer := fake.NewFeedServer(t, "Example", fake.Item{Title: "Post", Link: "https://example.com/post", Published: now.Add(-time.Hour)})
+	fs, store := newHealthService(t, &now, HealthPolicy{FailureThreshold: 2}, FeedConfig{URL: feedServer.URL})
+
+	feedServer.SetFail(true)
+	for i, want := range [][]string{nil, {HealthBroken}, nil} {
+		fs.CheckForRecentItems()
+		if got := alertKinds(fs); len(got) != len(want) || (len(want) > 0 && got[0] != want[0]) {
+			t.Fatalf("failure %d alerts = %v, want %v", i+1, got, want)
+		}
+		now = now.Add(24 * time.Hour)
+	}
+	if h := store.Get(feedServer.URL); h.ConsecutiveFailures != 3 || h.LastError == "" {
+		t.Errorf("health = %+v", h)
+	}
+
+	feedServer.SetFail(false)
+	if _, err := fs.CheckForRecentItems(); err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	if got := alertKinds(fs); len(got) != 1 || got[0] != HealthRecovered {
+		t.Errorf("recovery alerts = %v, want [recovered]", got)
+	}
+	if h := store.Get(feedServer.URL); h.ConsecutiveFailures != 0 || h.LastError != "" || !h.LastSuccess.Equal(now) {
+		t.Errorf("health after recovery = %+v", h)
+	}
+}
+
+func TestHealthAlertRetriedUntilSent(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	feedServer := fake.NewFeedServer(t, "Example", fake.Item{Title: "Post", Link: "https://example.com/post", Published: now.Add(-time.Hour)})
+	fs, store := newHealthService(t, &now, HealthPolicy{FailureThreshold: 1, SilentAfter: 24 * time.Hour}, FeedConfig{URL: feedServer.URL})
+
+	// 送信に失敗したアラートは送信済みにせず、次回のチェックで再びアラートする
+	feedServer.SetFail(true)
+	for i := 0; i < 2; i++ {
+		fs.CheckForRecentItems()
+		if got := fs.HealthAlerts(); len(got) != 1 || got[0].Kind != HealthBroken {
+			t.Fatalf("check %d alerts = %v, want [broken]", i+1, got)
+		}
+	}
+	if h := store.Get(feedServer.URL); h.BrokenAlerted {
+		t.Errorf("health = %+v, want broken alert not marked as sent", h)
+	}
+	if got := alertKinds(fs); len(got) != 1 {
+		t.Fatalf("alerts = %v", got)
+	}
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 0 {
+		t.Errorf("alerts = %v, want none after the alert was sent", got)
+	}
+
+	// 復旧のアラートも送信できるまで繰り返す
+	feedServer.SetFail(false)
+	fs.CheckForRecentItems()
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 1 || got[0] != HealthRecovered {
+		t.Errorf("alerts = %v, want [recovered]", got)
+	}
+
+	// 更新停止のアラートも同様
+	now = now.Add(48 * time.Hour)
+	fs.CheckForRecentItems()
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 1 || got[0] != HealthSilent {
+		t.Errorf("alerts = %v, want [silent]", got)
+	}
+	if h := store.Get(feedServer.URL); !h.SilentAlerted || h.BrokenAlerted {
+		t.Errorf("health = %+v", h)
+	}
+}
+
+func TestHealthSilentAndVelocity(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	feedServer := fake.NewFeedServer(t, "Example",
+		fake.Item{Title: "A", Link: "https://example.com/a", Published: now.Add(-3 * 24 * time.Hour)},
+		fake.Item{Title: "B", Link: "https://example.com/b", Published: now.Add(-10 * 24 * time.Hour)},
+		fake.Item{Title: "C", Link: "https://example.com/c", Published: now.Add(-40 * 24 * time.Hour)},
+	)
+	fs, store := newHealthService(t, &now, HealthPolicy{SilentAfter: 7 * 24 * time.Hour},
+		FeedConfig{URL: feedServer.URL},
+	)
+
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 0 {
+		t.Fatalf("alerts = %v, want none", got)
+	}
+	h := store.Get(feedServer.URL)
+	if want := 2.0 / 30; h.ItemsPerDay != want || !h.LastItemAt.Equal(now.Add(-3*24*time.Hour)) {
+		t.Errorf("health = %+v, want %v items/day", h, want)
+	}
+
+	// 最新記事から7日を超えたら1度だけアラートする
+	now = now.Add(5 * 24 * time.Hour)
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 1 || got[0] != HealthSilent {
+		t.Fatalf("alerts = %v, want [silent]", got)
+	}
+	now = now.Add(24 * time.Hour)
+	fs.CheckForRecentItems()
+	if got := alertKinds(fs); len(got) != 0 {
+		t.Errorf("alerts = %v, want no repeated alert", got)
+	}
+
+	// 記事が再開したらアラートの状態を解除する
+	feedServer.SetItems(fake.Item{Title: "D", Link: "https://example.com/d", Published: now.Add(-time.Hour)})
+	fs.CheckForRecentItems()
+	if h := store.Get(feedServer.URL); h.SilentAlerted {
+		t.Errorf("health = %+v, want silent alert cleared", h)
+	}
+}
+
+func TestHealthFlappingDisablesFeed(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	feedServer := fake.NewFeedServer(t, "Example")
+	fs, store := newHealthService(t, &now, HealthPolicy{FlapThreshold: 3, DisableFor: 7 * 24 * time.Hour},
+		FeedConfig{URL: feedServer.URL},
+	)
+
+	// 成功・失敗・成功・失敗で3回入れ替わったら無効化する
+	for i := 0; i < 4; i++ {
+		feedServer.SetFail(i%2 == 1)
+		fs.CheckForRecentItems()
+		now = now.Add(time.Hour)
+	}
+	if got := alertKinds(fs); len(got) != 1 || got[0] != HealthDisabled {
+		t.Fatalf("alerts = %v, want [disabled]", got)
+	}
+	if !store.Get(feedServer.URL).Disabled(now) {
+		t.Fatalf("feed is not disabled: %+v", store.Get(feedServer.URL))
+	}
+
+	// 無効化中は取得しない（失敗として扱わない）
+	if _, err := fs.CheckForRecentItems(); err != nil {
+		t.Errorf("CheckForRecentItems() error = %v, want disabled feed skipped", err)
+	}
+
+	// 期間が過ぎるか、手動で有効にすると再び取得する
+	if !store.Enable(feedServer.URL) || store.Get(feedServer.URL).Disabled(now) {
+		t.Fatal("Enable() did not re-enable the feed")
+	}
+	if _, err := fs.CheckForRecentItems(); err == nil {
+		t.Error("CheckForRecentItems() error = nil, want the re-enabled feed to be fetched")
+	}
+}
+
+func TestHealthStoreSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "feed_health.json")
+	store, err := NewHealthStore(path)
+	if err != nil {
+		t.Fatalf("NewHealthStore() error = %v", err)
+	}
+	store.update("https://example.com/feed", func(h *FeedHealth) { h.ConsecutiveFailures = 2 })
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewHealthStore(path)
+	if err != nil {
+		t.Fatalf("NewHealthStore() error = %v", err)
+	}
+	if h := reloaded.Get("https://example.com/feed"); h == nil || h.ConsecutiveFailures != 2 {
+		t.Errorf("reloaded health = %+v", h)
+	}
+	if reloaded.Enable("https://example.com/feed") {
+		t.Error("Enable() = true for a feed that is not disabled")
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// HeldItem は翻訳・要約に失敗したため通知せずに保留した記事
+type HeldItem struct {
+	Item      *FeedItem `json:"item"`
+	Attempts  int       `json:"attempts"` // 翻訳・要約を試みた回数
+	LastError string    `json:"last_error,omitempty"`
+	HeldAt    time.Time `json:"held_at"` // 最初に保留した日時
+}
+
+// HoldStore は保留した記事を永続化し、次回以降の実行で再試行できるようにする
+type HoldStore struct {
+	path  string
+	items []*HeldItem
+}
+
+// NewHoldStore は状態ファイルを読み込んでHoldStoreを作成する
+func NewHoldStore(path string) (*HoldStore, error) {
+	hs := &HoldStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return hs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read held items: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &hs.items); err != nil {
+		return nil, fmt.Errorf("failed to parse held items %s: %w", path, err)
+	}
+
+	return hs, nil
+}
+
+// Items は保留中の記事を返す
+func (hs *HoldStore) Items() []*HeldItem {
+	return hs.items
+}
+
+// Replace は保留中の記事を置き換える（今回の実行で再試行した記事は取り除き、新たに保留した記事を渡す）
+func (hs *HoldStore) Replace(items []*HeldItem) {
+	hs.items = items
+}
+
+// Save は状態をファイルに書き込む
+func (hs *HoldStore) Save() error {
+	items := hs.items
+	if items == nil {
+		items = []*HeldItem{}
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal held items: %w", err)
+	}
+	return writeFileAtomic(hs.path, data)
+}
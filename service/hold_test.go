@@ -0,0 +1,52 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHoldStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "held_items.json")
+	store, err := NewHoldStore(path)
+	if err != nil {
+		t.Fatalf("NewHoldStore() error = %v", err)
+	}
+	if len(store.Items()) != 0 {
+		t.Fatalf("Items() = %d, want none before the first save", len(store.Items()))
+	}
+
+	heldAt := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store.Replace([]*HeldItem{{
+		Item:      &FeedItem{Title: "Post", Link: "https://example.com/post", Feed: FeedInfo{URL: "https://example.com/feed", Name: "Example"}},
+		Attempts:  1,
+		LastError: "DeepL API error: status=456",
+		HeldAt:    heldAt,
+	}})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewHoldStore(path)
+	if err != nil {
+		t.Fatalf("NewHoldStore() error = %v", err)
+	}
+	items := reloaded.Items()
+	if len(items) != 1 || items[0].Attempts != 1 || items[0].Item.Link != "https://example.com/post" ||
+		items[0].Item.Feed.Name != "Example" || !items[0].HeldAt.Equal(heldAt) {
+		t.Fatalf("reloaded items = %+v", items)
+	}
+
+	// すべて再試行して保留がなくなった場合も保存できる
+	reloaded.Replace(nil)
+	if err := reloaded.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	emptied, err := NewHoldStore(path)
+	if err != nil {
+		t.Fatalf("NewHoldStore() error = %v", err)
+	}
+	if len(emptied.Items()) != 0 {
+		t.Errorf("Items() = %d, want none", len(emptied.Items()))
+	}
+}
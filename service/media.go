@@ -0,0 +1,144 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// maxContentDescriptionLength は概要がない記事で本文を概要として使う場合の最大文字数（翻訳APIの課金対象のため）
+const maxContentDescriptionLength = 1000
+
+// FeedMedia は記事に添付されたメディア（Media RSSの media:content、enclosure、JSON Feedの attachments）
+type FeedMedia struct {
+	URL    string `json:"url"`
+	Type   string `json:"type,omitempty"`   // MIMEタイプ
+	Medium string `json:"medium,omitempty"` // image, video, audio など
+	Title  string `json:"title,omitempty"`
+}
+
+// IsImage はメディアが画像かを返す
+func (m FeedMedia) IsImage() bool {
+	return m.Medium == "image" || strings.HasPrefix(m.Type, "image/")
+}
+
+// itemDescription は記事の概要を返す
+// （概要がない場合は media:description、それもなければ本文の先頭を使う。JSON Feedの content_html のみの記事など）
+func itemDescription(item *gofeed.Item, content string) string {
+	if description := cleanText(item.Description); description != "" {
+		return description
+	}
+	if description := cleanText(mediaText(item.Extensions, "description")); description != "" {
+		return description
+	}
+	return truncateText(content, maxContentDescriptionLength)
+}
+
+// itemMedia は記事に添付されたメディアを返す（media:group 内の media:content も含む）
+func itemMedia(item *gofeed.Item) []FeedMedia {
+	var media []FeedMedia
+	seen := make(map[string]bool)
+	add := func(m FeedMedia) {
+		if m.URL == "" || seen[m.URL] {
+			return
+		}
+		seen[m.URL] = true
+		media = append(media, m)
+	}
+
+	for _, content := range mediaContents(item.Extensions) {
+		add(FeedMedia{
+			URL:    content.Attrs["url"],
+			Type:   content.Attrs["type"],
+			Medium: content.Attrs["medium"],
+			Title:  childText(content.Children, "title"),
+		})
+	}
+	for _, enclosure := range item.Enclosures {
+		if enclosure != nil {
+			add(FeedMedia{URL: enclosure.URL, Type: enclosure.Type})
+		}
+	}
+	return media
+}
+
+// itemImage は記事の代表画像のURLを返す
+// （フィードの画像 > media:thumbnail > 画像の media:content / enclosure の順）
+func itemImage(item *gofeed.Item, media []FeedMedia) string {
+	if item.Image != nil && item.Image.URL != "" {
+		return item.Image.URL
+	}
+	if thumbnail := mediaThumbnail(item.Extensions); thumbnail != "" {
+		return thumbnail
+	}
+	for _, m := range media {
+		if m.IsImage() {
+			return m.URL
+		}
+	}
+	return ""
+}
+
+// mediaContents は media:content を media:group 内のものも含めて返す
+func mediaContents(extensions ext.Extensions) []ext.Extension {
+	elements := extensions["media"]
+	if elements == nil {
+		return nil
+	}
+
+	contents := append([]ext.Extension{}, elements["content"]...)
+	for _, group := range elements["group"] {
+		contents = append(contents, group.Children["content"]...)
+	}
+	return contents
+}
+
+// mediaThumbnail は media:thumbnail のURLを返す（記事直下 > media:group > media:content 内の順）
+func mediaThumbnail(extensions ext.Extensions) string {
+	elements := extensions["media"]
+	if elements == nil {
+		return ""
+	}
+
+	candidates := append([]ext.Extension{}, elements["thumbnail"]...)
+	for _, group := range elements["group"] {
+		candidates = append(candidates, group.Children["thumbnail"]...)
+	}
+	for _, content := range mediaContents(extensions) {
+		candidates = append(candidates, content.Children["thumbnail"]...)
+	}
+	for _, thumbnail := range candidates {
+		if url := thumbnail.Attrs["url"]; url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+// mediaText は media:title や media:description など、記事直下または media:group 内のテキストを返す
+func mediaText(extensions ext.Extensions, name string) string {
+	elements := extensions["media"]
+	if elements == nil {
+		return ""
+	}
+	if text := childText(elements, name); text != "" {
+		return text
+	}
+	for _, group := range elements["group"] {
+		if text := childText(group.Children, name); text != "" {
+			return text
+		}
+	}
+	return ""
+}
+
+// childText は子要素のうち最初に値を持つもののテキストを返す
+func childText(children map[string][]ext.Extension, name string) string {
+	for _, child := range children[name] {
+		if value := strings.TrimSpace(child.Value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// serveFeed は固定の本文を返すフィードサーバーの記事を取得する
+func serveFeed(t *testing.T, contentType, body string) []*FeedItem {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	fs := NewFeedService([]FeedConfig{{URL: server.URL}}, 10, WithClock(func() time.Time { return now }))
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	return items
+}
+
+func TestAtomContentAndMedia(t *testing.T) {
+	items := serveFeed(t, "application/atom+xml", `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <title>Video Blog</title>
+  <entry>
+    <title>Scaling Postgres</title>
+    <id>urn:entry:1</id>
+    <link href="https://example.com/postgres"/>
+    <updated>2024-01-15T08:00:00Z</updated>
+    <content type="html">&lt;p&gt;Full &lt;b&gt;article&lt;/b&gt; body.&lt;/p&gt;</content>
+    <media:group>
+      <media:title>Scaling Postgres talk</media:title>
+      <media:description>How we sharded our database.</media:description>
+      <media:content url="https://example.com/talk.mp4" type="video/mp4" medium="video"/>
+      <media:thumbnail url="https://example.com/talk.jpg" width="480" height="360"/>
+    </media:group>
+  </entry>
+</feed>`)
+
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	item := items[0]
+	if item.Content != "Full article body." {
+		t.Errorf("Content = %q", item.Content)
+	}
+	// 概要がない場合は media:description を使う
+	if item.Description != "How we sharded our database." {
+		t.Errorf("Description = %q", item.Description)
+	}
+	if item.ImageURL != "https://example.com/talk.jpg" {
+		t.Errorf("ImageURL = %q", item.ImageURL)
+	}
+	if len(item.Media) != 1 || item.Media[0].URL != "https://example.com/talk.mp4" || item.Media[0].Medium != "video" {
+		t.Errorf("Media = %+v", item.Media)
+	}
+}
+
+func TestRSSMediaContentImage(t *testing.T) {
+	items := serveFeed(t, "application/rss+xml", `<?xml version="1.0"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Photo Blog</title>
+    <item>
+      <title>Launch day</title>
+      <link>https://example.com/launch</link>
+      <description>Photos from the launch.</description>
+      <pubDate>Mon, 15 Jan 2024 08:00:00 GMT</pubDate>
+      <media:content url="https://example.com/launch.png" medium="image"><media:title>Stage</media:title></media:content>
+      <enclosure url="https://example.com/launch.mp3" type="audio/mpeg" length="1"/>
+    </item>
+  </channel>
+</rss>`)
+
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	item := items[0]
+	if item.Description != "Photos from the launch." || item.ImageURL != "https://example.com/launch.png" {
+		t.Errorf("item = %+v", item)
+	}
+	if len(item.Media) != 2 || item.Media[0].Title != "Stage" || item.Media[1].Type != "audio/mpeg" {
+		t.Errorf("Media = %+v", item.Media)
+	}
+}
+
+func TestJSONFeed(t *testing.T) {
+	items := serveFeed(t, "application/feed+json", `{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "JSON Blog",
+  "home_page_url": "https://example.com",
+  "items": [
+    {
+      "id": "42",
+      "url": "https://example.com/json-feed",
+      "title": "Why JSON Feed",
+      "content_html": "<p>JSON Feed is a syndication format.</p>",
+      "image": "https://example.com/cover.png",
+      "date_published": "2024-01-15T08:00:00Z",
+      "tags": ["formats"],
+      "authors": [{"name": "Jane"}],
+      "attachments": [{"url": "https://example.com/episode.mp3", "mime_type": "audio/mpeg"}]
+    }
+  ]
+}`)
+
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	item := items[0]
+	if item.Title != "Why JSON Feed" || item.GUID != "42" || item.Link != "https://example.com/json-feed" || item.Author != "Jane" {
+		t.Errorf("item = %+v", item)
+	}
+	// content_html のみの記事は本文を概要として使う
+	if item.Content != "JSON Feed is a syndication format." || item.Description != item.Content {
+		t.Errorf("Content = %q, Description = %q", item.Content, item.Description)
+	}
+	if item.ImageURL != "https://example.com/cover.png" || len(item.Media) != 1 || item.Feed.Name != "JSON Blog" {
+		t.Errorf("ImageURL = %q, Media = %+v, Feed = %+v", item.ImageURL, item.Media, item.Feed)
+	}
+}
+
+func TestItemDescriptionTruncatesContent(t *testing.T) {
+	long := make([]rune, maxContentDescriptionLength*2)
+	for i := range long {
+		long[i] = 'a'
+	}
+	items := serveFeed(t, "application/feed+json", `{"version": "https://jsonfeed.org/version/1.1", "title": "Long", "items": [
+  {"id": "1", "url": "https://example.com/long", "title": "Long", "content_text": "`+string(long)+`", "date_published": "2024-01-15T08:00:00Z"}
+]}`)
+
+	if len(items) != 1 || len([]rune(items[0].Description)) > maxContentDescriptionLength+3 || len(items[0].Content) != len(long) {
+		t.Errorf("description was not truncated: %d runes", len([]rune(items[0].Description)))
+	}
+}
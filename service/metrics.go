@@ -0,0 +1,205 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/sashabaranov/go-openai"
+)
+
+// metricsNamespace はメトリクス名の接頭辞
+const metricsNamespace = "rss_notification"
+
+// OpenAI APIを呼び出す処理（トークン数のメトリクスのラベル）
+const (
+	OpenAISummarize = "summarize" // 要約
+	OpenAITranslate = "translate" // DeepLの代替としての翻訳
+	OpenAIRelevance = "relevance" // 関連度判定
+)
+
+// Metrics はPrometheusのメトリクス（nilの場合は何も記録しない）
+type Metrics struct {
+	registry *prometheus.Registry // アプリケーションのメトリクス（Pushgatewayにはこちらのみ送信する）
+	runtime  *prometheus.Registry // Goランタイムとプロセスのメトリクス
+
+	fetchDuration    *prometheus.HistogramVec
+	fetchErrors      *prometheus.CounterVec
+	itemsFound       *prometheus.CounterVec
+	itemsNew         *prometheus.CounterVec
+	itemsPosted      *prometheus.CounterVec
+	translationChars *prometheus.CounterVec
+	openAITokens     *prometheus.CounterVec
+	sendFailures     *prometheus.CounterVec
+	runDuration      prometheus.Gauge
+	runFailures      prometheus.Gauge
+	lastRunTimestamp prometheus.Gauge
+	runsTotal        *prometheus.CounterVec
+}
+
+// NewMetrics はメトリクスを作成し、専用のレジストリに登録する
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runtime:  prometheus.NewRegistry(),
+		fetchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "feed_fetch_duration_seconds",
+			Help:      "Time taken to fetch and parse each feed.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"feed"}),
+		fetchErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "feed_fetch_errors_total",
+			Help:      "Number of failed feed fetches.",
+		}, []string{"feed"}),
+		itemsFound: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "items_found_total",
+			Help:      "Number of items contained in fetched feeds.",
+		}, []string{"feed"}),
+		itemsNew: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "items_new_total",
+			Help:      "Number of new items selected for processing.",
+		}, []string{"feed"}),
+		itemsPosted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "items_posted_total",
+			Help:      "Number of articles posted to each destination.",
+		}, []string{"destination"}),
+		translationChars: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "translation_characters_total",
+			Help:      "Number of characters sent for translation.",
+		}, []string{"provider"}),
+		openAITokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "openai_tokens_total",
+			Help:      "Number of OpenAI tokens used.",
+		}, []string{"operation", "type"}),
+		sendFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "notification_send_failures_total",
+			Help:      "Number of messages that failed to send, by notifier type (slack, teams, ...).",
+		}, []string{"notifier"}),
+		runDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_duration_seconds",
+			Help:      "Duration of the last run.",
+		}),
+		runFailures: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "run_failures",
+			Help:      "Number of failures recorded in the last run.",
+		}),
+		lastRunTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "last_run_timestamp_seconds",
+			Help:      "Unix time when the last run finished.",
+		}),
+		runsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "runs_total",
+			Help:      "Number of runs, by result (success or failure).",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		m.fetchDuration, m.fetchErrors, m.itemsFound, m.itemsNew, m.itemsPosted,
+		m.translationChars, m.openAITokens, m.sendFailures,
+		m.runDuration, m.runFailures, m.lastRunTimestamp, m.runsTotal,
+	)
+	m.runtime.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	return m
+}
+
+// ObserveFetch はフィードの取得にかかった時間と失敗を記録する
+// （feedラベルは/metricsやPushgatewayに公開するため、URLのユーザー情報とクエリを取り除く）
+func (m *Metrics) ObserveFetch(feedURL string, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	feed := redactURL(feedURL)
+	m.fetchDuration.WithLabelValues(feed).Observe(duration.Seconds())
+	if err != nil {
+		m.fetchErrors.WithLabelValues(feed).Inc()
+	}
+}
+
+// AddItems はフィードに含まれていた記事数と新着記事数を記録する
+func (m *Metrics) AddItems(feedURL string, found, newItems int) {
+	if m == nil {
+		return
+	}
+	feed := redactURL(feedURL)
+	m.itemsFound.WithLabelValues(feed).Add(float64(found))
+	m.itemsNew.WithLabelValues(feed).Add(float64(newItems))
+}
+
+// AddPosted は通知先に送信した記事数を記録する
+func (m *Metrics) AddPosted(destination string, n int) {
+	if m == nil {
+		return
+	}
+	m.itemsPosted.WithLabelValues(destination).Add(float64(n))
+}
+
+// AddTranslationCharacters は翻訳に送信した文字数を記録する（DeepLの課金対象の文字数）
+func (m *Metrics) AddTranslationCharacters(provider string, n int) {
+	if m == nil {
+		return
+	}
+	m.translationChars.WithLabelValues(provider).Add(float64(n))
+}
+
+// AddOpenAITokens はOpenAI APIのレスポンスに含まれる使用トークン数を記録する
+func (m *Metrics) AddOpenAITokens(operation string, usage openai.Usage) {
+	if m == nil {
+		return
+	}
+	m.openAITokens.WithLabelValues(operation, "prompt").Add(float64(usage.PromptTokens))
+	m.openAITokens.WithLabelValues(operation, "completion").Add(float64(usage.CompletionTokens))
+}
+
+// IncSendFailure は通知の送信の失敗を記録する
+func (m *Metrics) IncSendFailure(notifier string) {
+	if m == nil {
+		return
+	}
+	m.sendFailures.WithLabelValues(notifier).Inc()
+}
+
+// ObserveRun は実行にかかった時間と結果を記録する
+func (m *Metrics) ObserveRun(report *RunReport) {
+	if m == nil {
+		return
+	}
+	m.runDuration.Set(report.FinishedAt.Sub(report.StartedAt).Seconds())
+	m.runFailures.Set(float64(len(report.Failures)))
+	m.lastRunTimestamp.Set(float64(report.FinishedAt.Unix()))
+	result := "success"
+	if report.Failed() {
+		result = "failure"
+	}
+	m.runsTotal.WithLabelValues(result).Inc()
+}
+
+// Handler は/metricsで公開するハンドラーを返す（Goランタイムとプロセスのメトリクスを含む）
+func (m *Metrics) Handler() http.Handler {
+	gatherers := prometheus.Gatherers{m.registry, m.runtime}
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{})
+}
+
+// Push はアプリケーションのメトリクスをPushgatewayに送信する（1回だけ実行する場合はスクレイプされる前に終了するため）
+// 同じjobで以前に送信した値はすべて置き換える
+func (m *Metrics) Push(url, job string) error {
+	if err := push.New(url, job).Gatherer(m.registry).Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", url, err)
+	}
+	return nil
+}
@@ -1,31 +1,43 @@
 package service
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// メッセージ形式
+const (
+	FormatBlocks      = "blocks"      // Slack Block Kit
+	FormatAttachments = "attachments" // レガシーなAttachment（互換用）
 )
 
-// NotificationService はSlack通知を管理する
+// NotificationService は通知先（Slack・Teams・Discordなど）への通知を管理する
 type NotificationService struct {
-	webhookURL string
-	channel    string
-	httpClient *http.Client
+	channel  string
+	renderer *templateRenderer
+	runMu    sync.Mutex // 管理APIのプレビューとRSSチェックが並行するため、runの読み書きを保護する
+	run      RunInfo
+	notifier Notifier
 }
 
 // SlackMessage はSlackに送信するメッセージの構造体
+// （blocks・attachmentsはテンプレートが生成したJSONをそのまま送信する）
 type SlackMessage struct {
-	Channel     string       `json:"channel,omitempty"`
-	Username    string       `json:"username,omitempty"`
-	IconEmoji   string       `json:"icon_emoji,omitempty"`
-	Text        string       `json:"text,omitempty"`
-	Attachments []Attachment `json:"attachments,omitempty"`
-	ThreadTS    string       `json:"thread_ts,omitempty"` // スレッドタイムスタンプ
+	Channel     string          `json:"channel,omitempty"`
+	Username    string          `json:"username,omitempty"`
+	IconEmoji   string          `json:"icon_emoji,omitempty"`
+	IconURL     string          `json:"icon_url,omitempty"`
+	Text        string          `json:"text,omitempty"` // Block Kit使用時は通知・プレビュー用のフォールバックテキスト
+	Blocks      json.RawMessage `json:"blocks,omitempty"`
+	Attachments json.RawMessage `json:"attachments,omitempty"`
+	ThreadTS    string          `json:"thread_ts,omitempty"` // スレッドタイムスタンプ
 }
 
 // SlackResponse はSlackからのレスポンス構造体
@@ -35,430 +47,399 @@ type SlackResponse struct {
 	Timestamp string `json:"ts,omitempty"` // メッセージのタイムスタンプ
 }
 
-// Attachment はSlackメッセージの添付ファイル構造体
-type Attachment struct {
-	Color      string  `json:"color,omitempty"`
-	Title      string  `json:"title,omitempty"`
-	TitleLink  string  `json:"title_link,omitempty"`
-	Text       string  `json:"text,omitempty"`
-	Fields     []Field `json:"fields,omitempty"`
-	Footer     string  `json:"footer,omitempty"`
-	Timestamp  int64   `json:"ts,omitempty"`
-	MarkdownIn []string `json:"mrkdwn_in,omitempty"`
-}
-
-// Field はSlackメッセージの添付フィールド構造体
-type Field struct {
-	Title string `json:"title"`
-	Value string `json:"value"`
-	Short bool   `json:"short"`
-}
-
 // NewNotificationService は新しいNotificationServiceを作成する
-func NewNotificationService(webhookURL, channel string) *NotificationService {
-	return &NotificationService{
-		webhookURL: webhookURL,
-		channel:    channel,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
-}
+// （templateDirを指定すると、同名のテンプレートファイルで組み込みテンプレートを上書きする。
+// 通知先の種類はWithNotifierTypeで指定し、省略した場合はSlack）
+func NewNotificationService(webhookURL, channel, format, templateDir string, opts ...Option) (*NotificationService, error) {
+	o := applyOptions(opts)
 
-// SendNewArticleNotification は新記事の通知を送信する
-func (ns *NotificationService) SendNewArticleNotification(result *TranslationResult) error {
-	log.Printf("Sending Slack notification for article: %s", result.TranslatedTitle)
-
-	// Slackメッセージを構築
-	message := ns.buildArticleMessage(result)
-
-	// Slackに送信
-	if err := ns.sendToSlack(message); err != nil {
-		return fmt.Errorf("failed to send Slack notification: %w", err)
+	notifier, err := NewNotifier(o.notifierType, webhookURL, instrumentHTTPClient(o.httpClient))
+	if err != nil {
+		return nil, err
 	}
+	kind := o.notifierType
+	if kind == "" {
+		kind = NotifierSlack
+	}
+	notifier = &instrumentedNotifier{Notifier: notifier, kind: kind, metrics: o.metrics}
 
-	log.Printf("Slack notification sent successfully for: %s", result.TranslatedTitle)
-	return nil
-}
-
-// SendNewArticleNotificationWithThread は新記事の通知をスレッド形式で送信する
-func (ns *NotificationService) SendNewArticleNotificationWithThread(result *TranslationResult) error {
-	log.Printf("Sending threaded Slack notification for article: %s", result.TranslatedTitle)
-
-	// 1. まずタイトルメッセージを送信
-	titleMessage := ns.buildTitleMessage(result)
-	timestamp, err := ns.sendToSlackWithResponse(titleMessage)
+	renderer, err := newTemplateRenderer(templateFormat(o.notifierType, format), templateDir)
 	if err != nil {
-		return fmt.Errorf("failed to send title message: %w", err)
+		return nil, fmt.Errorf("failed to load notification templates: %w", err)
 	}
 
-	// 2. 要約をスレッドで返信
-	summaryMessage := ns.buildSummaryMessage(result)
-	summaryMessage.ThreadTS = timestamp // スレッドに関連付け
+	return &NotificationService{
+		channel:  channel,
+		renderer: renderer,
+		run:      RunInfo{StartedAt: time.Now()},
+		notifier: notifier,
+	}, nil
+}
 
-	if err := ns.sendToSlack(summaryMessage); err != nil {
-		return fmt.Errorf("failed to send summary in thread: %w", err)
-	}
+// SetRunInfo はテンプレートに渡す実行情報を設定する
+func (ns *NotificationService) SetRunInfo(run RunInfo) {
+	ns.runMu.Lock()
+	defer ns.runMu.Unlock()
+	ns.run = run
+}
 
-	log.Printf("Threaded Slack notification sent successfully for: %s", result.TranslatedTitle)
-	return nil
+// runInfo はテンプレートに渡す実行情報を返す
+func (ns *NotificationService) runInfo() RunInfo {
+	ns.runMu.Lock()
+	defer ns.runMu.Unlock()
+	return ns.run
 }
 
 // SendErrorNotification はエラー通知を送信する
 func (ns *NotificationService) SendErrorNotification(errorMsg string) error {
 	log.Printf("Sending error notification to Slack: %s", errorMsg)
 
-	message := &SlackMessage{
-		Channel:   ns.channel,
-		Username:  "RSS通知Bot",
-		IconEmoji: ":warning:",
-		Attachments: []Attachment{
-			{
-				Color: "danger",
-				Title: "RSS通知システムでエラーが発生しました",
-				Text:  errorMsg,
-				Fields: []Field{
-					{
-						Title: "発生日時",
-						Value: time.Now().In(time.FixedZone("JST", 9*60*60)).Format("2006-01-02 15:04:05 JST"),
-						Short: true,
-					},
-				},
-				Footer:     "RSS通知システム",
-				Timestamp:  time.Now().Unix(),
-				MarkdownIn: []string{"text"},
-			},
-		},
-	}
-
-	if err := ns.sendToSlack(message); err != nil {
+	message, err := ns.render(TemplateError, &TemplateData{Error: errorMsg})
+	if err != nil {
+		return err
+	}
+
+	if _, err := ns.notifier.Send(context.Background(), message, ""); err != nil {
 		return fmt.Errorf("failed to send error notification: %w", err)
 	}
 
 	return nil
 }
 
-// SendStartupNotification はシステム起動通知を送信する
-func (ns *NotificationService) SendStartupNotification() error {
-	log.Println("Sending startup notification to Slack")
+// SendHealthAlert はフィードの健全性アラート（取得失敗・記事の途絶・無効化・復旧）を送信する
+// （フィードごとの投稿先チャンネルではなく通知先のチャンネルに投稿する）
+func (ns *NotificationService) SendHealthAlert(alert *HealthAlert) error {
+	log.Printf("Sending feed health alert (%s) for %s", alert.Kind, alert.Feed.URL)
 
-	message := &SlackMessage{
-		Channel:   ns.channel,
-		Username:  "RSS通知Bot",
-		IconEmoji: ":rocket:",
-		Attachments: []Attachment{
-			{
-				Color: "good",
-				Title: "RSS通知システムが開始されました",
-				Text:  "ByteByteGoのRSSフィード監視を開始します。",
-				Fields: []Field{
-					{
-						Title: "開始日時",
-						Value: time.Now().In(time.FixedZone("JST", 9*60*60)).Format("2006-01-02 15:04:05 JST"),
-						Short: true,
-					},
-					{
-						Title: "フィードURL",
-						Value: "https://blog.bytebytego.com/feed",
-						Short: true,
-					},
-				},
-				Footer:     "RSS通知システム",
-				Timestamp:  time.Now().Unix(),
-				MarkdownIn: []string{"text"},
-			},
-		},
-	}
-
-	return ns.sendToSlack(message)
-}
-
-// buildArticleMessage は記事通知用のSlackメッセージを構築する
-func (ns *NotificationService) buildArticleMessage(result *TranslationResult) *SlackMessage {
-	// 説明文を短縮（Slackの制限に対応）
-	description := result.TranslatedDescription
-	if len(description) > 500 {
-		description = description[:500] + "..."
+	message, err := ns.render(TemplateHealth, &TemplateData{Health: alert})
+	if err != nil {
+		return err
 	}
 
-	// 要約文の整形
-	summary := result.Summary
-	if summary == "" {
-		summary = "要約が利用できません。"
+	if _, err := ns.notifier.Send(context.Background(), message, ""); err != nil {
+		return fmt.Errorf("failed to send health alert: %w", err)
 	}
 
-	return &SlackMessage{
-		Channel:   ns.channel,
-		Username:  "RSS通知Bot",
-		IconEmoji: ":newspaper:",
-		Text:      " *ByteByteGoの新しい記事が投稿されました！*",
-		Attachments: []Attachment{
-			{
-				Color:     "#36a64f",
-				Title:     result.TranslatedTitle,
-				TitleLink: result.Link,
-				Text:      fmt.Sprintf("* 要約*\n%s", summary),
-				Fields: []Field{
-					{
-						Title: "原文タイトル",
-						Value: result.OriginalTitle,
-						Short: false,
-					},
-					{
-						Title: "詳細",
-						Value: ns.truncateText(description, 300),
-						Short: false,
-					},
-				},
-				Footer: "ByteByteGo RSS通知",
-				Timestamp: time.Now().Unix(),
-				MarkdownIn: []string{"text", "fields"},
-			},
-		},
-	}
+	return nil
 }
 
-// sendToSlack はSlackにメッセージを送信する
-func (ns *NotificationService) sendToSlack(message *SlackMessage) error {
-	// JSONにエンコード
-	jsonData, err := json.Marshal(message)
+// SendRunReport は実行サマリー（件数と失敗の一覧）を送信する
+func (ns *NotificationService) SendRunReport(report *RunReport) error {
+	log.Printf("Sending run report: %d new, %d posted, %d failures", report.New, report.Posted, len(report.Failures))
+
+	message, err := ns.render(TemplateReport, &TemplateData{Report: report})
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return err
 	}
 
-	// HTTPリクエストを作成
-	req, err := http.NewRequest("POST", ns.webhookURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if _, err := ns.notifier.Send(context.Background(), message, ""); err != nil {
+		return fmt.Errorf("failed to send run report: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return nil
+}
 
-	// リクエストを送信
-	resp, err := ns.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+// SendStartupNotification はシステム起動通知を送信する
+func (ns *NotificationService) SendStartupNotification(feeds []FeedInfo) error {
+	log.Println("Sending startup notification to Slack")
 
-	// レスポンスを読み取り
-	body, err := io.ReadAll(resp.Body)
+	message, err := ns.render(TemplateStartup, &TemplateData{Feeds: feeds})
 	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+		return err
 	}
 
-	// ステータスコードをチェック
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("Slack API error: status=%d, body=%s", resp.StatusCode, string(body))
-	}
+	_, err = ns.notifier.Send(context.Background(), message, "")
+	return err
+}
 
-	// Slackからの "ok" レスポンスをチェック
-	if strings.TrimSpace(string(body)) != "ok" {
-		return fmt.Errorf("unexpected Slack response: %s", string(body))
+// ArticleEntry は記事通知を送信箱に保存できる形で作成する
+// （threadの場合はタイトルと、スレッドに返信する要約の2件のメッセージにする）
+func (ns *NotificationService) ArticleEntry(result *TranslationResult, thread bool) (*OutboxEntry, error) {
+	entry := &OutboxEntry{Kind: OutboxArticle, Title: result.TranslatedTitle, GUID: result.GUID, Thread: thread}
+	names := []string{TemplateArticle}
+	if thread {
+		names = []string{TemplateTitle, TemplateSummary}
+	}
+	for _, name := range names {
+		message, err := ns.render(name, ns.articleData(result))
+		if err != nil {
+			return nil, err
+		}
+		entry.Messages = append(entry.Messages, message)
 	}
-
-	return nil
+	return entry, nil
 }
 
-// sendToSlackWithResponse はSlackにメッセージを送信し、タイムスタンプを返す
-func (ns *NotificationService) sendToSlackWithResponse(message *SlackMessage) (string, error) {
-	// JSONにエンコード
-	jsonData, err := json.Marshal(message)
+// OverflowEntry は超過通知を送信箱に保存できる形で作成する
+func (ns *NotificationService) OverflowEntry(overflow *FeedOverflow) (*OutboxEntry, error) {
+	message, err := ns.render(TemplateOverflow, &TemplateData{Feed: overflow.Feed, Overflow: overflow})
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal message: %w", err)
+		return nil, err
 	}
+	title := fmt.Sprintf("%s（%d件）", overflow.Feed.Name, len(overflow.Items))
+	return &OutboxEntry{Kind: OutboxOverflow, Title: title, Messages: []*Message{message}}, nil
+}
 
-	// HTTPリクエストを作成
-	req, err := http.NewRequest("POST", ns.webhookURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+// Deliver は送信箱のメッセージのうち未送信のものを順に送信する
+// （途中で失敗した場合は送信済みの件数を記録し、再送時は続きから送信する）
+func (ns *NotificationService) Deliver(entry *OutboxEntry) (err error) {
+	ctx, span := startSpan(withArticleGUID(context.Background(), entry.GUID), "NotificationService.Deliver",
+		attribute.String("outbox.destination", entry.Destination), attribute.String("outbox.kind", entry.Kind), attribute.Int("outbox.attempts", entry.Attempts))
+	defer func() { endSpan(span, err) }()
+
+	for entry.Sent < len(entry.Messages) {
+		thread, err := ns.notifier.Send(ctx, entry.Messages[entry.Sent], entry.ThreadTS)
+		if err != nil {
+			return fmt.Errorf("failed to send message %d/%d: %w", entry.Sent+1, len(entry.Messages), err)
+		}
+		if entry.Thread && entry.Sent == 0 {
+			entry.ThreadTS = thread
+		}
+		entry.Sent++
 	}
+	return nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	// リクエストを送信
-	resp, err := ns.httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+// articleData は記事通知用のテンプレートデータを作成する
+func (ns *NotificationService) articleData(result *TranslationResult) *TemplateData {
+	return &TemplateData{
+		Result: result,
+		Feed:   result.Feed,
 	}
-	defer resp.Body.Close()
+}
 
-	// レスポンスを読み取り
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
+// render はテンプレートからメッセージを構築し、通知先の情報を設定する
+func (ns *NotificationService) render(name string, data *TemplateData) (*Message, error) {
+	data.Run = ns.runInfo()
+	data.Now = time.Now()
 
-	// ステータスコードをチェック
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Slack API error: status=%d, body=%s", resp.StatusCode, string(body))
+	body, err := ns.renderer.render(name, data)
+	if err != nil {
+		return nil, err
 	}
 
-	// Webhook形式の場合は "ok" レスポンスなので、現在時刻をタイムスタンプとして使用
-	// 注意: Webhook URLではメッセージタイムスタンプを取得できないため、
-	// 実際のBot TokenベースのAPIが必要な場合は別実装が必要
-	timestamp := fmt.Sprintf("%.6f", float64(time.Now().Unix())+float64(time.Now().Nanosecond())/1e9)
-	
-	// Slackからの "ok" レスポンスをチェック
-	if strings.TrimSpace(string(body)) != "ok" {
-		return "", fmt.Errorf("unexpected Slack response: %s", string(body))
+	// フィードごとに投稿先チャンネルを指定している場合はそちらを優先する
+	message := &Message{Channel: ns.channel, Body: body}
+	if data.Feed.Channel != "" {
+		message.Channel = data.Feed.Channel
 	}
-
-	return timestamp, nil
+	return message, nil
 }
 
 // TestSlackConnection はSlack Webhookの接続をテストする
 func (ns *NotificationService) TestSlackConnection() error {
 	log.Println("Testing Slack connection...")
 
-	message := &SlackMessage{
-		Channel:   ns.channel,
+	body, err := json.Marshal(&SlackMessage{
 		Username:  "RSS通知Bot",
 		IconEmoji: ":white_check_mark:",
 		Text:      " RSS通知システムの接続テストです。このメッセージが表示されていれば正常に動作しています。",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	return ns.sendToSlack(message)
+	_, err = ns.notifier.Send(context.Background(), &Message{Channel: ns.channel, Body: body}, "")
+	return err
 }
 
-// truncateText は指定した長さでテキストを切り詰める
-func (ns *NotificationService) truncateText(text string, maxLen int) string {
-	if len(text) <= maxLen {
+// truncateText は指定した文字数でテキストを切り詰める（マルチバイト文字を壊さないようにrune単位で扱う）
+func truncateText(text string, maxLen int) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
 		return text
 	}
-	
+
 	// 単語の境界で切り詰める
-	truncated := text[:maxLen]
-	if lastSpace := strings.LastIndex(truncated, " "); lastSpace > maxLen/2 {
+	truncated := string(runes[:maxLen])
+	if lastSpace := strings.LastIndex(truncated, " "); lastSpace > len(truncated)/2 {
 		truncated = truncated[:lastSpace]
 	}
-	
+
 	return truncated + "..."
 }
 
-// SendBatchNotification は複数の記事をまとめて通知する
-func (ns *NotificationService) SendBatchNotification(results []*TranslationResult) error {
-	if len(results) == 0 {
-		return nil
-	}
-
-	log.Printf("Sending batch notification for %d articles", len(results))
-
-	// バッチ通知のメッセージを構築
-	var attachments []Attachment
-
-	// ヘッダー添付
-	headerAttachment := Attachment{
-		Color: "#36a64f",
-		Title: fmt.Sprintf(" ByteByteGoに %d 件の新しい記事が投稿されました！", len(results)),
-		Footer: "ByteByteGo RSS通知",
-		Timestamp: time.Now().Unix(),
-		MarkdownIn: []string{"text"},
-	}
-	attachments = append(attachments, headerAttachment)
-
-	// 各記事の添付（最大5件まで）
-	maxArticles := 5
-	if len(results) > maxArticles {
-		results = results[:maxArticles]
-	}
-
-	for i, result := range results {
-		attachment := Attachment{
-			Color:     "#2196F3",
-			Title:     result.TranslatedTitle,
-			TitleLink: result.Link,
-			Text:      result.Summary,
-			Fields: []Field{
-				{
-					Title: "原文タイトル",
-					Value: result.OriginalTitle,
-					Short: false,
-				},
-			},
-			MarkdownIn: []string{"text"},
-		}
+// summaryOrDefault は要約文を返す（空の場合は代替メッセージ）
+func summaryOrDefault(result *TranslationResult) string {
+	if result.SummaryFailed {
+		return "要約を生成できませんでした。"
+	}
+	if result.Summary == "" {
+		return "要約が利用できません。"
+	}
+	return result.Summary
+}
 
-		// 最後の記事以外は区切り線を追加
-		if i < len(results)-1 {
-			attachment.Text += "\n---"
+// formatDegraded は翻訳・要約の失敗や代替プロバイダーでの翻訳を知らせる注意書きを作成する（問題がない場合は空文字列）
+func formatDegraded(result *TranslationResult) string {
+	var notes []string
+	if result.TranslationFailed {
+		notes = append(notes, "翻訳に失敗したため原文を表示しています")
+	} else if result.FallbackProvider != "" {
+		provider := result.FallbackProvider
+		if provider == FallbackOpenAI {
+			provider = "OpenAI"
 		}
+		notes = append(notes, fmt.Sprintf("DeepLが利用できなかったため%sで翻訳しました", provider))
+	}
+	if result.SummaryFailed {
+		notes = append(notes, "要約を生成できませんでした")
+	}
+	return strings.Join(notes, " / ")
+}
 
-		attachments = append(attachments, attachment)
+// formatRelevance は関連度を表示用の文字列にする（判定していない場合は空文字列）
+func formatRelevance(result *TranslationResult) string {
+	if result.Relevance == nil {
+		return ""
+	}
+	if result.Relevance.Reason == "" {
+		return fmt.Sprintf("関連度 %d/100", result.Relevance.Score)
 	}
+	return fmt.Sprintf("関連度 %d/100: %s", result.Relevance.Score, result.Relevance.Reason)
+}
 
-	message := &SlackMessage{
-		Channel:     ns.channel,
-		Username:    "RSS通知Bot",
-		IconEmoji:   ":newspaper:",
-		Attachments: attachments,
+// formatSources は重複をまとめた記事の配信元をSlackのリンク形式で列挙する（まとめていない場合は空文字列）
+func formatSources(result *TranslationResult) string {
+	if len(result.Sources) < 2 {
+		return ""
+	}
+	var links []string
+	for _, source := range result.Sources {
+		links = append(links, fmt.Sprintf("<%s|%s>", source.Link, source.Feed.Name))
 	}
+	return strings.Join(links, " / ")
+}
 
-	return ns.sendToSlack(message)
+// formatMarkdownSources は重複をまとめた記事の配信元をMarkdownのリンク形式で列挙する（まとめていない場合は空文字列）
+func formatMarkdownSources(result *TranslationResult) string {
+	if len(result.Sources) < 2 {
+		return ""
+	}
+	var links []string
+	for _, source := range result.Sources {
+		links = append(links, fmt.Sprintf("[%s](%s)", source.Feed.Name, source.Link))
+	}
+	return strings.Join(links, " / ")
 }
 
-// buildTitleMessage はタイトル投稿用のSlackメッセージを構築する
-func (ns *NotificationService) buildTitleMessage(result *TranslationResult) *SlackMessage {
-	return &SlackMessage{
-		Channel:   ns.channel,
-		Username:  "RSS通知Bot",
-		IconEmoji: ":newspaper:",
-		Text:      " *ByteByteGoの新しい記事が投稿されました！*",
-		Attachments: []Attachment{
-			{
-				Color:     "#36a64f",
-				Title:     result.TranslatedTitle,
-				TitleLink: result.Link,
-				Fields: []Field{
-					{
-						Title: "原文タイトル",
-						Value: result.OriginalTitle,
-						Short: false,
-					},
-				},
-				Footer:     "ByteByteGo RSS通知 - 要約は下記スレッドをご確認ください 👇",
-				Timestamp:  time.Now().Unix(),
-				MarkdownIn: []string{"text", "fields"},
-			},
-		},
+// formatHealthAlert はフィードの健全性アラートの見出しを作成する
+func formatHealthAlert(alert *HealthAlert, now time.Time) string {
+	h := alert.Health
+	switch alert.Kind {
+	case HealthBroken:
+		return fmt.Sprintf("%sの取得に%d回連続で失敗しています", alert.Feed.Name, h.ConsecutiveFailures)
+	case HealthSilent:
+		return fmt.Sprintf("%sの新しい記事が%d日間公開されていません", alert.Feed.Name, int(now.Sub(h.LastItemAt).Hours()/24))
+	case HealthDisabled:
+		return fmt.Sprintf("%sは取得の成功と失敗を繰り返しているため、%sまで無効化しました", alert.Feed.Name, formatJST(h.DisabledUntil))
+	case HealthRecovered:
+		return fmt.Sprintf("%sの取得が復旧しました", alert.Feed.Name)
+	default:
+		return fmt.Sprintf("%sの状態: %s", alert.Feed.Name, alert.Kind)
 	}
 }
 
-// buildSummaryMessage は要約投稿用のSlackメッセージを構築する
-func (ns *NotificationService) buildSummaryMessage(result *TranslationResult) *SlackMessage {
-	// 説明文を短縮（Slackの制限に対応）
-	description := result.TranslatedDescription
-	if len(description) > 800 {
-		description = description[:800] + "..."
+// formatJST は日時を日本時間の表示形式に変換する（ゼロ値の場合は「-」）
+func formatJST(t time.Time) string {
+	if t.IsZero() {
+		return "-"
 	}
+	return t.In(time.FixedZone("JST", 9*60*60)).Format("2006-01-02 15:04:05 JST")
+}
+
+// digestArticlesPerMessage は1メッセージに含めるダイジェスト記事数の上限
+// （超過分は切り捨てずに続けて投稿する）
+const digestArticlesPerMessage = 10
+
+// DigestGroup はダイジェスト内でフィードごとにまとめた記事
+type DigestGroup struct {
+	Feed    FeedInfo
+	Results []*TranslationResult
+}
 
-	// 要約文の整形
-	summary := result.Summary
-	if summary == "" {
-		summary = "要約が利用できません。"
+// DigestEntry は集計期間内の記事をフィードごとにまとめたダイジェストを送信箱のメッセージとして作成する
+// （Incoming Webhookでは投稿したメッセージの識別子を取得できずスレッドに返信できないため、
+// 2ページ目以降は1ページ目に続けて別のメッセージとして投稿する）
+func (ns *NotificationService) DigestEntry(results []*TranslationResult, window time.Duration) (*OutboxEntry, error) {
+	pages := paginateDigest(groupByFeed(results), digestArticlesPerMessage)
+
+	entry := &OutboxEntry{Kind: OutboxDigest, Title: fmt.Sprintf("ダイジェスト（%d件）", len(results)), Articles: len(results)}
+	for i, page := range pages {
+		digest := &DigestData{
+			Groups: page,
+			Page:   i + 1,
+			Pages:  len(pages),
+			Total:  len(results),
+			Window: formatWindow(window),
+		}
+		if i == 0 {
+			digest.Text = fmt.Sprintf(" *新着記事ダイジェスト*（%s / %d件）", digest.Window, len(results))
+		} else {
+			digest.Text = fmt.Sprintf("ダイジェストの続き（%d/%d）", i+1, len(pages))
+		}
+
+		message, err := ns.render(TemplateDigest, &TemplateData{Digest: digest})
+		if err != nil {
+			return nil, err
+		}
+		entry.Messages = append(entry.Messages, message)
 	}
+	return entry, nil
+}
 
-	return &SlackMessage{
-		Channel:   ns.channel,
-		Username:  "RSS通知Bot",
-		IconEmoji: ":memo:",
-		Text:      fmt.Sprintf(" **記事要約**\n%s", summary),
-		Attachments: []Attachment{
-			{
-				Color: "#2196F3",
-				Title: "詳細内容",
-				Text:  ns.truncateText(description, 600),
-				Fields: []Field{
-					{
-						Title: "記事リンク",
-						Value: fmt.Sprintf("<%s|記事を読む>", result.Link),
-						Short: true,
-					},
-				},
-				Footer:     "ByteByteGo RSS通知",
-				Timestamp:  time.Now().Unix(),
-				MarkdownIn: []string{"text", "fields"},
-			},
-		},
-	}
-}
\ No newline at end of file
+// groupByFeed は記事をフィードごとにまとめる（フィードの順序は最初に出現した順）
+func groupByFeed(results []*TranslationResult) []DigestGroup {
+	var groups []DigestGroup
+	index := make(map[string]int)
+	for _, result := range results {
+		i, ok := index[result.Feed.URL]
+		if !ok {
+			i = len(groups)
+			index[result.Feed.URL] = i
+			groups = append(groups, DigestGroup{Feed: result.Feed})
+		}
+		groups[i].Results = append(groups[i].Results, result)
+	}
+	return groups
+}
+
+// paginateDigest はフィードのまとまりを保ったまま、1ページあたりの記事数が上限を超えないように分割する
+func paginateDigest(groups []DigestGroup, perPage int) [][]DigestGroup {
+	var pages [][]DigestGroup
+	var current []DigestGroup
+	count := 0
+
+	for _, group := range groups {
+		results := group.Results
+		for len(results) > 0 {
+			if count == perPage {
+				pages = append(pages, current)
+				current = nil
+				count = 0
+			}
+
+			n := perPage - count
+			if n > len(results) {
+				n = len(results)
+			}
+			current = append(current, DigestGroup{Feed: group.Feed, Results: results[:n]})
+			count += n
+			results = results[n:]
+		}
+	}
+	if len(current) > 0 {
+		pages = append(pages, current)
+	}
+
+	return pages
+}
+
+// formatWindow は集計期間を表示用の文字列に変換する
+func formatWindow(window time.Duration) string {
+	day := 24 * time.Hour
+	if window%day == 0 {
+		return fmt.Sprintf("過去%d日間", window/day)
+	}
+	return fmt.Sprintf("過去%s", window)
+}
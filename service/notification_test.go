@@ -0,0 +1,497 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"short", "hello", 10, "hello"},
+		{"exact", "hello", 5, "hello"},
+		{"word boundary", "hello wonderful world", 15, "hello wonderful..."},
+		{"no boundary", "abcdefghij", 5, "abcde..."},
+		{"multibyte", "あいうえおかきくけこ", 5, "あいうえお..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateText(tt.in, tt.maxLen); got != tt.want {
+				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.in, tt.maxLen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateDigest(t *testing.T) {
+	var results []*TranslationResult
+	for i := 0; i < 3; i++ {
+		results = append(results, &TranslationResult{Link: fmt.Sprintf("a%d", i), Feed: FeedInfo{URL: "a"}})
+	}
+	for i := 0; i < 12; i++ {
+		results = append(results, &TranslationResult{Link: fmt.Sprintf("b%d", i), Feed: FeedInfo{URL: "b"}})
+	}
+
+	pages := paginateDigest(groupByFeed(results), 10)
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want 2", len(pages))
+	}
+
+	count := func(page []DigestGroup) int {
+		n := 0
+		for _, group := range page {
+			n += len(group.Results)
+		}
+		return n
+	}
+	if count(pages[0]) != 10 || count(pages[1]) != 5 {
+		t.Errorf("page sizes = %d, %d, want 10, 5", count(pages[0]), count(pages[1]))
+	}
+	if len(pages[0]) != 2 || pages[1][0].Feed.URL != "b" {
+		t.Errorf("groups were not kept together across pages: %+v", pages)
+	}
+}
+
+// sampleTemplateData はすべての組み込みテンプレートを実行できるデータを返す
+func sampleTemplateData() *TemplateData {
+	feed := FeedInfo{URL: "https://example.com/feed", Name: "Example", Link: "https://example.com", ImageURL: "https://example.com/icon.png", Footer: "Example RSS通知"}
+	result := &TranslationResult{
+		OriginalTitle:         `Title with "quotes" & <tags>`,
+		TranslatedTitle:       "翻訳タイトル",
+		TranslatedDescription: "説明\n2行目",
+		Summary:               "要約",
+		Link:                  "https://example.com/post?a=1&b=2",
+		Feed:                  feed,
+		ImageURL:              "https://example.com/post.png?a=1&b=2",
+		Relevance:             &Relevance{Score: 80, Reason: `"キャッシュ"に関する記事`},
+		Sources: []ItemSource{
+			{Feed: feed, Link: "https://example.com/post?a=1&b=2"},
+			{Feed: FeedInfo{Name: "Hacker News"}, Link: "https://news.ycombinator.com/item?id=1"},
+		},
+	}
+	return &TemplateData{
+		Result: result,
+		Feed:   feed,
+		Feeds:  []FeedInfo{feed},
+		Digest: &DigestData{Text: "ダイジェスト", Groups: groupByFeed([]*TranslationResult{result}), Page: 1, Pages: 1, Total: 1},
+		Error:  "something failed",
+		Overflow: &FeedOverflow{Feed: feed, Items: []*FeedItem{
+			{Title: `Skipped "post"`, Link: "https://example.com/skipped?a=1&b=2"},
+		}},
+		Health: &HealthAlert{Kind: HealthBroken, Feed: feed, Health: FeedHealth{
+			ConsecutiveFailures: 3,
+			LastError:           `http error: 404 "Not Found"`,
+			ItemsPerDay:         0.5,
+		}},
+		Report: &RunReport{
+			StartedAt:  time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+			FinishedAt: time.Date(2024, 1, 15, 9, 1, 30, 0, time.UTC),
+			New:        2,
+			Posted:     1,
+			Failures: []RunFailure{
+				{Stage: StageNotify, Target: `Title with "quotes"`, Reason: "unexpected Slack response: invalid_payload"},
+			},
+		},
+	}
+}
+
+// builtinFormats は組み込みテンプレートのディレクトリと、生成したメッセージに含まれるべきキー
+var builtinFormats = map[string][]string{
+	FormatBlocks:      {"blocks", "attachments"},
+	FormatAttachments: {"attachments"},
+	NotifierTeams:     {"attachments"},
+	NotifierDiscord:   {"embeds"},
+	NotifierWebhook:   {"event"},
+}
+
+func TestBuiltinTemplatesProduceValidMessages(t *testing.T) {
+	for format, keys := range builtinFormats {
+		renderer, err := newTemplateRenderer(format, "")
+		if err != nil {
+			t.Fatalf("newTemplateRenderer(%s) error = %v", format, err)
+		}
+
+		for _, name := range templateNames {
+			t.Run(format+"/"+name, func(t *testing.T) {
+				message, err := renderer.render(name, sampleTemplateData())
+				if err != nil {
+					t.Fatalf("render() error = %v", err)
+				}
+				var fields map[string]json.RawMessage
+				if err := json.Unmarshal(message, &fields); err != nil {
+					t.Fatalf("message is not a JSON object: %v", err)
+				}
+				found := false
+				for _, key := range keys {
+					found = found || len(fields[key]) > 0
+				}
+				if !found {
+					t.Errorf("message has none of %v: %s", keys, message)
+				}
+			})
+		}
+	}
+}
+
+func TestDegradedResultIsRendered(t *testing.T) {
+	for format := range builtinFormats {
+		renderer, err := newTemplateRenderer(format, "")
+		if err != nil {
+			t.Fatalf("newTemplateRenderer(%s) error = %v", format, err)
+		}
+
+		data := sampleTemplateData()
+		data.Result.TranslationFailed = true
+		data.Result.SummaryFailed = true
+		data.Result.Summary = ""
+		for _, name := range []string{TemplateArticle, TemplateTitle, TemplateSummary, TemplateDigest} {
+			message, err := renderer.render(name, data)
+			if err != nil {
+				t.Fatalf("%s/%s: render() error = %v", format, name, err)
+			}
+			if !strings.Contains(string(message), "翻訳に失敗したため原文を表示しています / 要約を生成できませんでした") {
+				t.Errorf("%s/%s: degraded notice is missing: %s", format, name, message)
+			}
+		}
+	}
+
+	result := &TranslationResult{FallbackProvider: FallbackOpenAI}
+	if got := formatDegraded(result); got != "DeepLが利用できなかったためOpenAIで翻訳しました" {
+		t.Errorf("formatDegraded() = %q", got)
+	}
+	if got := formatDegraded(&TranslationResult{}); got != "" {
+		t.Errorf("formatDegraded() = %q, want empty for a complete result", got)
+	}
+}
+
+func TestArticleWithoutOptionalFieldsIsRendered(t *testing.T) {
+	for format := range builtinFormats {
+		renderer, err := newTemplateRenderer(format, "")
+		if err != nil {
+			t.Fatalf("newTemplateRenderer(%s) error = %v", format, err)
+		}
+
+		for _, link := range []string{"", "https://example.com/post"} {
+			data := sampleTemplateData()
+			data.Result.Link = link
+			data.Result.Sources = nil
+			data.Result.TranslatedDescription = ""
+			for _, name := range []string{TemplateArticle, TemplateTitle, TemplateSummary} {
+				message, err := renderer.render(name, data)
+				if err != nil {
+					t.Fatalf("%s/%s: render() error = %v", format, name, err)
+				}
+				// Slackなどは空のURLを含むメッセージ全体を拒否する
+				if strings.Contains(string(message), `"url": ""`) {
+					t.Errorf("%s/%s: message contains an empty url: %s", format, name, message)
+				}
+			}
+		}
+	}
+}
+
+func TestDigestAndOverflowWithoutLinkAreRendered(t *testing.T) {
+	feed := FeedInfo{URL: "https://example.com/feed", Name: "Example"}
+	result := &TranslationResult{OriginalTitle: "No link", TranslatedTitle: "リンクなし", Summary: "要約", Feed: feed}
+	data := &TemplateData{
+		Feed:     feed,
+		Digest:   &DigestData{Groups: groupByFeed([]*TranslationResult{result}), Page: 1, Pages: 1, Total: 1, Window: formatWindow(24 * time.Hour)},
+		Overflow: &FeedOverflow{Feed: feed, Items: []*FeedItem{{Title: "No link"}}},
+	}
+
+	for format := range builtinFormats {
+		renderer, err := newTemplateRenderer(format, "")
+		if err != nil {
+			t.Fatalf("newTemplateRenderer(%s) error = %v", format, err)
+		}
+
+		for _, name := range []string{TemplateDigest, TemplateOverflow} {
+			message, err := renderer.render(name, data)
+			if err != nil {
+				t.Fatalf("%s/%s: render() error = %v", format, name, err)
+			}
+			if !json.Valid(message) {
+				t.Errorf("%s/%s: message is not valid JSON: %s", format, name, message)
+			}
+			// 空のリンクはSlackでは<|タイトル>、Discordでは[タイトル]()になり、メッセージ全体を拒否される
+			for _, broken := range []string{"<|", "]()", `"url": ""`} {
+				if strings.Contains(string(message), broken) {
+					t.Errorf("%s/%s: message contains an empty link %q: %s", format, name, broken, message)
+				}
+			}
+		}
+	}
+}
+
+func TestCustomTemplateOverridesBuiltin(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{"text": {{json (printf "%s / %s" .Feed.Name .Result.TranslatedTitle)}}}`
+	if err := os.WriteFile(filepath.Join(dir, "article.json.tmpl"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := newTemplateRenderer(FormatBlocks, dir)
+	if err != nil {
+		t.Fatalf("newTemplateRenderer() error = %v", err)
+	}
+
+	body, err := renderer.render(TemplateArticle, sampleTemplateData())
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	var message SlackMessage
+	if err := json.Unmarshal(body, &message); err != nil || message.Text != "Example / 翻訳タイトル" || message.Blocks != nil {
+		t.Errorf("custom template was not used: %s", body)
+	}
+
+	// 上書きしていないテンプレートは組み込みのものを使う
+	body, err = renderer.render(TemplateSummary, sampleTemplateData())
+	if err != nil || !strings.Contains(string(body), `"blocks"`) {
+		t.Errorf("builtin summary template was not used: %s, %v", body, err)
+	}
+}
+
+func TestInvalidTemplateOutput(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "article.json.tmpl"), []byte(`{"text": {{.Result.TranslatedTitle}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := newTemplateRenderer(FormatBlocks, dir)
+	if err != nil {
+		t.Fatalf("newTemplateRenderer() error = %v", err)
+	}
+	if _, err := renderer.render(TemplateArticle, sampleTemplateData()); err == nil || !strings.Contains(err.Error(), "invalid JSON") {
+		t.Errorf("render() error = %v, want invalid JSON error", err)
+	}
+}
+
+// deliverArticle はアプリと同じく記事通知を送信箱のメッセージとして作成し、Deliverで送信する
+func deliverArticle(t *testing.T, ns *NotificationService, result *TranslationResult, thread bool) error {
+	t.Helper()
+
+	entry, err := ns.ArticleEntry(result, thread)
+	if err != nil {
+		t.Fatalf("ArticleEntry() error = %v", err)
+	}
+	return ns.Deliver(entry)
+}
+
+func TestDeliverArticleWithThread(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#test", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, true); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	messages := slack.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	if messages[0]["channel"] != "#test" || messages[0]["thread_ts"] != nil {
+		t.Errorf("title message = %v", messages[0])
+	}
+	if messages[1]["thread_ts"] == nil {
+		t.Errorf("summary message was not posted in thread: %v", messages[1])
+	}
+}
+
+func TestDeliverArticleFeedChannel(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#test", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := sampleTemplateData().Result
+	result.Feed.Channel = "#golang"
+	if err := deliverArticle(t, ns, result, true); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if err := ns.SendErrorNotification("failed"); err != nil {
+		t.Fatalf("SendErrorNotification() error = %v", err)
+	}
+
+	messages := slack.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	for i, want := range []string{"#golang", "#golang", "#test"} {
+		if messages[i]["channel"] != want {
+			t.Errorf("messages[%d] channel = %v, want %s", i, messages[i]["channel"], want)
+		}
+	}
+}
+
+func TestDeliverArticleEntry(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#test", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := ns.ArticleEntry(sampleTemplateData().Result, true)
+	if err != nil {
+		t.Fatalf("ArticleEntry() error = %v", err)
+	}
+	if len(entry.Messages) != 2 || entry.Kind != OutboxArticle || entry.Title != "翻訳タイトル" {
+		t.Fatalf("entry = %+v, want title and summary messages", entry)
+	}
+
+	slack.SetFail(true)
+	if err := ns.Deliver(entry); err == nil || entry.Sent != 0 {
+		t.Fatalf("Deliver() error = %v, sent = %d, want failure before any message", err, entry.Sent)
+	}
+
+	slack.SetFail(false)
+	if err := ns.Deliver(entry); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	messages := slack.Messages()
+	if len(messages) != 2 || entry.Sent != 2 || messages[1]["thread_ts"] != entry.ThreadTS || entry.ThreadTS == "" {
+		t.Errorf("messages = %v, want the summary replied in the title's thread", messages)
+	}
+
+	// タイトルの送信後に失敗した場合は、再送時に要約のみをスレッドに返信する
+	resumed := &OutboxEntry{Thread: true, Sent: 1, ThreadTS: "1700000000.000001", Messages: entry.Messages}
+	if err := ns.Deliver(resumed); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	messages = slack.Messages()
+	if len(messages) != 3 || messages[2]["thread_ts"] != "1700000000.000001" {
+		t.Errorf("messages = %v, want only the summary resent", messages)
+	}
+}
+
+func TestDigestEntryOverflowsIntoPages(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#digest", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []*TranslationResult
+	for i := 0; i < 23; i++ {
+		results = append(results, &TranslationResult{
+			TranslatedTitle: fmt.Sprintf("記事%d", i),
+			Link:            fmt.Sprintf("https://example.com/%d", i),
+			Feed:            FeedInfo{URL: "https://example.com/feed", Name: "Example"},
+		})
+	}
+
+	entry, err := ns.DigestEntry(results, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("DigestEntry() error = %v", err)
+	}
+	if entry.Kind != OutboxDigest || entry.Articles != 23 {
+		t.Errorf("entry = %+v, want a digest of 23 articles", entry)
+	}
+	if err := ns.Deliver(entry); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	messages := slack.Messages()
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want 3", len(messages))
+	}
+	if !strings.Contains(messages[0]["text"].(string), "過去7日間 / 23件") {
+		t.Errorf("digest header = %q", messages[0]["text"])
+	}
+	// Incoming Webhookではスレッドに返信できないため、2ページ目以降は続けて投稿する
+	for _, message := range messages[1:] {
+		if message["thread_ts"] != nil {
+			t.Errorf("overflow page was posted with a made-up thread_ts: %v", message["text"])
+		}
+	}
+
+	// 切り捨てずに全記事が含まれていること
+	data, _ := json.Marshal(messages)
+	for _, result := range results {
+		if !strings.Contains(string(data), result.Link) {
+			t.Errorf("digest is missing %s", result.Link)
+		}
+	}
+}
+
+func TestSendToSlackError(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	slack.SetFail(true)
+
+	ns, err := NewNotificationService(slack.URL, "#test", FormatAttachments, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, false); err == nil {
+		t.Error("Deliver() error = nil, want error")
+	}
+}
+
+func TestDeliverOverflow(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#test", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overflow := &FeedOverflow{Feed: FeedInfo{Name: "Example", Footer: "Example RSS通知"}}
+	for i := 0; i < 25; i++ {
+		overflow.Items = append(overflow.Items, &FeedItem{Title: fmt.Sprintf("Post %d", i), Link: fmt.Sprintf("https://example.com/%d", i)})
+	}
+	entry, err := ns.OverflowEntry(overflow)
+	if err != nil {
+		t.Fatalf("OverflowEntry() error = %v", err)
+	}
+	if err := ns.Deliver(entry); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	messages := slack.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	blocks := fmt.Sprint(messages[0]["blocks"])
+	if !strings.Contains(blocks, "<https://example.com/19|Post 19>") || strings.Contains(blocks, "Post 20") || !strings.Contains(blocks, "ほか5件") {
+		t.Errorf("overflow list = %s", blocks)
+	}
+}
+
+func TestSendRunReport(t *testing.T) {
+	slack := fake.NewSlackServer(t)
+	ns, err := NewNotificationService(slack.URL, "#ops", FormatBlocks, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := &RunReport{StartedAt: time.Now(), FinishedAt: time.Now(), FeedsChecked: 3, New: 12}
+	for i := 0; i < 12; i++ {
+		report.AddFailure(StageNotify, fmt.Sprintf("Post %d", i), fmt.Errorf("slack error %d", i))
+	}
+	if err := ns.SendRunReport(report); err != nil {
+		t.Fatalf("SendRunReport() error = %v", err)
+	}
+
+	messages := slack.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	blocks := fmt.Sprint(messages[0]["blocks"])
+	if !strings.Contains(blocks, "12件の失敗") || !strings.Contains(blocks, "Post 9: slack error 9") || strings.Contains(blocks, "Post 10") || !strings.Contains(blocks, "ほか2件") {
+		t.Errorf("run report = %s", blocks)
+	}
+}
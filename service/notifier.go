@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// 通知先の種類
+const (
+	NotifierSlack      = "slack"      // Slack Incoming Webhook
+	NotifierTeams      = "teams"      // Microsoft Teams（Adaptive Card）
+	NotifierDiscord    = "discord"    // Discord Webhook
+	NotifierMattermost = "mattermost" // Mattermost Incoming Webhook（Slack互換）
+	NotifierWebhook    = "webhook"    // テンプレートで本文を定義する汎用JSON Webhook
+)
+
+// Message はテンプレートから作成した送信前のメッセージ
+type Message struct {
+	Channel string          `json:"channel,omitempty"` // 投稿先チャンネル（指定できる通知先のみ使用する）
+	Body    json.RawMessage `json:"body"`              // テンプレートが生成したJSON
+}
+
+// Notifier は通知先のサービスにメッセージを送信する
+type Notifier interface {
+	// Send はメッセージを送信し、後続のメッセージを返信するスレッドの識別子を返す
+	// （threadを指定するとスレッドに返信する。スレッドに対応しない通知先はthreadを無視して空文字列を返す）
+	Send(ctx context.Context, message *Message, thread string) (string, error)
+}
+
+// NewNotifier は通知先の種類に応じたNotifierを作成する（空の場合はSlack）
+func NewNotifier(kind, webhookURL string, httpClient *http.Client) (Notifier, error) {
+	switch kind {
+	case "", NotifierSlack:
+		return &SlackNotifier{webhookURL: webhookURL, httpClient: httpClient}, nil
+	case NotifierMattermost:
+		return &MattermostNotifier{webhookURL: webhookURL, httpClient: httpClient}, nil
+	case NotifierTeams:
+		return &TeamsNotifier{webhookURL: webhookURL, httpClient: httpClient}, nil
+	case NotifierDiscord:
+		return &DiscordNotifier{webhookURL: webhookURL, httpClient: httpClient}, nil
+	case NotifierWebhook:
+		return &WebhookNotifier{webhookURL: webhookURL, httpClient: httpClient}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", kind)
+	}
+}
+
+// instrumentedNotifier は送信ごとにスパンを作成し、送信の失敗をメトリクスに記録するNotifier
+type instrumentedNotifier struct {
+	Notifier
+	kind    string
+	metrics *Metrics
+}
+
+// Send はメッセージを送信し、結果をスパンとメトリクスに記録する
+func (n *instrumentedNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	ctx, span := startSpan(ctx, "Notifier.Send", AttrNotifier.String(n.kind), attribute.Bool("notifier.thread_reply", thread != ""))
+	ts, err := n.Notifier.Send(ctx, message, thread)
+	endSpan(span, err)
+	if err != nil {
+		n.metrics.IncSendFailure(n.kind)
+	}
+	return ts, err
+}
+
+// templateFormat は通知先の種類とメッセージ形式から組み込みテンプレートのディレクトリ名を決める
+// （SlackはBlock Kitとattachmentsを選べる。MattermostはBlock Kitに対応しないためattachmentsを使う）
+func templateFormat(kind, format string) string {
+	switch kind {
+	case "", NotifierSlack:
+		return format
+	case NotifierMattermost:
+		return FormatAttachments
+	default:
+		return kind
+	}
+}
+
+// postJSON はJSONをPOSTし、ステータスコードとレスポンスボディを返す
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, respBody, nil
+}
+
+// isSuccess は2xxのステータスコードかを返す
+func isSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
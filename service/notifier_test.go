@@ -0,0 +1,34 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewNotifier(t *testing.T) {
+	tests := []struct {
+		kind   string
+		format string
+		want   string
+	}{
+		{"", FormatBlocks, FormatBlocks},
+		{NotifierSlack, FormatAttachments, FormatAttachments},
+		{NotifierMattermost, FormatBlocks, FormatAttachments},
+		{NotifierTeams, FormatBlocks, NotifierTeams},
+		{NotifierDiscord, FormatBlocks, NotifierDiscord},
+		{NotifierWebhook, FormatBlocks, NotifierWebhook},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewNotifier(tt.kind, "https://example.com/hook", http.DefaultClient); err != nil {
+			t.Errorf("NewNotifier(%q) error = %v", tt.kind, err)
+		}
+		if got := templateFormat(tt.kind, tt.format); got != tt.want {
+			t.Errorf("templateFormat(%q, %q) = %q, want %q", tt.kind, tt.format, got, tt.want)
+		}
+	}
+
+	if _, err := NewNotifier("irc", "https://example.com/hook", http.DefaultClient); err == nil {
+		t.Error("NewNotifier(irc) error = nil, want error")
+	}
+}
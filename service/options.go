@@ -0,0 +1,110 @@
+package service
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultHTTPTimeout は外部APIへのリクエストのタイムアウト
+const defaultHTTPTimeout = 30 * time.Second
+
+// Option はサービスの任意設定（HTTPクライアントや接続先の差し替え）
+type Option func(*options)
+
+// options はOptionで設定される値
+type options struct {
+	httpClient    *http.Client
+	openAIBaseURL string
+	now           func() time.Time
+	resolveURLs   bool
+	feedState     *FeedStateStore
+	catchUpPolicy CatchUpPolicy
+	feedHealth    *HealthStore
+	healthPolicy  HealthPolicy
+	metrics       *Metrics
+
+	translationFallback string
+	notifierType        string
+}
+
+// WithHTTPClient は外部APIへのリクエストに使用するHTTPクライアントを指定する
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithOpenAIBaseURL はOpenAI APIの接続先を指定する（互換APIやテスト用のサーバーを使う場合）
+func WithOpenAIBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.openAIBaseURL = baseURL
+	}
+}
+
+// WithClock は記事の新着判定に使う現在時刻の取得方法を指定する（記録した通信の再生時など）
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithURLResolution は重複判定の際に記事ページを取得し、リダイレクト先や canonical URL で比較するかを指定する
+func WithURLResolution(enabled bool) Option {
+	return func(o *options) {
+		o.resolveURLs = enabled
+	}
+}
+
+// WithFeedState はフィードごとのウォーターマークを保存し、前回実行以降の記事を新着とするようにする
+func WithFeedState(store *FeedStateStore, policy CatchUpPolicy) Option {
+	return func(o *options) {
+		o.feedState = store
+		o.catchUpPolicy = policy
+	}
+}
+
+// WithFeedHealth はフィードごとの取得の成否と記事の公開状況を記録し、異常をアラートとして報告するようにする
+func WithFeedHealth(store *HealthStore, policy HealthPolicy) Option {
+	return func(o *options) {
+		o.feedHealth = store
+		o.healthPolicy = policy
+	}
+}
+
+// WithTranslationFallback はDeepLでの翻訳に失敗した場合に使う代替プロバイダーを指定する（FallbackOpenAI or FallbackNone）
+func WithTranslationFallback(provider string) Option {
+	return func(o *options) {
+		o.translationFallback = provider
+	}
+}
+
+// WithNotifierType は通知先の種類を指定する（NotifierSlack, NotifierTeams, NotifierDiscord, NotifierMattermost, NotifierWebhook）
+func WithNotifierType(kind string) Option {
+	return func(o *options) {
+		o.notifierType = kind
+	}
+}
+
+// WithMetrics はフィードの取得・翻訳・要約・通知の結果をメトリクスに記録するようにする
+func WithMetrics(metrics *Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// applyOptions はOptionを適用した設定を返す
+func applyOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.httpClient == nil {
+		o.httpClient = &http.Client{
+			Timeout: defaultHTTPTimeout,
+		}
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	return o
+}
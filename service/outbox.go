@@ -0,0 +1,252 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxOutboxBackoff は再送までの待ち時間の上限
+const maxOutboxBackoff = 24 * time.Hour
+
+// 送信箱のメッセージの種類
+const (
+	OutboxArticle  = "article"  // 記事通知（スレッド形式の場合はタイトルと要約）
+	OutboxOverflow = "overflow" // 超過通知
+	OutboxDigest   = "digest"   // ダイジェスト（メールは宛先ごとに1件）
+)
+
+// OutboxPolicy は送信に失敗したメッセージを再送する設定
+type OutboxPolicy struct {
+	MaxAttempts int           // この回数送信に失敗したらデッドレターに移す（0の場合は移さない）
+	Backoff     time.Duration // 最初の再送までの待ち時間（失敗するごとに2倍にし、24時間を上限とする）
+}
+
+// OutboxEntry は送信箱に保存した送信待ちのメッセージ
+type OutboxEntry struct {
+	ID          int        `json:"id"`
+	Destination string     `json:"destination"`        // 通知先の名前（Webhook URLは保存しない）
+	Kind        string     `json:"kind"`               // OutboxArticle, OutboxOverflow, OutboxDigest
+	Title       string     `json:"title"`              // 一覧表示用（記事のタイトルなど）
+	GUID        string     `json:"guid,omitempty"`     // 記事のGUID（トレースの属性に使う）
+	Articles    int        `json:"articles,omitempty"` // ダイジェストに含む記事数（送信できた時点で投稿数に数える）
+	Messages    []*Message `json:"messages"`           // 先頭から順に送信する
+	Thread      bool       `json:"thread,omitempty"`
+	Sent        int        `json:"sent"`                // 送信済みのメッセージ数（途中で失敗した場合は続きから再送する）
+	ThreadTS    string     `json:"thread_ts,omitempty"` // 1件目を送信した際のスレッドの識別子
+	Attempts    int        `json:"attempts"`            // 送信に失敗した回数
+	CreatedAt   time.Time  `json:"created_at"`
+	NextAttempt time.Time  `json:"next_attempt"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// outboxState は送信箱の状態ファイルの内容
+type outboxState struct {
+	NextID  int            `json:"next_id"`
+	Pending []*OutboxEntry `json:"pending"`
+	Dead    []*OutboxEntry `json:"dead"` // 再送の上限に達したメッセージ（デッドレター）
+}
+
+// Outbox は送信前のメッセージを永続化し、送信に失敗したものを次回以降の実行で再送する
+// （デーモンモードでは管理APIからも参照するため、一覧の変更はロックを取得して行う）
+type Outbox struct {
+	mu     sync.Mutex
+	path   string
+	policy OutboxPolicy
+	state  outboxState
+}
+
+// NewOutbox は状態ファイルを読み込んでOutboxを作成する
+func NewOutbox(path string, policy OutboxPolicy) (*Outbox, error) {
+	ob := &Outbox{
+		path:   path,
+		policy: policy,
+		state:  outboxState{NextID: 1},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ob, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &ob.state); err != nil {
+		return nil, fmt.Errorf("failed to parse outbox %s: %w", path, err)
+	}
+
+	return ob, nil
+}
+
+// Add はメッセージを送信待ちに追加する（IDと作成日時を設定する）
+func (ob *Outbox) Add(entry *OutboxEntry, now time.Time) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	entry.ID = ob.state.NextID
+	ob.state.NextID++
+	entry.CreatedAt = now
+	ob.state.Pending = append(ob.state.Pending, entry)
+}
+
+// Due は再送時刻を過ぎた送信待ちのメッセージを古い順に返す
+func (ob *Outbox) Due(now time.Time) []*OutboxEntry {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	var due []*OutboxEntry
+	for _, entry := range ob.state.Pending {
+		if !entry.NextAttempt.After(now) {
+			due = append(due, entry)
+		}
+	}
+	return due
+}
+
+// Pending は送信待ちのメッセージを返す
+func (ob *Outbox) Pending() []*OutboxEntry {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return append([]*OutboxEntry(nil), ob.state.Pending...)
+}
+
+// DeadLetters は再送の上限に達したメッセージを返す
+func (ob *Outbox) DeadLetters() []*OutboxEntry {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	return append([]*OutboxEntry(nil), ob.state.Dead...)
+}
+
+// Snapshot は一覧表示用に送信待ちとデッドレターの複製を返す（実行中の送信と並行して呼び出せる）
+// （送信中に更新するメッセージ・送信済みの数・スレッドの識別子は複製しない）
+func (ob *Outbox) Snapshot() (pending, dead []OutboxEntry) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	summarize := func(entries []*OutboxEntry) []OutboxEntry {
+		copies := make([]OutboxEntry, 0, len(entries))
+		for _, entry := range entries {
+			copies = append(copies, OutboxEntry{
+				ID:          entry.ID,
+				Destination: entry.Destination,
+				Kind:        entry.Kind,
+				Title:       entry.Title,
+				GUID:        entry.GUID,
+				Articles:    entry.Articles,
+				Thread:      entry.Thread,
+				Attempts:    entry.Attempts,
+				CreatedAt:   entry.CreatedAt,
+				NextAttempt: entry.NextAttempt,
+				LastError:   entry.LastError,
+			})
+		}
+		return copies
+	}
+	return summarize(ob.state.Pending), summarize(ob.state.Dead)
+}
+
+// Delivered は送信できたメッセージを送信待ちから取り除く
+func (ob *Outbox) Delivered(entry *OutboxEntry) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.state.Pending = removeEntry(ob.state.Pending, entry)
+}
+
+// Failed は送信の失敗を記録して次の再送時刻を決める
+// （失敗が上限に達した場合はデッドレターに移してtrueを返す）
+func (ob *Outbox) Failed(entry *OutboxEntry, sendErr error, now time.Time) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	entry.Attempts++
+	entry.LastError = sendErr.Error()
+
+	if ob.policy.MaxAttempts > 0 && entry.Attempts >= ob.policy.MaxAttempts {
+		ob.deadLetter(entry, sendErr)
+		return true
+	}
+
+	backoff := ob.policy.Backoff
+	for i := 1; i < entry.Attempts && backoff < maxOutboxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxOutboxBackoff {
+		backoff = maxOutboxBackoff
+	}
+	entry.NextAttempt = now.Add(backoff)
+	return false
+}
+
+// DeadLetter はメッセージを再送せずにデッドレターに移す（通知先が削除された場合など）
+func (ob *Outbox) DeadLetter(entry *OutboxEntry, reason error) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	ob.deadLetter(entry, reason)
+}
+
+// deadLetter はメッセージをデッドレターに移す（呼び出し側でロックを取得していること）
+func (ob *Outbox) deadLetter(entry *OutboxEntry, reason error) {
+	entry.LastError = reason.Error()
+	ob.state.Pending = removeEntry(ob.state.Pending, entry)
+	ob.state.Dead = append(ob.state.Dead, entry)
+}
+
+// Replay はデッドレターのメッセージを送信待ちに戻し、次回の実行で送信するようにする（見つからない場合はfalse）
+func (ob *Outbox) Replay(id int) bool {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	for _, entry := range ob.state.Dead {
+		if entry.ID == id {
+			ob.replay(entry)
+			return true
+		}
+	}
+	return false
+}
+
+// ReplayAll はすべてのデッドレターを送信待ちに戻し、戻した件数を返す
+func (ob *Outbox) ReplayAll() int {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	dead := append([]*OutboxEntry(nil), ob.state.Dead...)
+	for _, entry := range dead {
+		ob.replay(entry)
+	}
+	return len(dead)
+}
+
+// Save は状態をファイルに書き込む
+func (ob *Outbox) Save() error {
+	ob.mu.Lock()
+	data, err := json.MarshalIndent(ob.state, "", "  ")
+	ob.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox: %w", err)
+	}
+	return writeFileAtomic(ob.path, data)
+}
+
+// replay はデッドレターのメッセージを失敗回数をリセットして送信待ちに戻す（呼び出し側でロックを取得していること）
+func (ob *Outbox) replay(entry *OutboxEntry) {
+	entry.Attempts = 0
+	entry.NextAttempt = time.Time{}
+	ob.state.Dead = removeEntry(ob.state.Dead, entry)
+	ob.state.Pending = append(ob.state.Pending, entry)
+}
+
+// removeEntry はメッセージを一覧から取り除く
+func removeEntry(entries []*OutboxEntry, target *OutboxEntry) []*OutboxEntry {
+	kept := make([]*OutboxEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry != target {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutboxBackoffAndDeadLetter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "outbox.json")
+	outbox, err := NewOutbox(path, OutboxPolicy{MaxAttempts: 3, Backoff: 10 * time.Minute})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	entry := &OutboxEntry{Destination: "default", Kind: OutboxArticle, Title: "Post", Messages: []*Message{{Body: json.RawMessage(`{"text":"hello"}`)}}}
+	outbox.Add(entry, now)
+	if entry.ID != 1 || len(outbox.Due(now)) != 1 {
+		t.Fatalf("entry = %+v, want it due immediately", entry)
+	}
+
+	// 失敗するごとに再送までの待ち時間を2倍にする
+	sendErr := errors.New("Slack API error: status=500")
+	for i, wait := range []time.Duration{10 * time.Minute, 20 * time.Minute} {
+		if outbox.Failed(entry, sendErr, now) {
+			t.Fatalf("attempt %d moved to dead letters too early", i+1)
+		}
+		if !entry.NextAttempt.Equal(now.Add(wait)) {
+			t.Errorf("attempt %d NextAttempt = %v, want %v later", i+1, entry.NextAttempt, wait)
+		}
+		if len(outbox.Due(now.Add(wait-time.Second))) != 0 || len(outbox.Due(now.Add(wait))) != 1 {
+			t.Errorf("attempt %d: unexpected due entries", i+1)
+		}
+	}
+
+	// 上限に達したらデッドレターに移す
+	if !outbox.Failed(entry, sendErr, now) {
+		t.Fatal("Failed() = false, want the entry moved to dead letters")
+	}
+	if len(outbox.Pending()) != 0 || len(outbox.DeadLetters()) != 1 || entry.LastError != sendErr.Error() {
+		t.Fatalf("pending = %d, dead = %d, entry = %+v", len(outbox.Pending()), len(outbox.DeadLetters()), entry)
+	}
+	if err := outbox.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// 再起動後もデッドレターを送信待ちに戻せる
+	reloaded, err := NewOutbox(path, OutboxPolicy{})
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	if reloaded.Replay(99) {
+		t.Error("Replay(99) = true for an unknown id")
+	}
+	if !reloaded.Replay(1) {
+		t.Fatal("Replay(1) = false")
+	}
+	due := reloaded.Due(now)
+	if len(due) != 1 || due[0].Attempts != 0 || !strings.Contains(string(due[0].Messages[0].Body), "hello") || len(reloaded.DeadLetters()) != 0 {
+		t.Fatalf("due = %+v, want the replayed entry", due)
+	}
+
+	// IDは再起動後も重複しない
+	next := &OutboxEntry{Destination: "default"}
+	reloaded.Add(next, now)
+	if next.ID != 2 {
+		t.Errorf("ID = %d, want 2", next.ID)
+	}
+	reloaded.Delivered(due[0])
+	if pending := reloaded.Pending(); len(pending) != 1 || pending[0] != next {
+		t.Errorf("pending = %+v, want only the new entry", pending)
+	}
+}
+
+func TestOutboxReplayAll(t *testing.T) {
+	outbox, err := NewOutbox(filepath.Join(t.TempDir(), "outbox.json"), OutboxPolicy{MaxAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		entry := &OutboxEntry{Destination: "default"}
+		outbox.Add(entry, now)
+		outbox.Failed(entry, errors.New("down"), now)
+	}
+	if got := outbox.ReplayAll(); got != 3 {
+		t.Errorf("ReplayAll() = %d, want 3", got)
+	}
+	if len(outbox.Due(now)) != 3 || len(outbox.DeadLetters()) != 0 {
+		t.Errorf("due = %d, dead = %d", len(outbox.Due(now)), len(outbox.DeadLetters()))
+	}
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 出力するフィードの形式
+const (
+	FeedFormatAtom = "atom" // Atom 1.0
+	FeedFormatRSS  = "rss"  // RSS 2.0
+	FeedFormatJSON = "json" // JSON Feed 1.1
+)
+
+// defaultPublishedItems は出力するフィードに含める記事数の既定値
+const defaultPublishedItems = 50
+
+// feedGenerator は出力するフィードの生成元として記載する名前
+const feedGenerator = "rss-en-to-jp-notification"
+
+// PublishedItem は翻訳したフィードに出力する記事
+type PublishedItem struct {
+	Result  *TranslationResult `json:"result"`
+	AddedAt time.Time          `json:"added_at"` // 翻訳したフィードに追加した日時
+}
+
+// PublishedStore は翻訳したフィードに出力する記事を新しい順に永続化する
+// （デーモンモードではHTTPのリクエストと並行して更新するため排他制御する）
+type PublishedStore struct {
+	mu    sync.RWMutex
+	path  string
+	limit int
+	items []*PublishedItem
+}
+
+// FeedMeta は出力するフィード自体の情報
+type FeedMeta struct {
+	Title       string
+	Description string
+	SelfURL     string // フィードを公開するURL（空の場合はフィードのIDに固定の値を使う）
+}
+
+// NewPublishedStore は状態ファイルを読み込んでPublishedStoreを作成する（limitが0以下の場合は既定の記事数）
+func NewPublishedStore(path string, limit int) (*PublishedStore, error) {
+	if limit <= 0 {
+		limit = defaultPublishedItems
+	}
+	ps := &PublishedStore{path: path, limit: limit}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read published items: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &ps.items); err != nil {
+		return nil, fmt.Errorf("failed to parse published items %s: %w", path, err)
+	}
+
+	return ps, nil
+}
+
+// Add は記事を先頭に追加し、上限を超えた古い記事を取り除く（同じフィードの同じGUIDの記事は追加しない）
+func (ps *PublishedStore) Add(results []*TranslationResult, now time.Time) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, item := range ps.items {
+		seen[resultKey(item.Result)] = true
+	}
+	var added []*PublishedItem
+	for _, result := range results {
+		if seen[resultKey(result)] {
+			continue
+		}
+		seen[resultKey(result)] = true
+		added = append(added, &PublishedItem{Result: result, AddedAt: now})
+	}
+	// 同時に追加した記事は公開日時の新しい順にする
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Result.Published.After(added[j].Result.Published)
+	})
+
+	ps.items = append(added, ps.items...)
+	if len(ps.items) > ps.limit {
+		ps.items = ps.items[:ps.limit]
+	}
+}
+
+// Items は出力する記事を新しい順に返す
+func (ps *PublishedStore) Items() []*PublishedItem {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return append([]*PublishedItem(nil), ps.items...)
+}
+
+// Save は状態をファイルに書き込む
+func (ps *PublishedStore) Save() error {
+	items := ps.Items()
+	if items == nil {
+		items = []*PublishedItem{}
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal published items: %w", err)
+	}
+	return writeFileAtomic(ps.path, data)
+}
+
+// FeedContentType は出力するフィードの形式に対応するContent-Typeを返す
+func FeedContentType(format string) string {
+	switch format {
+	case FeedFormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FeedFormatJSON:
+		return "application/feed+json; charset=utf-8"
+	default:
+		return "application/atom+xml; charset=utf-8"
+	}
+}
+
+// WriteFeedFile は翻訳したフィードをファイルに書き出す
+func WriteFeedFile(path, format string, meta FeedMeta, items []*PublishedItem, now time.Time) error {
+	var buf bytes.Buffer
+	if err := WriteFeed(&buf, format, meta, items, now); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write feed file: %w", err)
+	}
+	return nil
+}
+
+// WriteFeed は翻訳したタイトル・要約と元記事のリンクで、指定した形式のフィードを書き出す
+func WriteFeed(w io.Writer, format string, meta FeedMeta, items []*PublishedItem, now time.Time) error {
+	switch format {
+	case FeedFormatAtom:
+		return writeAtom(w, meta, items, now)
+	case FeedFormatRSS:
+		return writeRSS(w, meta, items, now)
+	case FeedFormatJSON:
+		return writeJSONFeed(w, meta, items, now)
+	default:
+		return fmt.Errorf("unsupported feed format %q", format)
+	}
+}
+
+type atomFeed struct {
+	XMLName   xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang      string      `xml:"xml:lang,attr"`
+	Title     string      `xml:"title"`
+	Subtitle  string      `xml:"subtitle,omitempty"`
+	ID        string      `xml:"id"`
+	Updated   string      `xml:"updated"`
+	Links     []atomLink  `xml:"link"`
+	Generator string      `xml:"generator"`
+	Entries   []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	ID        string     `xml:"id"`
+	Links     []atomLink `xml:"link,omitempty"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Author    string     `xml:"author>name"`
+	Summary   atomText   `xml:"summary"`
+	Content   atomText   `xml:"content"`
+}
+
+func writeAtom(w io.Writer, meta FeedMeta, items []*PublishedItem, now time.Time) error {
+	feed := atomFeed{
+		Lang:      "ja",
+		Title:     meta.Title,
+		Subtitle:  meta.Description,
+		ID:        feedID(meta),
+		Updated:   feedUpdated(items, now).Format(time.RFC3339),
+		Generator: feedGenerator,
+	}
+	if meta.SelfURL != "" {
+		feed.Links = []atomLink{{Href: meta.SelfURL, Rel: "self", Type: "application/atom+xml"}}
+	}
+	for _, item := range items {
+		r := item.Result
+		var links []atomLink
+		if r.Link != "" {
+			links = []atomLink{{Href: r.Link, Rel: "alternate"}}
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     r.TranslatedTitle,
+			ID:        entryID(item),
+			Links:     links,
+			Published: itemPublished(item).Format(time.RFC3339),
+			Updated:   item.AddedAt.Format(time.RFC3339),
+			Author:    publishedAuthor(r),
+			Summary:   atomText{Type: "text", Body: summaryOrDefault(r)},
+			Content:   atomText{Type: "html", Body: itemContentHTML(r)},
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Generator     string    `xml:"generator"`
+	AtomLink      *atomLink `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link,omitempty"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Source      rssItemSource `xml:"source"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItemSource struct {
+	URL  string `xml:"url,attr"`
+	Name string `xml:",chardata"`
+}
+
+func writeRSS(w io.Writer, meta FeedMeta, items []*PublishedItem, now time.Time) error {
+	feed := rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         meta.Title,
+			Link:          meta.SelfURL,
+			Description:   meta.Description,
+			Language:      "ja",
+			LastBuildDate: feedUpdated(items, now).Format(time.RFC1123Z),
+			Generator:     feedGenerator,
+		},
+	}
+	if meta.SelfURL != "" {
+		feed.Channel.AtomLink = &atomLink{Href: meta.SelfURL, Rel: "self", Type: "application/rss+xml"}
+	}
+	if feed.Channel.Description == "" {
+		feed.Channel.Description = meta.Title
+	}
+	for _, item := range items {
+		r := item.Result
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       r.TranslatedTitle,
+			Link:        r.Link,
+			GUID:        rssGUID{IsPermaLink: r.Link != "", Value: entryID(item)},
+			PubDate:     itemPublished(item).Format(time.RFC1123Z),
+			Description: itemContentHTML(r),
+			Source:      rssItemSource{URL: r.Feed.URL, Name: publishedAuthor(r)},
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type jsonFeed struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	FeedURL     string          `json:"feed_url,omitempty"`
+	Language    string          `json:"language"`
+	Items       []jsonFeedEntry `json:"items"`
+}
+
+type jsonFeedEntry struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title"`
+	Summary       string           `json:"summary"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Image         string           `json:"image,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+func writeJSONFeed(w io.Writer, meta FeedMeta, items []*PublishedItem, now time.Time) error {
+	feed := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       meta.Title,
+		Description: meta.Description,
+		FeedURL:     meta.SelfURL,
+		Language:    "ja",
+		Items:       []jsonFeedEntry{},
+	}
+	for _, item := range items {
+		r := item.Result
+		feed.Items = append(feed.Items, jsonFeedEntry{
+			ID:            entryID(item),
+			URL:           r.Link,
+			Title:         r.TranslatedTitle,
+			Summary:       summaryOrDefault(r),
+			ContentHTML:   itemContentHTML(r),
+			DatePublished: itemPublished(item).Format(time.RFC3339),
+			DateModified:  item.AddedAt.Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: publishedAuthor(r), URL: r.Feed.Link}},
+			Image:         r.ImageURL,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write JSON feed: %w", err)
+	}
+	return nil
+}
+
+// writeXML はXML宣言を付けてフィードを書き出す
+func writeXML(w io.Writer, feed any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// itemContentHTML は要約・原文タイトル・翻訳の注意書きを記事本文のHTMLにする
+func itemContentHTML(r *TranslationResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(summaryOrDefault(r)))
+	if r.Link != "" {
+		fmt.Fprintf(&b, `<p>原文タイトル: <a href="%s">%s</a></p>`, html.EscapeString(r.Link), html.EscapeString(r.OriginalTitle))
+	} else {
+		fmt.Fprintf(&b, "<p>原文タイトル: %s</p>", html.EscapeString(r.OriginalTitle))
+	}
+	if note := formatDegraded(r); note != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(note))
+	}
+	return b.String()
+}
+
+// publishedAuthor は記事の配信元のフィード名を返す
+func publishedAuthor(r *TranslationResult) string {
+	if r.Feed.Name != "" {
+		return r.Feed.Name
+	}
+	return feedGenerator
+}
+
+// itemPublished は元記事の公開日時を返す（不明な場合はフィードに追加した日時）
+func itemPublished(item *PublishedItem) time.Time {
+	if item.Result.Published.IsZero() {
+		return item.AddedAt
+	}
+	return item.Result.Published
+}
+
+// entryID は記事のIDを返す（リンクがある場合はリンク、ない場合は配信元フィードとGUIDから作るtag URI）
+func entryID(item *PublishedItem) string {
+	r := item.Result
+	if r.Link != "" {
+		return r.Link
+	}
+	authority := feedGenerator
+	if u, err := url.Parse(r.Feed.URL); err == nil && u.Hostname() != "" {
+		authority = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", authority, itemPublished(item).UTC().Format("2006-01-02"), url.PathEscape(archiveKey(r)))
+}
+
+// feedUpdated はフィードの更新日時（最新の記事を追加した日時）を返す
+func feedUpdated(items []*PublishedItem, now time.Time) time.Time {
+	if len(items) == 0 {
+		return now
+	}
+	return items[0].AddedAt
+}
+
+// feedID はAtomのフィードのIDを返す
+func feedID(meta FeedMeta) string {
+	if meta.SelfURL != "" {
+		return meta.SelfURL
+	}
+	return "urn:" + feedGenerator + ":translated-feed"
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestPublishedStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "published_items.json")
+	store, err := NewPublishedStore(path, 2)
+	if err != nil {
+		t.Fatalf("NewPublishedStore() error = %v", err)
+	}
+
+	first := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store.Add([]*TranslationResult{
+		{Link: "https://example.com/1", Published: first.Add(-time.Hour)},
+		{Link: "https://example.com/2", Published: first.Add(-2 * time.Hour)},
+	}, first)
+	// 同じリンクの記事は追加せず、上限を超えた古い記事を取り除く（同時に追加した記事は公開日時の新しい順）
+	store.Add([]*TranslationResult{{Link: "https://example.com/2"}, {Link: "https://example.com/3"}}, first.Add(time.Hour))
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewPublishedStore(path, 2)
+	if err != nil {
+		t.Fatalf("NewPublishedStore() error = %v", err)
+	}
+	items := reloaded.Items()
+	if len(items) != 2 || items[0].Result.Link != "https://example.com/3" || items[1].Result.Link != "https://example.com/1" ||
+		!items[0].AddedAt.Equal(first.Add(time.Hour)) {
+		t.Fatalf("reloaded items = %+v", items)
+	}
+}
+
+func TestPublishedStoreWithoutLinks(t *testing.T) {
+	store, err := NewPublishedStore(filepath.Join(t.TempDir(), "published_items.json"), 10)
+	if err != nil {
+		t.Fatalf("NewPublishedStore() error = %v", err)
+	}
+
+	// リンクのない記事はフィードとGUIDで区別する
+	feed := FeedInfo{URL: "https://example.com/feed"}
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	store.Add([]*TranslationResult{{GUID: "a", Feed: feed}, {GUID: "b", Feed: feed}}, now)
+	store.Add([]*TranslationResult{{GUID: "a", Feed: feed}, {GUID: "a", Feed: FeedInfo{URL: "https://example.org/feed"}}}, now)
+	if items := store.Items(); len(items) != 3 {
+		t.Fatalf("items = %d, want 3", len(items))
+	}
+}
+
+func TestWriteFeedWithoutLinks(t *testing.T) {
+	feed := FeedInfo{URL: "https://example.com/feed", Name: "Example"}
+	added := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	items := []*PublishedItem{
+		{Result: &TranslationResult{TranslatedTitle: "記事A", GUID: "a", Feed: feed}, AddedAt: added},
+		{Result: &TranslationResult{TranslatedTitle: "記事B", GUID: "b", Feed: feed}, AddedAt: added},
+	}
+
+	for _, format := range []string{FeedFormatAtom, FeedFormatRSS, FeedFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteFeed(&buf, format, FeedMeta{Title: "翻訳フィード"}, items, added); err != nil {
+				t.Fatalf("WriteFeed() error = %v", err)
+			}
+			if strings.Contains(buf.String(), `href=""`) || strings.Contains(buf.String(), `isPermaLink="true"`) {
+				t.Errorf("feed contains an empty link:\n%s", buf.String())
+			}
+
+			parsed, err := gofeed.NewParser().ParseString(buf.String())
+			if err != nil {
+				t.Fatalf("failed to parse %s feed: %v\n%s", format, err, buf.String())
+			}
+			if len(parsed.Items) != 2 {
+				t.Fatalf("items = %d, want 2", len(parsed.Items))
+			}
+			a, b := parsed.Items[0].GUID, parsed.Items[1].GUID
+			if a != "tag:example.com,2024-01-15:a" || b == "" || a == b {
+				t.Errorf("ids = %q, %q; want distinct tag URIs", a, b)
+			}
+		})
+	}
+}
+
+func TestWriteFeed(t *testing.T) {
+	result := sampleTemplateData().Result
+	result.Published = time.Date(2024, 1, 14, 12, 0, 0, 0, time.UTC)
+	degraded := *result
+	degraded.Link = "https://example.com/failed"
+	degraded.SummaryFailed = true
+	items := []*PublishedItem{
+		{Result: result, AddedAt: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{Result: &degraded, AddedAt: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+	}
+	meta := FeedMeta{Title: "翻訳フィード", Description: "英語記事の日本語訳", SelfURL: "https://rss.example.com/feed.atom"}
+
+	for _, format := range []string{FeedFormatAtom, FeedFormatRSS, FeedFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteFeed(&buf, format, meta, items, time.Now()); err != nil {
+				t.Fatalf("WriteFeed() error = %v", err)
+			}
+
+			// 一般的なフィードリーダーと同じパーサーで読み込めることを確認する
+			feed, err := gofeed.NewParser().ParseString(buf.String())
+			if err != nil {
+				t.Fatalf("failed to parse %s feed: %v\n%s", format, err, buf.String())
+			}
+			if feed.Title != "翻訳フィード" || len(feed.Items) != 2 {
+				t.Fatalf("feed = %+v", feed)
+			}
+			item := feed.Items[0]
+			if item.Title != "翻訳タイトル" || item.Link != "https://example.com/post?a=1&b=2" ||
+				item.PublishedParsed == nil || !item.PublishedParsed.Equal(result.Published) {
+				t.Errorf("item = %+v", item)
+			}
+			content := item.Content
+			if content == "" {
+				content = item.Description
+			}
+			if !strings.Contains(content, "要約") || !strings.Contains(content, "Title with &#34;quotes&#34; &amp; &lt;tags&gt;") {
+				t.Errorf("content = %s", content)
+			}
+			if second := feed.Items[1].Content + feed.Items[1].Description; !strings.Contains(second, "要約を生成できませんでした") {
+				t.Errorf("degraded item content = %s", second)
+			}
+		})
+	}
+
+	if err := WriteFeed(&bytes.Buffer{}, "opml", meta, items, time.Now()); err == nil {
+		t.Error("WriteFeed(opml) error = nil, want error")
+	}
+}
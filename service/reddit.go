@@ -0,0 +1,101 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// redditBaseURL はパーマリンクを絶対URLにする際の基準
+const redditBaseURL = "https://www.reddit.com"
+
+// RedditSource はRedditのJSON API（/r/{subreddit}/{sort}.json）から記事を取得する
+// （Redditは既定のUser-Agentを制限するため、FeedHTTPConfigのUserAgentの指定を推奨）
+type RedditSource struct {
+	MinScore int // このスコア未満の投稿は対象にしない
+}
+
+// redditListing はリスティングのレスポンスのうち使用する項目
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Data struct {
+				Name       string  `json:"name"`
+				Title      string  `json:"title"`
+				URL        string  `json:"url"`
+				Permalink  string  `json:"permalink"`
+				Selftext   string  `json:"selftext"`
+				Author     string  `json:"author"`
+				CreatedUTC float64 `json:"created_utc"`
+				Score      int     `json:"score"`
+				IsSelf     bool    `json:"is_self"`
+				Stickied   bool    `json:"stickied"`
+				Flair      string  `json:"link_flair_text"`
+				Thumbnail  string  `json:"thumbnail"`
+				Subreddit  string  `json:"subreddit_name_prefixed"`
+			} `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// Fetch はサブレディットの投稿を取得する（固定された投稿は除く。テキスト投稿はスレッドを記事のURLとする）
+func (s *RedditSource) Fetch(apiURL string, client *SourceClient) (*SourceFeed, error) {
+	var listing redditListing
+	if err := client.getJSON(apiURL, "application/json", &listing); err != nil {
+		return nil, err
+	}
+
+	feed := &SourceFeed{Title: redditSubreddit(apiURL)}
+	if feed.Title != "" {
+		feed.Link = redditBaseURL + "/" + feed.Title
+	}
+
+	for _, child := range listing.Data.Children {
+		post := child.Data
+		if post.Title == "" || post.Stickied || post.Score < s.MinScore {
+			continue
+		}
+		if feed.Title == "" {
+			feed.Title = post.Subreddit
+		}
+
+		permalink := redditBaseURL + post.Permalink
+		link := post.URL
+		if post.IsSelf || link == "" {
+			link = permalink
+		}
+		text := cleanText(post.Selftext)
+		item := &FeedItem{
+			Title:       cleanText(post.Title),
+			Description: truncateText(text, maxContentDescriptionLength),
+			Content:     text,
+			Link:        link,
+			GUID:        permalink,
+			Author:      post.Author,
+		}
+		if post.Flair != "" {
+			item.Categories = []string{post.Flair}
+		}
+		if strings.HasPrefix(post.Thumbnail, "http") {
+			item.ImageURL = post.Thumbnail
+		}
+		if post.CreatedUTC > 0 {
+			item.Published = time.Unix(int64(post.CreatedUTC), 0).UTC()
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
+
+// redditSubreddit はAPIのURLから "r/{subreddit}" を取り出す（取り出せない場合は空文字列）
+func redditSubreddit(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "r" {
+		return "r/" + strings.TrimSuffix(parts[1], ".json")
+	}
+	return ""
+}
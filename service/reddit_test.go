@@ -0,0 +1,46 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedditSource(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Write([]byte(`{"data": {"children": [
+  {"kind": "t3", "data": {"name": "t3_sticky", "title": "Weekly thread", "permalink": "/r/golang/comments/sticky/", "stickied": true, "score": 500, "is_self": true}},
+  {"kind": "t3", "data": {"name": "t3_a", "title": "Go 1.22 is released", "url": "https://go.dev/blog/go1.22", "permalink": "/r/golang/comments/a/go_122/", "author": "gopher", "created_utc": 1705305600.0, "score": 320, "link_flair_text": "news", "thumbnail": "https://b.thumbs.redditmedia.com/a.jpg", "subreddit_name_prefixed": "r/golang"}},
+  {"kind": "t3", "data": {"name": "t3_b", "title": "How do you structure services?", "url": "https://www.reddit.com/r/golang/comments/b/how/", "permalink": "/r/golang/comments/b/how/", "selftext": "Looking for advice.", "is_self": true, "created_utc": 1705302000.0, "score": 80, "thumbnail": "self"}},
+  {"kind": "t3", "data": {"name": "t3_c", "title": "Low score", "url": "https://example.com/c", "permalink": "/r/golang/comments/c/", "score": 2}}
+]}}`))
+	}))
+	defer server.Close()
+
+	client := &SourceClient{client: http.DefaultClient, config: &FeedHTTPConfig{UserAgent: "team-notify/1.0"}}
+	feed, err := (&RedditSource{MinScore: 10}).Fetch(server.URL+"/r/golang/hot.json?limit=25", client)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if userAgent != "team-notify/1.0" {
+		t.Errorf("User-Agent = %q", userAgent)
+	}
+	if feed.Title != "r/golang" || feed.Link != "https://www.reddit.com/r/golang" {
+		t.Errorf("feed = %+v", feed)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2 (sticky and low score excluded)", len(feed.Items))
+	}
+	link := feed.Items[0]
+	if link.Link != "https://go.dev/blog/go1.22" || link.GUID != "https://www.reddit.com/r/golang/comments/a/go_122/" ||
+		link.ImageURL != "https://b.thumbs.redditmedia.com/a.jpg" || len(link.Categories) != 1 || link.Published.Unix() != 1705305600 {
+		t.Errorf("link post = %+v", link)
+	}
+	self := feed.Items[1]
+	if self.Link != "https://www.reddit.com/r/golang/comments/b/how/" || self.Description != "Looking for advice." || self.ImageURL != "" {
+		t.Errorf("self post = %+v", self)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Relevance はチームの関心プロファイルに対する記事の関連度
+type Relevance struct {
+	Score  int    `json:"score"`  // 0〜100
+	Reason string `json:"reason"` // 判定理由（日本語）
+}
+
+// ScoreRelevance は記事がprofileに記述された関心事項にどの程度関連するかをOpenAI APIで判定する
+// （翻訳前の原文で判定するため、対象外の記事に翻訳・要約の費用がかからない）
+func (ts *TranslatorService) ScoreRelevance(item *FeedItem, profile string) (*Relevance, error) {
+	prompt := fmt.Sprintf(`以下の関心プロファイルに対して、記事がどの程度関連するかを0から100の整数で評価してください。
+
+関心プロファイル:
+%s
+
+タイトル: %s
+
+内容: %s
+
+次の形式のJSONのみで回答してください（reasonは日本語で1文）:
+{"score": 0, "reason": ""}`, profile, item.Title, truncateText(item.Description, 2000))
+
+	ctx, span := withArticle(context.Background(), "TranslatorService.ScoreRelevance", item)
+	defer span.End()
+
+	resp, err := ts.chatCompletion(ctx, OpenAIRelevance,
+		openai.ChatCompletionRequest{
+			Model: ts.openAIModel,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "あなたは技術記事を読者の関心に基づいて分類するAIアシスタントです。指定された形式のJSONのみを出力してください。",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: prompt,
+				},
+			},
+			MaxTokens:   150,
+			Temperature: 0,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to score relevance with OpenAI: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no relevance score returned by OpenAI")
+	}
+
+	return parseRelevance(resp.Choices[0].Message.Content)
+}
+
+// parseRelevance はモデルの回答から関連度を取り出す（コードブロックや前後の文章は無視する）
+func parseRelevance(content string) (*Relevance, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("relevance response is not JSON: %q", content)
+	}
+
+	var relevance Relevance
+	if err := json.Unmarshal([]byte(content[start:end+1]), &relevance); err != nil {
+		return nil, fmt.Errorf("failed to parse relevance response: %w", err)
+	}
+
+	if relevance.Score < 0 {
+		relevance.Score = 0
+	}
+	if relevance.Score > 100 {
+		relevance.Score = 100
+	}
+	relevance.Reason = strings.TrimSpace(relevance.Reason)
+
+	return &relevance, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestScoreRelevance(t *testing.T) {
+	openAI := fake.NewOpenAIServer(t, "")
+	openAI.SetReplyFunc(func(prompt string) string {
+		if !strings.Contains(prompt, "分散システム") || !strings.Contains(prompt, "Consensus in practice") {
+			return "unexpected prompt"
+		}
+		return "```json\n{\"score\": 85, \"reason\": \"合意アルゴリズムの解説\"}\n```"
+	})
+
+	ts := NewTranslatorService("deepl-key", "", "openai-key", "gpt-3.5-turbo", WithOpenAIBaseURL(openAI.BaseURL()))
+	relevance, err := ts.ScoreRelevance(&FeedItem{Title: "Consensus in practice", Description: "Raft and Paxos."}, "分散システム")
+	if err != nil {
+		t.Fatalf("ScoreRelevance() error = %v", err)
+	}
+	if relevance.Score != 85 || relevance.Reason != "合意アルゴリズムの解説" {
+		t.Errorf("ScoreRelevance() = %+v", relevance)
+	}
+}
+
+func TestParseRelevance(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    Relevance
+		wantErr bool
+	}{
+		{"plain", `{"score": 40, "reason": "関連は薄い"}`, Relevance{Score: 40, Reason: "関連は薄い"}, false},
+		{"surrounding text", "評価: {\"score\": 70, \"reason\": \" 関連あり \"} です", Relevance{Score: 70, Reason: "関連あり"}, false},
+		{"clamped", `{"score": 150}`, Relevance{Score: 100}, false},
+		{"not json", "高い", Relevance{}, true},
+		{"invalid score", `{"score": "high"}`, Relevance{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRelevance(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseRelevance(%q) expected error", tt.in)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRelevance(%q) error = %v", tt.in, err)
+			}
+			if *got != tt.want {
+				t.Errorf("parseRelevance(%q) = %+v, want %+v", tt.in, *got, tt.want)
+			}
+		})
+	}
+}
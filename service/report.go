@@ -0,0 +1,66 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 実行レポートで失敗を記録する処理の段階
+const (
+	StageFetch     = "fetch"     // フィードの取得
+	StageRelevance = "relevance" // 関連度判定
+	StageTranslate = "translate" // 翻訳
+	StageSummarize = "summarize" // 要約
+	StageNotify    = "notify"    // 通知の送信
+	StageState     = "state"     // 状態ファイルの保存
+	StagePublish   = "publish"   // 翻訳したフィードの書き出し
+)
+
+// RunReport は1回の実行の集計結果
+type RunReport struct {
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   time.Time    `json:"finished_at"`
+	FeedsChecked int          `json:"feeds_checked"` // 取得を試みたフィード数（無効化中のフィードは含まない）
+	FeedsFailed  int          `json:"feeds_failed"`
+	Fetched      int          `json:"fetched"`    // 取得できたフィードに含まれていた記事数
+	New          int          `json:"new"`        // 新着記事数（重複をまとめた後）
+	Skipped      int          `json:"skipped"`    // 関連度が低いため除外した記事数
+	Translated   int          `json:"translated"` // 翻訳に成功した記事数
+	Summarized   int          `json:"summarized"` // 要約に成功した記事数
+	Posted       int          `json:"posted"`     // 送信した記事数（通知先ごとに数える。ダイジェストは配信時に数え、メールは宛先ごとに数える）
+	Held         int          `json:"held"`       // 翻訳・要約に失敗したため通知せずに保留した記事数
+	Queued       int          `json:"queued"`     // 送信に失敗し、送信箱で再送を待っているメッセージ数
+	Failures     []RunFailure `json:"failures,omitempty"`
+}
+
+// RunFailure は実行中に発生した1件の失敗
+type RunFailure struct {
+	Stage  string `json:"stage"`
+	Target string `json:"target"` // フィードのURL、記事のタイトル、通知先の名前など
+	Reason string `json:"reason"`
+}
+
+// AddFailure は失敗を記録する
+func (r *RunReport) AddFailure(stage, target string, err error) {
+	r.Failures = append(r.Failures, RunFailure{Stage: stage, Target: target, Reason: err.Error()})
+}
+
+// Failed は失敗が1件以上あったかを返す
+func (r *RunReport) Failed() bool {
+	return len(r.Failures) > 0
+}
+
+// Duration は実行にかかった時間を返す
+func (r *RunReport) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Second)
+}
+
+// Save はレポートをJSONファイルに書き込む（CIの成果物などで参照する）
+func (r *RunReport) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run report: %w", err)
+	}
+	return writeFileAtomic(path, data)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunReport(t *testing.T) {
+	started := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	report := &RunReport{StartedAt: started, FinishedAt: started.Add(90*time.Second + 400*time.Millisecond), New: 3}
+	if report.Failed() {
+		t.Error("Failed() = true for a report without failures")
+	}
+	if got := report.Duration(); got != 90*time.Second {
+		t.Errorf("Duration() = %v, want 1m30s", got)
+	}
+
+	report.AddFailure(StageTranslate, "Hello", errors.New("quota exceeded"))
+	if !report.Failed() {
+		t.Error("Failed() = false after AddFailure")
+	}
+
+	path := filepath.Join(t.TempDir(), "state", "run_report.json")
+	if err := report.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved RunReport
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("saved report is not valid JSON: %v", err)
+	}
+	want := RunFailure{Stage: StageTranslate, Target: "Hello", Reason: "quota exceeded"}
+	if saved.New != 3 || len(saved.Failures) != 1 || saved.Failures[0] != want {
+		t.Errorf("saved report = %+v", saved)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// scrapeDateLayouts はDateLayoutを指定しない場合に試す日付の形式
+var scrapeDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// ScrapeSource はHTMLページからCSSセレクターで記事を抽出する（RSSを提供していないブログやニュースページ向け）
+type ScrapeSource struct {
+	Selectors  ScrapeSelectors
+	DateLayout string // 日付の形式（Goのレイアウト。未指定の場合は一般的な形式を順に試す）
+}
+
+// ScrapeSelectors は記事を抽出するCSSセレクター（Item以外はItemの要素内で評価する）
+type ScrapeSelectors struct {
+	Item        string // 記事1件分の要素（必須）
+	Title       string // タイトル（未指定の場合はリンクのテキスト）
+	Link        string // リンク（href属性。未指定の場合は最初のa要素、Item自体がa要素の場合はItem）
+	Description string
+	Date        string // 公開日時（datetime属性があればその値、なければテキスト）
+	Image       string // 画像（src属性）
+	Author      string
+}
+
+// Fetch はページを取得し、記事を抽出する（日付を取得できない記事は公開日時なしとして扱う）
+func (s *ScrapeSource) Fetch(pageURL string, client *SourceClient) (*SourceFeed, error) {
+	if s.Selectors.Item == "" {
+		return nil, fmt.Errorf("item selector is required")
+	}
+
+	resp, err := client.Get(pageURL, "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxSourceResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	// 相対URLはリダイレクト後のページURLを基準に解決する
+	base := resp.Request.URL
+	feed := &SourceFeed{
+		Title: strings.TrimSpace(doc.Find("title").First().Text()),
+		Link:  base.String(),
+	}
+	if image, ok := doc.Find(`meta[property="og:image"]`).Attr("content"); ok {
+		feed.ImageURL = resolveURL(base, image)
+	}
+
+	doc.Find(s.Selectors.Item).Each(func(_ int, sel *goquery.Selection) {
+		if item := s.extract(sel, base); item != nil {
+			feed.Items = append(feed.Items, item)
+		}
+	})
+	return feed, nil
+}
+
+// extract は記事1件分の要素から記事を作成する（タイトルかリンクがない場合はnil）
+func (s *ScrapeSource) extract(sel *goquery.Selection, base *url.URL) *FeedItem {
+	link := sel
+	if s.Selectors.Link != "" {
+		link = sel.Find(s.Selectors.Link).First()
+	} else if !sel.Is("a") {
+		link = sel.Find("a[href]").First()
+	}
+	href, ok := link.Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		return nil
+	}
+
+	title := link.Text()
+	if s.Selectors.Title != "" {
+		title = sel.Find(s.Selectors.Title).First().Text()
+	}
+	title = cleanText(title)
+	if title == "" {
+		return nil
+	}
+
+	item := &FeedItem{
+		Title:       title,
+		Description: cleanText(selectText(sel, s.Selectors.Description)),
+		Link:        resolveURL(base, href),
+		Author:      cleanText(selectText(sel, s.Selectors.Author)),
+	}
+	item.GUID = item.Link
+	if s.Selectors.Image != "" {
+		if src, ok := sel.Find(s.Selectors.Image).First().Attr("src"); ok {
+			item.ImageURL = resolveURL(base, src)
+		}
+	}
+	if s.Selectors.Date != "" {
+		date := sel.Find(s.Selectors.Date).First()
+		value, ok := date.Attr("datetime")
+		if !ok {
+			value = date.Text()
+		}
+		item.Published = s.parseDate(strings.TrimSpace(value))
+	}
+	return item
+}
+
+// parseDate は日付を解析する（解析できない場合はゼロ値）
+func (s *ScrapeSource) parseDate(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	layouts := scrapeDateLayouts
+	if s.DateLayout != "" {
+		layouts = []string{s.DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// selectText はセレクターに一致する最初の要素のテキストを返す（セレクターが空の場合は空文字列）
+func selectText(sel *goquery.Selection, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	return sel.Find(selector).First().Text()
+}
+
+// resolveURL は相対URLをページのURLを基準に絶対URLにする
+func resolveURL(base *url.URL, ref string) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
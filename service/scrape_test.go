@@ -0,0 +1,88 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const scrapePage = `<!DOCTYPE html>
+<html>
+<head><title>Acme Engineering</title><meta property="og:image" content="/logo.png"></head>
+<body>
+  <div class="post">
+    <h2><a href="/blog/zero-downtime">Zero-downtime deploys</a></h2>
+    <time datetime="2024-01-15T08:00:00Z">January 15</time>
+    <p class="summary">How we deploy <b>without</b> outages.</p>
+    <img src="images/deploy.png">
+    <span class="by">Jane</span>
+  </div>
+  <div class="post">
+    <h2><a href="https://other.example.com/guest">Guest post</a></h2>
+    <time>Jan 10, 2024</time>
+  </div>
+  <div class="post">
+    <h2>No link here</h2>
+  </div>
+</body>
+</html>`
+
+func TestScrapeSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(scrapePage))
+	}))
+	defer server.Close()
+
+	source := &ScrapeSource{Selectors: ScrapeSelectors{
+		Item:        ".post",
+		Title:       "h2",
+		Link:        "h2 a",
+		Description: ".summary",
+		Date:        "time",
+		Image:       "img",
+		Author:      ".by",
+	}}
+	feed, err := source.Fetch(server.URL+"/blog/", &SourceClient{client: http.DefaultClient})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	if feed.Title != "Acme Engineering" || feed.ImageURL != server.URL+"/logo.png" {
+		t.Errorf("feed = %+v", feed)
+	}
+	if len(feed.Items) != 2 {
+		t.Fatalf("got %d items, want 2 (item without link skipped)", len(feed.Items))
+	}
+
+	first := feed.Items[0]
+	if first.Title != "Zero-downtime deploys" || first.Link != server.URL+"/blog/zero-downtime" || first.GUID != first.Link {
+		t.Errorf("first = %+v", first)
+	}
+	if first.Description != "How we deploy without outages." || first.Author != "Jane" || first.ImageURL != server.URL+"/blog/images/deploy.png" {
+		t.Errorf("first details = %+v", first)
+	}
+	if !first.Published.Equal(time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("first.Published = %v", first.Published)
+	}
+	if second := feed.Items[1]; second.Link != "https://other.example.com/guest" || !second.Published.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("second = %+v", second)
+	}
+}
+
+func TestScrapeSourceDefaultsToLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<ul><li><a href="/a">First article</a></li><li><a href="/b">  </a></li></ul>`))
+	}))
+	defer server.Close()
+
+	// Item自体がリンクの場合は、そのテキストとhrefを使う
+	feed, err := (&ScrapeSource{Selectors: ScrapeSelectors{Item: "li a"}}).Fetch(server.URL, &SourceClient{client: http.DefaultClient})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].Title != "First article" || feed.Items[0].Link != server.URL+"/a" || !feed.Items[0].Published.IsZero() {
+		t.Errorf("items = %+v", feed.Items)
+	}
+}
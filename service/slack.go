@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SlackNotifier はSlack Incoming Webhookにメッセージを送信する
+type SlackNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// Send はメッセージにチャンネルとスレッドを設定してSlackに送信する
+func (sn *SlackNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	body, err := slackBody(message, thread)
+	if err != nil {
+		return "", err
+	}
+
+	status, respBody, err := postJSON(ctx, sn.httpClient, sn.webhookURL, body)
+	if err != nil {
+		return "", err
+	}
+
+	// ステータスコードをチェック
+	if status != http.StatusOK {
+		return "", fmt.Errorf("Slack API error: status=%d, body=%s", status, string(respBody))
+	}
+
+	// Slackからの "ok" レスポンスをチェック
+	if strings.TrimSpace(string(respBody)) != "ok" {
+		return "", fmt.Errorf("unexpected Slack response: %s", string(respBody))
+	}
+
+	// 注意: Webhook URLではメッセージのタイムスタンプを取得できないため、現在時刻をスレッドの識別子として使用する
+	// （実際のBot TokenベースのAPIが必要な場合は別実装が必要）
+	now := time.Now()
+	return fmt.Sprintf("%.6f", float64(now.Unix())+float64(now.Nanosecond())/1e9), nil
+}
+
+// MattermostNotifier はMattermost Incoming Webhookにメッセージを送信する
+// （Slack互換のペイロードを受け付けるが、Webhookではスレッドに返信できない）
+type MattermostNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// Send はメッセージにチャンネルを設定してMattermostに送信する
+func (mn *MattermostNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	body, err := slackBody(message, "")
+	if err != nil {
+		return "", err
+	}
+
+	status, respBody, err := postJSON(ctx, mn.httpClient, mn.webhookURL, body)
+	if err != nil {
+		return "", err
+	}
+	if !isSuccess(status) {
+		return "", fmt.Errorf("Mattermost API error: status=%d, body=%s", status, string(respBody))
+	}
+	return "", nil
+}
+
+// slackBody はSlack形式のメッセージに投稿先チャンネルとスレッドを設定したJSONを作成する
+// （テンプレートが生成したほかのキー（unfurl_links・mrkdwnなど）はそのまま送信する）
+func slackBody(message *Message, thread string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message.Body, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse Slack message: %w", err)
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("failed to parse Slack message: not a JSON object")
+	}
+
+	// 文字列のエンコードは失敗しないためエラーを確認しない
+	if message.Channel != "" {
+		fields["channel"], _ = json.Marshal(message.Channel)
+	}
+	delete(fields, "thread_ts")
+	if thread != "" {
+		fields["thread_ts"], _ = json.Marshal(thread)
+	}
+
+	body, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestMattermostNotifier(t *testing.T) {
+	server := fake.NewWebhookServer(t, http.StatusOK, "ok")
+	ns, err := NewNotificationService(server.URL, "town-square", FormatBlocks, "", WithNotifierType(NotifierMattermost))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Webhookではスレッドに返信できないため、要約は続けて投稿する
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, true); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(messages))
+	}
+	for i, message := range messages {
+		if message["channel"] != "town-square" || message["attachments"] == nil || message["thread_ts"] != nil {
+			t.Errorf("messages[%d] = %v, want Slack-compatible attachments without a thread", i, message)
+		}
+	}
+
+	server.SetFail(true)
+	if err := ns.SendErrorNotification("failed"); err == nil {
+		t.Error("SendErrorNotification() error = nil, want error")
+	}
+}
+
+func TestSlackBodyKeepsTemplateFields(t *testing.T) {
+	message := &Message{
+		Channel: "#golang",
+		Body:    json.RawMessage(`{"text": "hello", "unfurl_links": false, "unfurl_media": false, "mrkdwn": true, "reply_broadcast": true, "channel": "#ignored"}`),
+	}
+	body, err := slackBody(message, "1700000000.000001")
+	if err != nil {
+		t.Fatalf("slackBody() error = %v", err)
+	}
+
+	// 投稿先チャンネルとスレッドだけを設定し、カスタムテンプレートのキーは落とさない
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"text": "hello", "unfurl_links": false, "unfurl_media": false, "mrkdwn": true, "reply_broadcast": true,
+		"channel": "#golang", "thread_ts": "1700000000.000001",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("body = %v, want %v", fields, want)
+	}
+
+	if _, err := slackBody(&Message{Body: json.RawMessage(`[]`)}, ""); err == nil {
+		t.Error("slackBody() error = nil, want error for a non-object message")
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// maxSourceResponseSize はAPIやWebページの応答として読み込む最大サイズ
+const maxSourceResponseSize = 10 << 20
+
+// Source は記事の取得元（RSSを提供していないAPIやWebページを含む）
+// 取得した記事はRSSフィードの記事と同様に、新着判定・フィルター・重複排除の対象になる
+type Source interface {
+	// Fetch はurlから記事を取得する（公開日時が不明な記事はPublishedをゼロ値にする）
+	Fetch(url string, client *SourceClient) (*SourceFeed, error)
+}
+
+// SourceFeed は取得元から取得したフィードのメタデータと記事
+type SourceFeed struct {
+	Title    string
+	Link     string
+	ImageURL string
+	Items    []*FeedItem
+}
+
+// SourceClient はフィードごとのHTTP設定（ヘッダー・認証・プロキシなど）を適用してリクエストを送る
+type SourceClient struct {
+	ctx    context.Context // 取得のスパンを含むcontext（nilの場合はcontext.Background）
+	client *http.Client
+	config *FeedHTTPConfig
+}
+
+// Get はGETリクエストを送り、成功（2xx）の場合のみレスポンスを返す
+func (c *SourceClient) Get(url, accept string) (*http.Response, error) {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.config.apply(req, accept)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, gofeed.HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return resp, nil
+}
+
+// getJSON はGETリクエストを送り、JSONのレスポンスをvにデコードする
+func (c *SourceClient) getJSON(url, accept string, v any) error {
+	resp, err := c.Get(url, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxSourceResponseSize)).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
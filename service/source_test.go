@@ -0,0 +1,68 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stubSource は固定の記事を返す取得元
+type stubSource struct {
+	items []*FeedItem
+	url   string
+}
+
+func (s *stubSource) Fetch(url string, client *SourceClient) (*SourceFeed, error) {
+	s.url = url
+	var items []*FeedItem
+	for _, item := range s.items {
+		copied := *item
+		items = append(items, &copied)
+	}
+	return &SourceFeed{Title: "Stub", Link: "https://stub.example.com", Items: items}, nil
+}
+
+func TestCheckForRecentItemsFromSource(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	source := &stubSource{items: []*FeedItem{
+		{Title: "Recent release", Link: "https://stub.example.com/recent", Published: now.Add(-time.Hour)},
+		{Title: "Sponsored", Link: "https://stub.example.com/ad", Published: now.Add(-time.Hour)},
+		{Title: "Old release", Link: "https://stub.example.com/old", Published: now.Add(-48 * time.Hour)},
+	}}
+	filter, err := NewItemFilter(nil, &FilterRule{Field: "title", Contains: "sponsored"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewFeedService([]FeedConfig{{URL: "https://api.stub.example.com/items", Source: source, Filter: filter}}, 10,
+		WithClock(func() time.Time { return now }))
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+
+	// RSSと同様に新着判定・フィルターを適用し、フィード情報を付与する
+	if source.url != "https://api.stub.example.com/items" {
+		t.Errorf("Fetch() url = %q", source.url)
+	}
+	if len(items) != 1 || items[0].Title != "Recent release" {
+		t.Fatalf("items = %+v, want only the recent release", items)
+	}
+	if items[0].GUID != "https://stub.example.com/recent" || items[0].Feed.Name != "Stub" || items[0].Feed.Link != "https://stub.example.com" {
+		t.Errorf("item = %+v", items[0])
+	}
+}
+
+func TestSourceClientHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var v any
+	client := &SourceClient{client: http.DefaultClient}
+	if err := client.getJSON(server.URL, "application/json", &v); err == nil {
+		t.Error("getJSON() error = nil, want HTTP error")
+	}
+}
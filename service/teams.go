@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// TeamsNotifier はMicrosoft TeamsのWebhook（Incoming WebhookまたはWorkflows）にAdaptive Cardを送信する
+type TeamsNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// Send はテンプレートが生成したメッセージをそのままTeamsに送信する（スレッドには対応しない）
+func (tn *TeamsNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	status, body, err := postJSON(ctx, tn.httpClient, tn.webhookURL, message.Body)
+	if err != nil {
+		return "", err
+	}
+	if !isSuccess(status) {
+		return "", fmt.Errorf("Teams API error: status=%d, body=%s", status, string(body))
+	}
+
+	// 旧来のIncoming Webhookは成功時に "1" を返し、失敗しても200でエラーメッセージを返すことがある
+	// （Workflowsは202で空のボディを返す）
+	if text := strings.TrimSpace(string(body)); text != "" && text != "1" {
+		return "", fmt.Errorf("unexpected Teams response: %s", text)
+	}
+	return "", nil
+}
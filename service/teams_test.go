@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestTeamsNotifier(t *testing.T) {
+	server := fake.NewWebhookServer(t, http.StatusAccepted, "")
+	ns, err := NewNotificationService(server.URL, "#ignored", FormatBlocks, "", WithNotifierType(NotifierTeams))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, false); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	card := fmt.Sprint(messages[0]["attachments"])
+	if messages[0]["type"] != "message" || !strings.Contains(card, "AdaptiveCard") || !strings.Contains(card, "翻訳タイトル") {
+		t.Errorf("message = %v, want an Adaptive Card", messages[0])
+	}
+	if _, ok := messages[0]["channel"]; ok {
+		t.Errorf("message has a Slack channel: %v", messages[0])
+	}
+}
+
+func TestTeamsNotifierErrorResponse(t *testing.T) {
+	// 旧来のIncoming Webhookは失敗時も200でエラーメッセージを返すことがある
+	server := fake.NewWebhookServer(t, http.StatusOK, "Webhook message delivery failed with error: Microsoft Teams endpoint returned HTTP error 429")
+	ns, err := NewNotificationService(server.URL, "", FormatBlocks, "", WithNotifierType(NotifierTeams))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.SendErrorNotification("failed"); err == nil || !strings.Contains(err.Error(), "HTTP error 429") {
+		t.Errorf("SendErrorNotification() error = %v, want the Teams error", err)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// テンプレート名（ファイル名は <名前>.json.tmpl）
+const (
+	TemplateArticle  = "article"  // 通常形式の記事通知
+	TemplateTitle    = "title"    // スレッド形式のタイトル投稿
+	TemplateSummary  = "summary"  // スレッド形式の要約返信
+	TemplateDigest   = "digest"   // ダイジェスト（1ページ分）
+	TemplateError    = "error"    // エラー通知
+	TemplateStartup  = "startup"  // 起動通知
+	TemplateOverflow = "overflow" // フィードごとの上限を超えた記事の一覧
+	TemplateHealth   = "health"   // フィードの健全性アラート
+	TemplateReport   = "report"   // 実行サマリー
+)
+
+// templateNames は必要なテンプレートの一覧
+var templateNames = []string{
+	TemplateArticle,
+	TemplateTitle,
+	TemplateSummary,
+	TemplateDigest,
+	TemplateError,
+	TemplateStartup,
+	TemplateOverflow,
+	TemplateHealth,
+	TemplateReport,
+}
+
+// builtinTemplates は組み込みテンプレート（メッセージ形式ごとのディレクトリ）
+//
+//go:embed templates
+var builtinTemplates embed.FS
+
+// TemplateData はテンプレートに渡すデータ
+type TemplateData struct {
+	Result   *TranslationResult // 記事通知の場合の翻訳結果
+	Feed     FeedInfo           // 記事のフィード情報
+	Feeds    []FeedInfo         // 起動通知の場合の監視対象フィード
+	Digest   *DigestData        // ダイジェストの場合のデータ
+	Overflow *FeedOverflow      // 超過通知の場合の記事
+	Health   *HealthAlert       // 健全性アラートの場合のフィードの状態
+	Report   *RunReport         // 実行サマリーの場合の実行結果
+	Error    string             // エラー通知の場合のメッセージ
+	Run      RunInfo            // 実行情報
+	Now      time.Time
+}
+
+// DigestData はダイジェスト1ページ分のデータ
+type DigestData struct {
+	Text   string // 見出しテキスト
+	Groups []DigestGroup
+	Page   int // 1始まりのページ番号
+	Pages  int
+	Total  int    // ダイジェスト全体の記事数
+	Window string // 集計期間の表示用文字列
+}
+
+// RunInfo は実行ごとの情報
+type RunInfo struct {
+	StartedAt   time.Time
+	Destination string
+	Mode        string
+}
+
+// templateRenderer はtext/templateで通知先に送信するメッセージのJSONを生成する
+type templateRenderer struct {
+	templates map[string]*template.Template
+}
+
+// newTemplateRenderer はメッセージ形式の組み込みテンプレートを読み込み、
+// templateDirが指定されていれば同名のファイルで上書きする
+func newTemplateRenderer(format, templateDir string) (*templateRenderer, error) {
+	if format == "" {
+		format = FormatBlocks
+	}
+
+	tr := &templateRenderer{templates: make(map[string]*template.Template)}
+	for _, name := range templateNames {
+		filename := name + ".json.tmpl"
+
+		text, err := builtinTemplates.ReadFile("templates/" + format + "/" + filename)
+		if err != nil {
+			return nil, fmt.Errorf("unknown message format %q: %w", format, err)
+		}
+
+		// 利用者定義のテンプレートがあれば優先する
+		if templateDir != "" {
+			custom, err := os.ReadFile(filepath.Join(templateDir, filename))
+			if err == nil {
+				text = custom
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to read template %s: %w", filename, err)
+			}
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", filename, err)
+		}
+		tr.templates[name] = tmpl
+	}
+
+	return tr, nil
+}
+
+// render はテンプレートを実行し、生成したJSONを検証して返す
+func (tr *templateRenderer) render(name string, data *TemplateData) (json.RawMessage, error) {
+	tmpl, ok := tr.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+
+	var message json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &message); err != nil {
+		return nil, fmt.Errorf("template %s produced invalid JSON: %w", name, err)
+	}
+
+	return message, nil
+}
+
+// TemplateFuncs はテンプレート内で使用できる関数の複製を返す（管理画面など、通知以外のテンプレート用）
+func TemplateFuncs() template.FuncMap {
+	funcs := make(template.FuncMap, len(templateFuncs))
+	for name, fn := range templateFuncs {
+		funcs[name] = fn
+	}
+	return funcs
+}
+
+// templateFuncs はテンプレート内で使用できる関数
+var templateFuncs = template.FuncMap{
+	// json は値をJSONリテラルとして出力する（文字列は引用符付き）
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// jsonEscape は文字列をJSON文字列の中身としてエスケープする（引用符なし）
+	"jsonEscape": func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data[1 : len(data)-1])
+	},
+	"truncate": func(maxLen int, s string) string {
+		return truncateText(s, maxLen)
+	},
+	"summary":   summaryOrDefault,
+	"relevance": formatRelevance,
+	"sources":   formatSources,
+	"mdSources": formatMarkdownSources,
+	"degraded":  formatDegraded,
+	"jst":       formatJST,
+	"health":    formatHealthAlert,
+	"default": func(def, s string) string {
+		if strings.TrimSpace(s) == "" {
+			return def
+		}
+		return s
+	},
+}
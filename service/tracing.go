@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName はサービスが作成するスパンの計装ライブラリ名
+const tracerName = "rss-en-to-jp-notification/service"
+
+// スパンの属性
+const (
+	AttrArticleGUID  = attribute.Key("article.guid")
+	AttrArticleLink  = attribute.Key("article.link")
+	AttrArticleTitle = attribute.Key("article.title")
+	AttrFeedURL      = attribute.Key("feed.url")
+	AttrNotifier     = attribute.Key("notifier.type")
+)
+
+// articleGUIDKey は処理中の記事のGUIDをcontextに保存するキー
+type articleGUIDKey struct{}
+
+// tracer はグローバルなTracerProviderからTracerを取得する
+// （TracerProviderを設定していない場合は何も記録しない）
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// withArticle は記事のスパンを開始し、記事のGUIDを子スパンに引き継ぐcontextを返す
+func withArticle(ctx context.Context, name string, item *FeedItem) (context.Context, trace.Span) {
+	ctx = withArticleGUID(ctx, item.GUID)
+	return startSpan(ctx, name, AttrArticleLink.String(item.Link), AttrArticleTitle.String(item.Title))
+}
+
+// withArticleGUID は記事のGUIDを以降に作成するスパンの属性に加えるcontextを返す
+func withArticleGUID(ctx context.Context, guid string) context.Context {
+	return context.WithValue(ctx, articleGUIDKey{}, guid)
+}
+
+// startSpan はスパンを開始する（記事の処理中であれば記事のGUIDを属性に加える）
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(spanAttributes(ctx, attrs)...))
+}
+
+// spanAttributes は処理中の記事のGUIDを属性に加える
+func spanAttributes(ctx context.Context, attrs []attribute.KeyValue) []attribute.KeyValue {
+	if guid, ok := ctx.Value(articleGUIDKey{}).(string); ok && guid != "" {
+		attrs = append(attrs, AttrArticleGUID.String(guid))
+	}
+	return attrs
+}
+
+// endSpan はエラーをスパンに記録して終了する
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// redactURL はスパンの属性に記録できるよう、URLからユーザー情報・クエリ・フラグメントを取り除く
+// （フィードのURLはクエリやユーザー情報にトークンを含むことがある。解析できない場合は空文字列）
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// redactError はスパンに記録できるよう、エラーメッセージに含まれるURLをredactURLで置き換える
+// （http.Clientのエラーはパスワードを「***」に置き換えたURLを含むため、その形式も置き換える）
+func redactError(err error, rawURLs ...string) error {
+	if err == nil {
+		return nil
+	}
+	var pairs []string
+	for _, raw := range rawURLs {
+		redacted := redactURL(raw)
+		pairs = append(pairs, raw, redacted)
+		if u, parseErr := url.Parse(raw); parseErr == nil && u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				pairs = append(pairs, strings.Replace(u.String(), password+"@", "***@", 1), redacted)
+			}
+		}
+	}
+	return errors.New(strings.NewReplacer(pairs...).Replace(err.Error()))
+}
+
+// tracingTransport は外部APIへのHTTPリクエストごとにスパンを作成する
+// （Webhook URLのパスやクエリには秘密の値が含まれるため、属性にはホスト名のみを記録する）
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip はリクエストを送信し、メソッド・ホスト・ステータスコードをスパンに記録する
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	ctx, span := tracer().Start(ctx, "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(spanAttributes(ctx, []attribute.KeyValue{
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Hostname()),
+		})...))
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
+
+// instrumentHTTPClient はリクエストごとにスパンを作成するHTTPクライアントを返す（元のクライアントは変更しない）
+func instrumentHTTPClient(client *http.Client) *http.Client {
+	if _, ok := client.Transport.(*tracingTransport); ok {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	instrumented := *client
+	instrumented.Transport = &tracingTransport{base: base}
+	return &instrumented
+}
@@ -11,8 +11,10 @@ import (
 	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TranslatorService は翻訳サービスを管理する
@@ -22,8 +24,22 @@ type TranslatorService struct {
 	openAIClient  *openai.Client
 	openAIModel   string
 	httpClient    *http.Client
+	fallback      string // DeepLでの翻訳に失敗した場合の代替プロバイダー（空の場合は代替しない）
+	metrics       *Metrics
 }
 
+// 翻訳の代替プロバイダー
+const (
+	FallbackNone   = "none"   // 代替せず原文のまま通知する
+	FallbackOpenAI = "openai" // OpenAIで翻訳する
+)
+
+// maxSummaryContentLength は要約の元として送信する本文の最大文字数（OpenAIの課金対象のため）
+const maxSummaryContentLength = 4000
+
+// providerDeepL は翻訳文字数のメトリクスでDeepLを表すラベル（代替プロバイダーはFallbackOpenAI）
+const providerDeepL = "deepl"
+
 // DeepLRequest はDeepL APIのリクエスト構造体
 type DeepLRequest struct {
 	Text       []string `json:"text"`
@@ -41,50 +57,91 @@ type DeepLResponse struct {
 
 // TranslationResult は翻訳結果を表す構造体
 type TranslationResult struct {
-	OriginalTitle       string
-	TranslatedTitle     string
-	OriginalDescription string
-	TranslatedDescription string
-	Summary             string
-	Link                string
+	OriginalTitle         string       `json:"original_title"`
+	TranslatedTitle       string       `json:"translated_title"`
+	OriginalDescription   string       `json:"original_description"`
+	TranslatedDescription string       `json:"translated_description"`
+	Summary               string       `json:"summary"`
+	Link                  string       `json:"link"`
+	GUID                  string       `json:"guid,omitempty"`
+	Feed                  FeedInfo     `json:"feed"`
+	Published             time.Time    `json:"published"`
+	ImageURL              string       `json:"image_url,omitempty"` // 記事の代表画像
+	Relevance             *Relevance   `json:"relevance,omitempty"` // 関連度判定を行った場合のみ
+	Sources               []ItemSource `json:"sources,omitempty"`   // 重複をまとめた場合の全配信元
+	// 翻訳・要約の状態（翻訳に失敗した場合は原文、要約に失敗した場合は空のまま）
+	TranslationFailed bool   `json:"translation_failed,omitempty"`
+	SummaryFailed     bool   `json:"summary_failed,omitempty"`
+	FallbackProvider  string `json:"fallback_provider,omitempty"` // DeepLの代わりに翻訳したプロバイダー
+	TranslationError  string `json:"translation_error,omitempty"` // 翻訳に失敗した理由
+	SummaryError      string `json:"summary_error,omitempty"`     // 要約に失敗した理由
+}
+
+// Degraded は翻訳または要約に失敗した結果かを返す（代替プロバイダーでの翻訳は含まない）
+func (r *TranslationResult) Degraded() bool {
+	return r.TranslationFailed || r.SummaryFailed
 }
 
 // NewTranslatorService は新しいTranslatorServiceを作成する
-func NewTranslatorService(deepLAPIKey, deepLAPIURL, openAIAPIKey, openAIModel string) *TranslatorService {
+func NewTranslatorService(deepLAPIKey, deepLAPIURL, openAIAPIKey, openAIModel string, opts ...Option) *TranslatorService {
+	o := applyOptions(opts)
+	httpClient := instrumentHTTPClient(o.httpClient)
+
+	openAIConfig := openai.DefaultConfig(openAIAPIKey)
+	openAIConfig.HTTPClient = httpClient
+	if o.openAIBaseURL != "" {
+		openAIConfig.BaseURL = o.openAIBaseURL
+	}
+
 	return &TranslatorService{
 		deepLAPIKey:  deepLAPIKey,
 		deepLAPIURL:  deepLAPIURL,
-		openAIClient: openai.NewClient(openAIAPIKey),
+		openAIClient: openai.NewClientWithConfig(openAIConfig),
 		openAIModel:  openAIModel,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:   httpClient,
+		fallback:     o.translationFallback,
+		metrics:      o.metrics,
 	}
 }
 
 // TranslateAndSummarize は記事を翻訳し要約を生成する
-func (ts *TranslatorService) TranslateAndSummarize(item *FeedItem) (*TranslationResult, error) {
+// （翻訳・要約に失敗した場合は原文や空の要約を使い、失敗したことを結果に記録する）
+func (ts *TranslatorService) TranslateAndSummarize(item *FeedItem) *TranslationResult {
 	log.Printf("Translating and summarizing: %s", item.Title)
+	ctx, span := withArticle(context.Background(), "TranslatorService.TranslateAndSummarize", item)
+	defer span.End()
+
+	var translationErr, summaryErr error
+	var fallbackProvider string
 
 	// タイトルを翻訳
-	translatedTitle, err := ts.translateWithDeepL(item.Title)
+	translatedTitle, fallback, err := ts.translate(ctx, item.Title)
 	if err != nil {
 		log.Printf("Warning: Title translation failed, using original: %v", err)
 		translatedTitle = item.Title
+		translationErr = err
+	}
+	if fallback != "" {
+		fallbackProvider = fallback
 	}
 
 	// 説明文を翻訳
-	translatedDescription, err := ts.translateWithDeepL(item.Description)
+	translatedDescription, fallback, err := ts.translate(ctx, item.Description)
 	if err != nil {
 		log.Printf("Warning: Description translation failed, using original: %v", err)
 		translatedDescription = item.Description
+		translationErr = err
+	}
+	if fallback != "" {
+		fallbackProvider = fallback
 	}
 
-	// OpenAI APIで要約を生成
-	summary, err := ts.generateSummaryWithOpenAI(translatedTitle, translatedDescription)
+	// OpenAI APIで要約を生成（失敗した場合は空のまま、通知側で失敗したことを表示する）
+	summary, err := ts.generateSummaryWithOpenAI(ctx, translatedTitle, summaryContent(item, translatedDescription))
 	if err != nil {
 		log.Printf("Warning: Summary generation failed: %v", err)
-		summary = "要約の生成に失敗しました。"
+		summary = ""
+		summaryErr = err
 	}
 
 	result := &TranslationResult{
@@ -94,17 +151,55 @@ func (ts *TranslatorService) TranslateAndSummarize(item *FeedItem) (*Translation
 		TranslatedDescription: translatedDescription,
 		Summary:               summary,
 		Link:                  item.Link,
+		GUID:                  item.GUID,
+		Feed:                  item.Feed,
+		Published:             item.Published,
+		ImageURL:              item.ImageURL,
+		Relevance:             item.Relevance,
+		Sources:               item.Sources,
+		FallbackProvider:      fallbackProvider,
+	}
+	if translationErr != nil {
+		result.TranslationFailed = true
+		result.TranslationError = translationErr.Error()
+	}
+	if summaryErr != nil {
+		result.SummaryFailed = true
+		result.SummaryError = summaryErr.Error()
 	}
+	span.SetAttributes(
+		attribute.Bool("translation.failed", result.TranslationFailed),
+		attribute.Bool("summary.failed", result.SummaryFailed),
+		attribute.String("translation.fallback_provider", fallbackProvider),
+	)
 
 	log.Printf("Translation and summarization completed for: %s", item.Title)
-	return result, nil
+	return result
+}
+
+// translate はDeepLでテキストを翻訳し、失敗した場合は代替プロバイダーで翻訳する
+// （代替プロバイダーで翻訳した場合はその名前を返す）
+func (ts *TranslatorService) translate(ctx context.Context, text string) (string, string, error) {
+	translated, err := ts.translateWithDeepL(ctx, text)
+	if err == nil || ts.fallback != FallbackOpenAI {
+		return translated, "", err
+	}
+
+	log.Printf("Warning: DeepL translation failed, falling back to OpenAI: %v", err)
+	translated, fallbackErr := ts.translateWithOpenAI(ctx, text)
+	if fallbackErr != nil {
+		return "", "", fmt.Errorf("%v (fallback: %w)", err, fallbackErr)
+	}
+	return translated, FallbackOpenAI, nil
 }
 
 // translateWithDeepL はDeepL APIを使用してテキストを翻訳する
-func (ts *TranslatorService) translateWithDeepL(text string) (string, error) {
+func (ts *TranslatorService) translateWithDeepL(ctx context.Context, text string) (_ string, err error) {
 	if strings.TrimSpace(text) == "" {
 		return "", nil
 	}
+	ctx, span := startSpan(ctx, "DeepL translate", attribute.Int("translation.characters", utf8.RuneCountInString(text)))
+	defer func() { endSpan(span, err) }()
 
 	// リクエストボディを作成
 	reqBody := DeepLRequest{
@@ -119,7 +214,7 @@ func (ts *TranslatorService) translateWithDeepL(text string) (string, error) {
 	}
 
 	// HTTPリクエストを作成
-	req, err := http.NewRequest("POST", ts.deepLAPIURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", ts.deepLAPIURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -155,14 +250,17 @@ func (ts *TranslatorService) translateWithDeepL(text string) (string, error) {
 		return "", fmt.Errorf("no translations returned from DeepL")
 	}
 
+	ts.metrics.AddTranslationCharacters(providerDeepL, utf8.RuneCountInString(text))
 	return deepLResp.Translations[0].Text, nil
 }
 
 // translateWithDeepLFormData はDeepL APIをform-dataで呼び出す（代替実装）
-func (ts *TranslatorService) translateWithDeepLFormData(text string) (string, error) {
+func (ts *TranslatorService) translateWithDeepLFormData(ctx context.Context, text string) (_ string, err error) {
 	if strings.TrimSpace(text) == "" {
 		return "", nil
 	}
+	ctx, span := startSpan(ctx, "DeepL translate", attribute.Int("translation.characters", utf8.RuneCountInString(text)))
+	defer func() { endSpan(span, err) }()
 
 	// フォームデータを作成
 	data := url.Values{}
@@ -171,7 +269,7 @@ func (ts *TranslatorService) translateWithDeepLFormData(text string) (string, er
 	data.Set("source_lang", "EN")
 
 	// HTTPリクエストを作成
-	req, err := http.NewRequest("POST", ts.deepLAPIURL, strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", ts.deepLAPIURL, strings.NewReader(data.Encode()))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -207,11 +305,22 @@ func (ts *TranslatorService) translateWithDeepLFormData(text string) (string, er
 		return "", fmt.Errorf("no translations returned from DeepL")
 	}
 
+	ts.metrics.AddTranslationCharacters(providerDeepL, utf8.RuneCountInString(text))
 	return deepLResp.Translations[0].Text, nil
 }
 
+// summaryContent は要約の元にする内容を返す
+// （フィードに本文がある場合はHTMLを除去して切り詰めた本文、ない場合は翻訳した説明文）
+func summaryContent(item *FeedItem, translatedDescription string) string {
+	content := cleanText(item.Content)
+	if content == "" {
+		return translatedDescription
+	}
+	return truncateText(content, maxSummaryContentLength)
+}
+
 // generateSummaryWithOpenAI はOpenAI APIを使用して要約を生成する
-func (ts *TranslatorService) generateSummaryWithOpenAI(title, description string) (string, error) {
+func (ts *TranslatorService) generateSummaryWithOpenAI(ctx context.Context, title, content string) (string, error) {
 	// プロンプトを作成
 	prompt := fmt.Sprintf(`以下の技術記事の内容を、日本語で3行以内で要約してください。重要なポイントと学べる内容を含めて簡潔にまとめてください。
 
@@ -219,11 +328,10 @@ func (ts *TranslatorService) generateSummaryWithOpenAI(title, description string
 
 内容: %s
 
-要約:`, title, description)
+要約:`, title, content)
 
 	// OpenAI APIにリクエストを送信
-	resp, err := ts.openAIClient.CreateChatCompletion(
-		context.Background(),
+	resp, err := ts.chatCompletion(ctx, OpenAISummarize,
 		openai.ChatCompletionRequest{
 			Model: ts.openAIModel,
 			Messages: []openai.ChatCompletionMessage{
@@ -260,13 +368,62 @@ func (ts *TranslatorService) generateSummaryWithOpenAI(title, description string
 	return summary, nil
 }
 
+// translateWithOpenAI はOpenAI APIを使用してテキストを翻訳する（DeepLが利用できない場合の代替）
+func (ts *TranslatorService) translateWithOpenAI(ctx context.Context, text string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+
+	resp, err := ts.chatCompletion(ctx, OpenAITranslate,
+		openai.ChatCompletionRequest{
+			Model: ts.openAIModel,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleSystem,
+					Content: "あなたは英語の技術記事を日本語に翻訳する翻訳者です。与えられたテキストを自然な日本語に翻訳し、翻訳結果のみを出力してください。",
+				},
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: text,
+				},
+			},
+			MaxTokens:   1000,
+			Temperature: 0,
+		},
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to translate with OpenAI: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no translation returned from OpenAI")
+	}
+
+	ts.metrics.AddTranslationCharacters(FallbackOpenAI, utf8.RuneCountInString(text))
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// chatCompletion はOpenAI APIのチャット補完を呼び出し、スパンと使用トークン数を記録する
+func (ts *TranslatorService) chatCompletion(ctx context.Context, operation string, req openai.ChatCompletionRequest) (resp openai.ChatCompletionResponse, err error) {
+	ctx, span := startSpan(ctx, "OpenAI chat completion", attribute.String("openai.operation", operation), attribute.String("openai.model", req.Model))
+	defer func() { endSpan(span, err) }()
+
+	resp, err = ts.openAIClient.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("openai.prompt_tokens", resp.Usage.PromptTokens), attribute.Int("openai.completion_tokens", resp.Usage.CompletionTokens))
+	ts.metrics.AddOpenAITokens(operation, resp.Usage)
+	return resp, nil
+}
+
 // TestDeepLConnection はDeepL APIの接続をテストする
 func (ts *TranslatorService) TestDeepLConnection() error {
 	testText := "Hello, World!"
-	_, err := ts.translateWithDeepL(testText)
+	_, err := ts.translateWithDeepL(context.Background(), testText)
 	if err != nil {
 		// JSON形式で失敗した場合はform-data形式を試す
-		_, err2 := ts.translateWithDeepLFormData(testText)
+		_, err2 := ts.translateWithDeepLFormData(context.Background(), testText)
 		if err2 != nil {
 			return fmt.Errorf("DeepL connection test failed (JSON: %v, FormData: %v)", err, err2)
 		}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestTranslateAndSummarize(t *testing.T) {
+	deepL := fake.NewDeepLServer(t)
+	openAI := fake.NewOpenAIServer(t, "1行目\n2行目\n3行目\n4行目")
+
+	ts := NewTranslatorService("deepl-key", deepL.URL, "openai-key", "gpt-3.5-turbo", WithOpenAIBaseURL(openAI.BaseURL()))
+	item := &FeedItem{
+		Title:       "Hello",
+		Description: "World",
+		Link:        "https://example.com/post",
+		Published:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		Feed:        FeedInfo{URL: "https://example.com/feed", Name: "Example"},
+	}
+
+	result := ts.TranslateAndSummarize(item)
+
+	if result.TranslatedTitle != "[JA] Hello" || result.TranslatedDescription != "[JA] World" {
+		t.Errorf("translation = %q / %q", result.TranslatedTitle, result.TranslatedDescription)
+	}
+	// 要約は3行までに切り詰められる
+	if result.Summary != "1行目\n2行目\n3行目" {
+		t.Errorf("Summary = %q", result.Summary)
+	}
+	if result.Feed.Name != "Example" || !result.Published.Equal(item.Published) {
+		t.Errorf("feed metadata was not carried over: %+v", result)
+	}
+	if result.TranslationError != "" || result.SummaryError != "" {
+		t.Errorf("unexpected failure reasons: %q / %q", result.TranslationError, result.SummaryError)
+	}
+}
+
+func TestTranslateAndSummarizeUsesContent(t *testing.T) {
+	deepL := fake.NewDeepLServer(t)
+	openAI := fake.NewOpenAIServer(t, "")
+	var prompts []string
+	openAI.SetReplyFunc(func(prompt string) string {
+		prompts = append(prompts, prompt)
+		return "要約です。"
+	})
+
+	ts := NewTranslatorService("deepl-key", deepL.URL, "openai-key", "gpt-3.5-turbo", WithOpenAIBaseURL(openAI.BaseURL()))
+	// 本文がある場合はHTMLを除去して切り詰めた本文から要約する
+	long := strings.Repeat("word ", maxSummaryContentLength)
+	ts.TranslateAndSummarize(&FeedItem{Title: "Hello", Description: "Short.", Content: "<p>Full <b>content</b></p>" + long})
+	// 本文がない場合は翻訳した説明文から要約する
+	ts.TranslateAndSummarize(&FeedItem{Title: "Hello", Description: "Short."})
+
+	if len(prompts) != 2 {
+		t.Fatalf("got %d prompts, want 2", len(prompts))
+	}
+	if !strings.Contains(prompts[0], "内容: Full content") || strings.Contains(prompts[0], "<p>") || strings.Contains(prompts[0], "[JA] Short.") {
+		t.Errorf("prompt does not use the stripped content: %.200s", prompts[0])
+	}
+	if len([]rune(prompts[0])) > maxSummaryContentLength+200 {
+		t.Errorf("content was not truncated: %d characters", len([]rune(prompts[0])))
+	}
+	if !strings.Contains(prompts[1], "内容: [JA] Short.") {
+		t.Errorf("prompt does not fall back to the description: %s", prompts[1])
+	}
+}
+
+func TestTranslateAndSummarizeFallback(t *testing.T) {
+	deepL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", 456)
+	}))
+	defer deepL.Close()
+	openAI := httptest.NewServer(http.NotFoundHandler())
+	defer openAI.Close()
+
+	ts := NewTranslatorService("deepl-key", deepL.URL, "openai-key", "gpt-3.5-turbo", WithOpenAIBaseURL(openAI.URL+"/v1"))
+	result := ts.TranslateAndSummarize(&FeedItem{Title: "Hello", Description: "World"})
+
+	if result.TranslatedTitle != "Hello" {
+		t.Errorf("TranslatedTitle = %q, want original title", result.TranslatedTitle)
+	}
+	// 要約は既定の文言で埋めず、失敗したことをフラグで示す
+	if result.Summary != "" || !result.TranslationFailed || !result.SummaryFailed || !result.Degraded() {
+		t.Errorf("result = %+v, want translation and summary marked as failed", result)
+	}
+	if result.TranslationError == "" || result.SummaryError == "" {
+		t.Errorf("failure reasons were not recorded: %q / %q", result.TranslationError, result.SummaryError)
+	}
+	if result.FallbackProvider != "" {
+		t.Errorf("FallbackProvider = %q, want none", result.FallbackProvider)
+	}
+}
+
+func TestTranslateAndSummarizeOpenAIFallback(t *testing.T) {
+	deepL := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", 456)
+	}))
+	defer deepL.Close()
+	openAI := fake.NewOpenAIServer(t, "")
+	openAI.SetReplyFunc(func(prompt string) string {
+		if strings.Contains(prompt, "要約") {
+			return "要約です。"
+		}
+		return "[OpenAI] " + prompt
+	})
+
+	ts := NewTranslatorService("deepl-key", deepL.URL, "openai-key", "gpt-3.5-turbo",
+		WithOpenAIBaseURL(openAI.BaseURL()),
+		WithTranslationFallback(FallbackOpenAI),
+	)
+	result := ts.TranslateAndSummarize(&FeedItem{Title: "Hello", Description: "World"})
+
+	if result.TranslatedTitle != "[OpenAI] Hello" || result.TranslatedDescription != "[OpenAI] World" || result.Summary != "要約です。" {
+		t.Errorf("result = %+v, want translation by OpenAI", result)
+	}
+	if result.FallbackProvider != FallbackOpenAI || result.Degraded() {
+		t.Errorf("result = %+v, want fallback provider recorded without degradation", result)
+	}
+}
+
+func TestTestDeepLConnection(t *testing.T) {
+	deepL := fake.NewDeepLServer(t)
+
+	ts := NewTranslatorService("deepl-key", deepL.URL, "openai-key", "gpt-3.5-turbo")
+	if err := ts.TestDeepLConnection(); err != nil {
+		t.Errorf("TestDeepLConnection() error = %v", err)
+	}
+
+	text, err := ts.translateWithDeepLFormData(context.Background(), "Hello")
+	if err != nil || text != "[JA] Hello" {
+		t.Errorf("translateWithDeepLFormData() = %q, %v", text, err)
+	}
+}
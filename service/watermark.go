@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// FeedStateStore はフィードごとの処理済み位置（ウォーターマーク）を永続化する
+type FeedStateStore struct {
+	path  string
+	feeds map[string]*FeedState
+}
+
+// FeedState は1つのフィードの処理済み位置
+type FeedState struct {
+	Watermark time.Time `json:"watermark"` // 処理済みの記事の最新の公開日時（公開日時が取得時刻より未来の記事は含めない）
+	// UndatedSeen は日付のない記事のうち処理済みのもの（フィードから消えた記事は次回の更新で取り除かれる）
+	UndatedSeen []string `json:"undated_seen,omitempty"`
+	// WatermarkSeen は公開日時がウォーターマーク以降の処理済みの記事（同時刻の記事や公開日時が未来の記事）
+	WatermarkSeen []string `json:"watermark_seen,omitempty"`
+}
+
+// フィードごとの上限を超えた記事の扱い
+const (
+	OverflowDefer  = "defer"  // 古い記事から処理し、残りは次回の実行に繰り越す
+	OverflowNotice = "notice" // 新しい記事を処理し、残りは一覧のみを超過通知で知らせる
+)
+
+// CatchUpPolicy はウォーターマークに基づく新着判定の設定
+type CatchUpPolicy struct {
+	MaxCatchUp       time.Duration // 実行が途絶えた場合に遡る期間の上限
+	FirstRunBackfill int           // 初回実行時に通知する最新記事の件数（0の場合はすべて処理済みとして扱う）
+	Overflow         string        // 上限を超えた記事の扱い（OverflowDefer or OverflowNotice）
+}
+
+// NewFeedStateStore は状態ファイルを読み込んでFeedStateStoreを作成する
+func NewFeedStateStore(path string) (*FeedStateStore, error) {
+	ss := &FeedStateStore{
+		path:  path,
+		feeds: make(map[string]*FeedState),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ss, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &ss.feeds); err != nil {
+		return nil, fmt.Errorf("failed to parse feed state %s: %w", path, err)
+	}
+
+	return ss, nil
+}
+
+// Get はフィードの処理済み位置を返す（初回実行の場合はnil）
+func (ss *FeedStateStore) Get(feedURL string) *FeedState {
+	return ss.feeds[feedURL]
+}
+
+// Set はフィードの処理済み位置を更新する（Saveを呼ぶまでファイルには書き込まない）
+func (ss *FeedStateStore) Set(feedURL string, state *FeedState) {
+	ss.feeds[feedURL] = state
+}
+
+// Save は状態をファイルに書き込む
+func (ss *FeedStateStore) Save() error {
+	data, err := json.MarshalIndent(ss.feeds, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feed state: %w", err)
+	}
+	return writeFileAtomic(ss.path, data)
+}
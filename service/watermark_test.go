@@ -0,0 +1,265 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestFeedStateStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "feed_state.json")
+	watermark := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	store, err := NewFeedStateStore(path)
+	if err != nil {
+		t.Fatalf("NewFeedStateStore() error = %v", err)
+	}
+	if store.Get("https://example.com/feed") != nil {
+		t.Fatal("expected no state before the first run")
+	}
+	store.Set("https://example.com/feed", &FeedState{Watermark: watermark, UndatedSeen: []string{"a"}})
+	if err := store.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	reloaded, err := NewFeedStateStore(path)
+	if err != nil {
+		t.Fatalf("NewFeedStateStore() error = %v", err)
+	}
+	state := reloaded.Get("https://example.com/feed")
+	if state == nil || !state.Watermark.Equal(watermark) || len(state.UndatedSeen) != 1 {
+		t.Errorf("reloaded state = %+v", state)
+	}
+}
+
+func TestCheckForRecentItemsWatermark(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "Older", Link: "https://example.com/older", Published: now.Add(-3 * time.Hour)},
+		fake.Item{Title: "Newest", Link: "https://example.com/newest", Published: now.Add(-time.Hour)},
+		fake.Item{Title: "Undated", Link: "https://example.com/undated"},
+	)
+
+	store, err := NewFeedStateStore(filepath.Join(t.TempDir(), "feed_state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 10,
+		WithClock(clock),
+		WithFeedState(store, CatchUpPolicy{MaxCatchUp: 72 * time.Hour, FirstRunBackfill: 1}),
+	)
+	check := func() []string {
+		t.Helper()
+		items, err := fs.CheckForRecentItems()
+		if err != nil {
+			t.Fatalf("CheckForRecentItems() error = %v", err)
+		}
+		var titles []string
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+		return titles
+	}
+
+	// 初回は最新1件のみ（日付のない記事は既読扱い）
+	if got := check(); len(got) != 1 || got[0] != "Newest" {
+		t.Fatalf("first run = %v, want [Newest]", got)
+	}
+	if err := fs.CommitState(); err != nil {
+		t.Fatalf("CommitState() error = %v", err)
+	}
+
+	// 2回目は同じ記事を再度通知しない（日付のない記事も含む）
+	now = now.Add(24 * time.Hour)
+	if got := check(); len(got) != 0 {
+		t.Fatalf("second run = %v, want none", got)
+	}
+	fs.CommitState()
+
+	// 1日以上実行が途絶えても、ウォーターマーク以降の記事はすべて対象になる
+	feedServer.SetItems(
+		fake.Item{Title: "Missed", Link: "https://example.com/missed", Published: now.Add(time.Hour)},
+		fake.Item{Title: "Latest", Link: "https://example.com/latest", Published: now.Add(47 * time.Hour)},
+		fake.Item{Title: "New undated", Link: "https://example.com/new-undated"},
+		fake.Item{Title: "Undated", Link: "https://example.com/undated"},
+	)
+	now = now.Add(48 * time.Hour)
+	if got := check(); len(got) != 3 {
+		t.Fatalf("catch-up run = %v, want [Missed Latest New undated]", got)
+	}
+
+	// 通知に失敗してCommitStateしなかった場合は、次回も同じ記事を処理する
+	if got := check(); len(got) != 3 {
+		t.Fatalf("retry run = %v, want the same 3 items", got)
+	}
+
+	// 遡るのはMaxCatchUpまで
+	fs.CommitState()
+	store.Set(feedServer.URL, &FeedState{Watermark: now.Add(-30 * 24 * time.Hour)})
+	feedServer.SetItems(
+		fake.Item{Title: "Too old", Link: "https://example.com/too-old", Published: now.Add(-100 * time.Hour)},
+		fake.Item{Title: "Recent", Link: "https://example.com/recent", Published: now.Add(-70 * time.Hour)},
+	)
+	if got := check(); len(got) != 1 || got[0] != "Recent" {
+		t.Fatalf("capped catch-up = %v, want [Recent]", got)
+	}
+}
+
+func TestCheckForRecentItemsMarkSeenOnFirstRun(t *testing.T) {
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "New", Link: "https://example.com/new", Published: time.Now().Add(-time.Hour)},
+	)
+	store, err := NewFeedStateStore(filepath.Join(t.TempDir(), "feed_state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 10, WithFeedState(store, CatchUpPolicy{MaxCatchUp: 72 * time.Hour}))
+	items, err := fs.CheckForRecentItems()
+	if err != nil {
+		t.Fatalf("CheckForRecentItems() error = %v", err)
+	}
+	if len(items) != 0 {
+		t.Errorf("got %d items on first run, want 0", len(items))
+	}
+	if err := fs.CommitState(); err != nil {
+		t.Fatalf("CommitState() error = %v", err)
+	}
+	if store.Get(feedServer.URL) == nil {
+		t.Error("watermark was not recorded on first run")
+	}
+}
+
+func TestCheckForRecentItemsOverflow(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	items := []fake.Item{
+		{Title: "A", Link: "https://example.com/a", GUID: "a", Published: now.Add(-3 * time.Hour)},
+		{Title: "B", Link: "https://example.com/b", GUID: "b", Published: now.Add(-2 * time.Hour)},
+		{Title: "C", Link: "https://example.com/c", GUID: "c", Published: now.Add(-2 * time.Hour)},
+		{Title: "D", Link: "https://example.com/d", GUID: "d", Published: now.Add(-time.Hour)},
+	}
+	titles := func(items []*FeedItem) string {
+		var s []string
+		for _, item := range items {
+			s = append(s, item.Title)
+		}
+		return strings.Join(s, ",")
+	}
+	newService := func(policy string) *FeedService {
+		feedServer := fake.NewFeedServer(t, "Example Blog", items...)
+		store, err := NewFeedStateStore(filepath.Join(t.TempDir(), "feed_state.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 初回実行として扱わないよう、すべての記事より前の処理済み位置を設定しておく
+		store.Set(feedServer.URL, &FeedState{Watermark: now.Add(-4 * time.Hour)})
+		fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 2,
+			WithClock(func() time.Time { return now }),
+			WithFeedState(store, CatchUpPolicy{MaxCatchUp: 72 * time.Hour, Overflow: policy}),
+		)
+		return fs
+	}
+
+	t.Run("defer", func(t *testing.T) {
+		fs := newService(OverflowDefer)
+
+		// 古い順に上限まで処理し、残りは次回に繰り越す（同じ公開日時の記事も取りこぼさない）
+		for _, want := range []string{"A,B", "C,D", ""} {
+			got, err := fs.CheckForRecentItems()
+			if err != nil {
+				t.Fatalf("CheckForRecentItems() error = %v", err)
+			}
+			if titles(got) != want || len(fs.Overflows()) != 0 {
+				t.Fatalf("items = %q, overflows = %d; want %q and no overflow", titles(got), len(fs.Overflows()), want)
+			}
+			if err := fs.CommitState(); err != nil {
+				t.Fatalf("CommitState() error = %v", err)
+			}
+		}
+	})
+
+	t.Run("notice", func(t *testing.T) {
+		fs := newService(OverflowNotice)
+
+		// 新しい記事を処理し、古い記事は超過通知の対象として処理済みにする
+		got, err := fs.CheckForRecentItems()
+		if err != nil {
+			t.Fatalf("CheckForRecentItems() error = %v", err)
+		}
+		overflows := fs.Overflows()
+		if titles(got) != "C,D" || len(overflows) != 1 || titles(overflows[0].Items) != "A,B" {
+			t.Fatalf("items = %q, overflows = %+v", titles(got), overflows)
+		}
+		fs.CommitState()
+
+		if got, _ := fs.CheckForRecentItems(); len(got) != 0 || len(fs.Overflows()) != 0 {
+			t.Errorf("second run = %q, overflows = %d; want none", titles(got), len(fs.Overflows()))
+		}
+	})
+}
+
+func TestCheckForRecentItemsWatermarkFollowsPublished(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	feedServer := fake.NewFeedServer(t, "Example Blog",
+		fake.Item{Title: "Newest", Link: "https://example.com/newest", Published: now.Add(-time.Hour)},
+		fake.Item{Title: "Future", Link: "https://example.com/future", Published: now.Add(5 * time.Hour)},
+	)
+	store, err := NewFeedStateStore(filepath.Join(t.TempDir(), "feed_state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Set(feedServer.URL, &FeedState{Watermark: now.Add(-2 * time.Hour)})
+	fs := NewFeedService([]FeedConfig{{URL: feedServer.URL}}, 10,
+		WithClock(func() time.Time { return now }),
+		WithFeedState(store, CatchUpPolicy{MaxCatchUp: 72 * time.Hour}),
+	)
+	check := func() string {
+		t.Helper()
+		items, err := fs.CheckForRecentItems()
+		if err != nil {
+			t.Fatalf("CheckForRecentItems() error = %v", err)
+		}
+		if err := fs.CommitState(); err != nil {
+			t.Fatalf("CommitState() error = %v", err)
+		}
+		var titles []string
+		for _, item := range items {
+			titles = append(titles, item.Title)
+		}
+		return strings.Join(titles, ",")
+	}
+
+	if got := check(); got != "Newest,Future" {
+		t.Fatalf("first check = %q, want Newest,Future", got)
+	}
+	// 公開日時が未来の記事があっても、処理済み位置は取得時刻を超えない
+	if state := store.Get(feedServer.URL); !state.Watermark.Equal(now.Add(-time.Hour)) {
+		t.Errorf("watermark = %s, want %s", state.Watermark, now.Add(-time.Hour))
+	}
+
+	// 取得後にフィードに現れた、取得時刻より前の公開日時の記事も処理する
+	// 公開日時が未来の記事は再度処理しない
+	feedServer.SetItems(
+		fake.Item{Title: "Newest", Link: "https://example.com/newest", Published: now.Add(-time.Hour)},
+		fake.Item{Title: "Backdated", Link: "https://example.com/backdated", Published: now.Add(-10 * time.Minute)},
+		fake.Item{Title: "Future", Link: "https://example.com/future", Published: now.Add(5 * time.Hour)},
+	)
+	now = now.Add(time.Hour)
+	if got := check(); got != "Backdated" {
+		t.Fatalf("second check = %q, want Backdated", got)
+	}
+	now = now.Add(time.Hour)
+	if got := check(); got != "" {
+		t.Fatalf("third check = %q, want none", got)
+	}
+
+	// 公開日時を過ぎた後も再度処理しない
+	now = now.Add(6 * time.Hour)
+	if got := check(); got != "" {
+		t.Fatalf("check after the future date = %q, want none", got)
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// WebhookNotifier はテンプレートで生成したJSONを任意のURLにPOSTする
+// （本文の形式は通知先のテンプレートディレクトリで上書きして受信側に合わせる）
+type WebhookNotifier struct {
+	webhookURL string
+	httpClient *http.Client
+}
+
+// Send はテンプレートが生成したJSONをそのまま送信する（スレッドには対応しない）
+func (wn *WebhookNotifier) Send(ctx context.Context, message *Message, thread string) (string, error) {
+	status, body, err := postJSON(ctx, wn.httpClient, wn.webhookURL, message.Body)
+	if err != nil {
+		return "", err
+	}
+	if !isSuccess(status) {
+		return "", fmt.Errorf("webhook error: status=%d, body=%s", status, string(body))
+	}
+	return "", nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	server := fake.NewWebhookServer(t, http.StatusOK, "")
+	ns, err := NewNotificationService(server.URL, "", FormatBlocks, "", WithNotifierType(NotifierWebhook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.SetRunInfo(RunInfo{Destination: "partner"})
+
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, false); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	article, _ := messages[0]["article"].(map[string]interface{})
+	if messages[0]["event"] != "article" || messages[0]["destination"] != "partner" || article["translated_title"] != "翻訳タイトル" || article["link"] != "https://example.com/post?a=1&b=2" {
+		t.Errorf("message = %v", messages[0])
+	}
+}
+
+func TestWebhookNotifierCustomBody(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{"title": {{json .Result.TranslatedTitle}}, "url": {{json .Result.Link}}}`
+	if err := os.WriteFile(filepath.Join(dir, "article.json.tmpl"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := fake.NewWebhookServer(t, http.StatusCreated, `{"id": 1}`)
+	ns, err := NewNotificationService(server.URL, "", FormatBlocks, dir, WithNotifierType(NotifierWebhook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := deliverArticle(t, ns, sampleTemplateData().Result, false); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	// テンプレートが生成した本文をそのまま送信する
+	messages := server.Messages()
+	if len(messages) != 1 || len(messages[0]) != 2 || messages[0]["title"] != "翻訳タイトル" {
+		t.Errorf("messages = %v, want the templated body as-is", messages)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"rss-en-to-jp-notification/config"
+)
+
+// tracingServiceName はトレースのサービス名の既定値（OTEL_SERVICE_NAMEで上書きできる）
+const tracingServiceName = "rss-en-to-jp-notification"
+
+// tracingShutdownTimeout は終了時に未送信のスパンを送信する際のタイムアウト
+const tracingShutdownTimeout = 10 * time.Second
+
+// setupTracing はTRACING_ENABLEDの場合にOTLP（HTTP）でスパンを送信するTracerProviderを設定し、
+// 終了時に未送信のスパンを送信する関数を返す
+// （送信先はOTEL_EXPORTER_OTLP_ENDPOINTなどの標準の環境変数で指定し、既定はローカルのコレクター http://localhost:4318）
+func setupTracing(cfg *config.Config) (func(), error) {
+	if !cfg.TracingEnabled {
+		return func() {}, nil
+	}
+
+	ctx := context.Background()
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("OTLPエクスポーターの作成に失敗しました: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(tracingServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("トレースのリソースの作成に失敗しました: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	log.Println("トレースをOTLPで送信します")
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Printf("WARNING: トレースの送信に失敗しました: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+
+	"rss-en-to-jp-notification/config"
+	"rss-en-to-jp-notification/internal/fake"
+)
+
+func TestSetupTracing(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	// 無効の場合はTracerProviderを設定しない
+	shutdown, err := setupTracing(&config.Config{})
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	shutdown()
+	if otel.GetTracerProvider() != previous {
+		t.Error("setupTracing() set a tracer provider while tracing is disabled")
+	}
+
+	// OTLPのコレクターの代わりに受信したリクエストを記録する
+	var mu sync.Mutex
+	var paths []string
+	var received int
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		received += len(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+
+	env := newTestEnv(t,
+		fake.Item{Title: "Understanding Caches", Link: "https://example.com/caches", GUID: "1", Published: time.Now().Add(-time.Hour)},
+	)
+	app := newTestApp(t, env)
+	app.config.TracingEnabled = true
+	shutdown, err = setupTracing(app.config)
+	if err != nil {
+		t.Fatalf("setupTracing() error = %v", err)
+	}
+	app.RunOnce()
+	// 終了時に未送信のスパンをコレクターに送信する
+	shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) == 0 || paths[0] != "POST /v1/traces" || received == 0 {
+		t.Errorf("collector received %v (%d bytes), want spans posted to /v1/traces", paths, received)
+	}
+}